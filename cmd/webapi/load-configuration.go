@@ -21,6 +21,9 @@ type webAPIConfiguration struct {
 		ReadTimeout     time.Duration `conf:"default:60s"`
 		WriteTimeout    time.Duration `conf:"default:5s"`
 		ShutdownTimeout time.Duration `conf:"default:5s"`
+
+		// The URL where the users reach the web UI, used in the links sent by email
+		PublicUrl string `conf:"default:http://localhost:3000"`
 	}
 	Log struct {
 		Debug    bool   `conf:"default:true"`
@@ -37,6 +40,64 @@ type webAPIConfiguration struct {
 		// The virtual API path prefix to prepend to request a static file on this server
 		WebPrefix string `conf:"default:/static/user_content"`
 	}
+	// Setup the personal data exports
+	Exports struct {
+		// The local filesystem path where to store the archives.
+		// Unlike UserContent.FsDir, it must not be publicly served
+		Dir string `conf:"default:exports"`
+	}
+	// Setup the limits on username changes
+	Usernames struct {
+		// How long a released username is reserved to its previous owner,
+		// while it still leads to their profile
+		ReservationPeriod time.Duration `conf:"default:720h"`
+
+		// Minimum time between two username changes of the same user
+		ChangeInterval time.Duration `conf:"default:168h"`
+	}
+	// Setup how the uploaded images are processed
+	Images struct {
+		// Either "local", to process them in this server, or "tinypng", to use the TinyPNG service.
+		// The local processor only produces lossless images, a few times larger than the TinyPNG ones
+		Processor string `conf:"default:local"`
+
+		// Width of the processed images. Smaller images are never enlarged
+		Width int `conf:"default:500"`
+
+		// The API key of the TinyPNG service, required only by the "tinypng" processor
+		TinyPngToken string `conf:"mask"`
+	}
+	// Setup the first admin, who can then manage the roles of the other users
+	Admin struct {
+		// ID of the user to promote to admin at startup, only if there are no admins yet.
+		// Leave it empty to skip it
+		UserId string
+	}
+	// Setup how emails are sent
+	Mail struct {
+		// The SMTP server to send emails through.
+		// Leave it empty to save them as files in Directory instead (useful for local development)
+		SmtpHost     string
+		SmtpPort     int `conf:"default:587"`
+		SmtpUsername string
+		SmtpPassword string `conf:"mask"`
+
+		// The sender address of every email
+		From string `conf:"default:noreply@localhost"`
+
+		// Where to save emails if no SMTP server is set
+		Directory string `conf:"default:mail"`
+	}
+	// Setup the optional login through an external OpenID Connect provider
+	Oidc struct {
+		// The issuer URL of the provider. Leave it empty to disable this login method
+		Issuer       string
+		ClientId     string
+		ClientSecret string `conf:"mask"`
+
+		// Where the provider redirects the user after the login (usually, the web UI URL)
+		RedirectUrl string
+	}
 }
 
 // loadConfiguration creates a webAPIConfiguration starting from flags, environment variables and configuration file.
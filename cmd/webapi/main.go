@@ -29,7 +29,12 @@ import (
 	"fmt"
 	"github.com/ardanlabs/conf"
 	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/imaging"
 	"github.com/simonesestito/wasaphoto/service/ioc"
+	"github.com/simonesestito/wasaphoto/service/jobs"
+	"github.com/simonesestito/wasaphoto/service/mailer"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc"
 	"net/http"
 	"os"
 	"os/signal"
@@ -50,6 +55,7 @@ func main() {
 // * creates and configure the logger
 // * connects to any external resources (like databases, authenticators, etc.)
 // * creates an instance of the service/api package
+// * starts the background jobs
 // * starts the principal web server (using the service/api.Router.Handler() for HTTP handlers)
 // * waits for any termination event: SIGTERM signal (UNIX), non-recoverable server error, etc.
 // * closes the principal web server
@@ -65,20 +71,58 @@ func run() error {
 
 	logger := initLogging(cfg)
 
-	// Run HTTPS testing (it requires a third-party service)
-	mustPerformHttpsRequest(logger)
+	// Run HTTPS testing, only if a third-party service is going to be used
+	if cfg.Images.Processor == imaging.ProcessorTinyPng {
+		mustPerformHttpsRequest(logger)
+	}
 
 	logger.Infof("application initializing")
 	db, onClose := initDatabase(cfg, logger)
 	defer onClose()
 
 	// Initialize dependency injection Inversion of Control container
-	iocContainer, err := ioc.New(nil, logger, db, cfg.UserContent.FsDir, cfg.UserContent.WebPrefix)
+	iocContainer, err := ioc.New(nil, logger, db, cfg.UserContent.FsDir, cfg.UserContent.WebPrefix, ioc.Config{
+		Oidc: oidc.Config{
+			Issuer:       cfg.Oidc.Issuer,
+			ClientId:     cfg.Oidc.ClientId,
+			ClientSecret: cfg.Oidc.ClientSecret,
+			RedirectUrl:  cfg.Oidc.RedirectUrl,
+		},
+		Mail: mailer.Config{
+			SmtpHost:     cfg.Mail.SmtpHost,
+			SmtpPort:     cfg.Mail.SmtpPort,
+			SmtpUsername: cfg.Mail.SmtpUsername,
+			SmtpPassword: cfg.Mail.SmtpPassword,
+			From:         cfg.Mail.From,
+			Directory:    cfg.Mail.Directory,
+		},
+		PublicUrl:  cfg.Web.PublicUrl,
+		ExportsDir: cfg.Exports.Dir,
+		Usernames: user.UsernameConfig{
+			ReservationPeriod: cfg.Usernames.ReservationPeriod,
+			ChangeInterval:    cfg.Usernames.ChangeInterval,
+		},
+		Images: imaging.Config{
+			Processor:    cfg.Images.Processor,
+			Width:        cfg.Images.Width,
+			TinyPngToken: cfg.Images.TinyPngToken,
+		},
+	})
 	if err != nil {
 		logger.WithError(err).Error("error creating dependency container")
 		return fmt.Errorf("creating dependency container: %w", err)
 	}
 
+	// Bootstrap the first admin
+	if cfg.Admin.UserId != "" {
+		promoted, err := iocContainer.CreateRoleService().BootstrapAdmin(cfg.Admin.UserId)
+		if err != nil {
+			logger.WithError(err).Warnf("unable to promote %s to admin", cfg.Admin.UserId)
+		} else if promoted {
+			logger.Infof("user %s promoted to admin", cfg.Admin.UserId)
+		}
+	}
+
 	// Start (main) API server
 	logger.Info("initializing API server")
 
@@ -102,6 +146,11 @@ func run() error {
 	// Test storage
 	mustWriteToStorage(iocContainer.CreateStorage(), logger)
 
+	// Start background jobs
+	jobsRunner := jobs.NewRunner(iocContainer.CreateJobs(), logger)
+	jobsRunner.Start()
+	defer jobsRunner.Close()
+
 	// Static user content files (such as uploaded photos)
 	apiRouter.RegisterStatic(cfg.UserContent.FsDir, cfg.UserContent.WebPrefix)
 
@@ -123,6 +172,9 @@ func run() error {
 		ReadTimeout:       cfg.Web.ReadTimeout,
 		ReadHeaderTimeout: cfg.Web.ReadTimeout,
 		WriteTimeout:      cfg.Web.WriteTimeout,
+
+		// Allow long uploads and downloads to extend the timeouts
+		ConnContext: api.SaveConnection,
 	}
 
 	// Start the service listening for requests in a separate goroutine
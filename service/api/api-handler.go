@@ -6,6 +6,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/simonesestito/wasaphoto/service/api/route"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 	"reflect"
 )
@@ -26,10 +27,47 @@ import (
 // -- 'route.SecureRoute' [PUT] /users/:userId/username
 // -- 'route.SecureRoute' [GET] /users/
 //
+// - Avatar related endpoints are registered in features/user/avatar-controller.go (user.AvatarController#ListRoutes())
+// -- 'route.SecureRoute' [PUT] /users/:userId/avatar
+// -- 'route.SecureRoute' [DELETE] /users/:userId/avatar
+//
+// - Account deletion endpoints are registered in features/account/controller.go (account.Controller#ListRoutes())
+// -- 'route.SecureRoute' [DELETE] /users/:userId
+// -- 'route.SecureRoute' [GET] /users/:userId/deletion
+// -- 'route.SecureRoute' [DELETE] /users/:userId/deletion
+//
+// - Data export endpoints are registered in features/export/controller.go (export.Controller#ListRoutes())
+// -- 'route.SecureRoute' [POST] /users/:userId/export
+// -- 'route.SecureRoute' [GET] /users/:userId/export
+// -- 'route.SecureRoute' [POST] /users/:userId/export/links
+// -- 'route.AnonymousRoute' [GET] /data-exports/:token
+//
+// - Account import endpoints are registered in features/export/import-controller.go (export.ImportController#ListRoutes())
+// -- 'route.SecureRoute' [POST] /users/:userId/import
+//
+// - Role related endpoints are registered in features/user/role-controller.go (user.RoleController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/role
+// -- 'route.SecureRoute' [PUT] /users/:userId/role
+//
 // - Ban related endpoints are registered in features/user/ban-controller.go (user.BanController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/bannedPeople/
 // -- 'route.SecureRoute' [PUT] /users/:userId/bannedPeople/:bannedId
 // -- 'route.SecureRoute' [DELETE] /users/:userId/bannedPeople/:bannedId
 //
+// - Mute related endpoints are registered in features/user/mute-controller.go (user.MuteController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/mutedPeople/
+// -- 'route.SecureRoute' [GET] /users/:userId/mutedPeople/:mutedId
+// -- 'route.SecureRoute' [PUT] /users/:userId/mutedPeople/:mutedId
+// -- 'route.SecureRoute' [DELETE] /users/:userId/mutedPeople/:mutedId
+//
+// - Close friends related endpoints are registered in features/user/close-friend-controller.go (user.CloseFriendController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/closeFriends/
+// -- 'route.SecureRoute' [PUT] /users/:userId/closeFriends/:friendId
+// -- 'route.SecureRoute' [DELETE] /users/:userId/closeFriends/:friendId
+//
+// - Relationship related endpoints are registered in features/user/relationship-controller.go (user.RelationshipController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/relationships/:otherId
+//
 // - Stream related endpoints are registered in features/stream/controller.go (stream.Controller#ListRoutes())
 // -- 'route.SecureRoute' [GET] /users/:userId/stream
 //
@@ -47,6 +85,11 @@ import (
 // -- 'route.SecureRoute' [DELETE] /users/:userId/followings/:followedId
 // -- 'route.SecureRoute' [GET] /users/:userId/followers/
 // -- 'route.SecureRoute' [GET] /users/:userId/followings/
+// -- 'route.SecureRoute' [GET] /users/:userId/followRequests/
+// -- 'route.SecureRoute' [PUT] /users/:userId/followRequests/:requesterId
+// -- 'route.SecureRoute' [DELETE] /users/:userId/followRequests/:requesterId
+// -- 'route.SecureRoute' [GET] /users/:userId/suggestions
+// -- 'route.SecureRoute' [PUT] /users/:userId/privacy
 //
 // - Comments related endpoints are registered in features/comments/controller.go (comments.Controller#ListRoutes())
 // -- 'route.SecureRoute' [POST] /photos/:photoId/comments/
@@ -55,6 +98,36 @@ import (
 //
 // - Login/auth related endpoints are registered in features/auth/login-controller.go (auth.LoginController#ListRoutes())
 // -- 'route.AnonymousRoute' [POST] /session
+// -- 'route.SecureRoute' [DELETE] /session
+// -- 'route.AnonymousRoute' [POST] /users/
+// -- 'route.SecureRoute' [PUT] /users/:userId/password
+//
+// - Email related endpoints are registered in features/auth/email-controller.go (auth.EmailController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/email
+// -- 'route.SecureRoute' [PUT] /users/:userId/email
+// -- 'route.SecureRoute' [POST] /users/:userId/email/verification
+// -- 'route.AnonymousRoute' [POST] /email-verification
+// -- 'route.AnonymousRoute' [POST] /password-reset
+// -- 'route.AnonymousRoute' [PUT] /password-reset
+//
+// - Sessions related endpoints are registered in features/auth/session-controller.go (auth.SessionController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/sessions/
+// -- 'route.SecureRoute' [DELETE] /users/:userId/sessions/
+// -- 'route.SecureRoute' [DELETE] /users/:userId/sessions/:sessionId
+//
+// - TOTP related endpoints are registered in features/auth/totp-controller.go (auth.TotpController#ListRoutes())
+// -- 'route.SecureRoute' [POST] /users/:userId/totp
+// -- 'route.SecureRoute' [PUT] /users/:userId/totp
+// -- 'route.SecureRoute' [DELETE] /users/:userId/totp
+//
+// - Personal access tokens related endpoints are registered in features/auth/personal-token-controller.go (auth.PersonalTokenController#ListRoutes())
+// -- 'route.SecureRoute' [GET] /users/:userId/tokens/
+// -- 'route.SecureRoute' [POST] /users/:userId/tokens/
+// -- 'route.SecureRoute' [DELETE] /users/:userId/tokens/:tokenId
+//
+// - External login endpoints are registered in features/auth/oidc-controller.go (auth.OidcController#ListRoutes()), if enabled
+// -- 'route.AnonymousRoute' [POST] /session/oidc
+// -- 'route.AnonymousRoute' [POST] /session/oidc/callback
 func (router *_router) RegisterAll(controllers []route.Controller) error {
 	// Register routes
 	for _, controller := range controllers {
@@ -94,7 +167,7 @@ func (router *_router) Register(routeInfo route.Route) error {
 	case isAnonymous:
 		handler = anonymousRoute.Handler
 	case isSecure:
-		handler = router.authMiddleware.Intercept(secureRoute.Handler)
+		handler = router.authMiddleware.Intercept(secureRoute.Handler, secureRoute.Scope, secureRoute.RequiredRole)
 	default:
 		return fmt.Errorf("unknown route type: %s", reflect.TypeOf(routeInfo))
 	}
@@ -104,6 +177,11 @@ func (router *_router) Register(routeInfo route.Route) error {
 		handler = middleware(handler)
 	}
 
+	// The limit of the route must be applied first, to replace the default one
+	if isSecure && secureRoute.MaxBodySize > 0 {
+		handler = LimitBodySize(secureRoute.MaxBodySize)(handler)
+	}
+
 	// Register path and method
 	router.logger.Debugf(
 		"Registering route of type '%s' [%s] %s",
@@ -125,8 +203,15 @@ func (router *_router) wrap(handle route.Handler) httprouter.Handle {
 			return
 		}
 
+		remoteIp, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			remoteIp = r.RemoteAddr
+		}
+
 		var ctx = route.RequestContext{
-			ReqUUID: reqUUID,
+			ReqUUID:   reqUUID,
+			RemoteIp:  remoteIp,
+			UserAgent: r.UserAgent(),
 		}
 
 		// Create a request-specific logger
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+type connectionKey struct{}
+
+// SaveConnection stores the client connection in the context of its requests,
+// so that a handler can change its deadlines.
+// It must be used as the ConnContext of the http.Server.
+func SaveConnection(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connectionKey{}, conn)
+}
+
+// ErrNoConnection is returned when the connection has not been saved by SaveConnection
+var ErrNoConnection = errors.New("client connection not available")
+
+// ExtendReadDeadline overrides the ReadTimeout of the server for the current request,
+// for request bodies which take longer to be received, like file uploads.
+func ExtendReadDeadline(r *http.Request, timeout time.Duration) error {
+	conn, ok := r.Context().Value(connectionKey{}).(net.Conn)
+	if !ok {
+		return ErrNoConnection
+	}
+
+	return conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// ExtendWriteDeadline overrides the WriteTimeout of the server for the current request,
+// for responses which take longer to be sent, like file downloads.
+func ExtendWriteDeadline(r *http.Request, timeout time.Duration) error {
+	conn, ok := r.Context().Value(connectionKey{}).(net.Conn)
+	if !ok {
+		return ErrNoConnection
+	}
+
+	return conn.SetWriteDeadline(time.Now().Add(timeout))
+}
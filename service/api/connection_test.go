@@ -0,0 +1,43 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExtendWriteDeadline(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ExtendWriteDeadline(r, time.Minute); err != nil {
+			t.Error(err)
+		}
+
+		// Slower than the WriteTimeout of the server
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Config.ConnContext = SaveConnection
+	server.Start()
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil || string(body) != "done" {
+		t.Errorf("expected the whole response, got %q, %v", body, err)
+	}
+}
+
+func TestExtendWriteDeadlineWithoutConnection(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := ExtendWriteDeadline(request, time.Minute); err != ErrNoConnection {
+		t.Errorf("expected ErrNoConnection, got %v", err)
+	}
+}
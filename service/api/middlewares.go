@@ -3,16 +3,26 @@ package api
 import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/simonesestito/wasaphoto/service/api/route"
+	"io"
 	"net/http"
 )
 
 // LimitBodySize prevents spending too much time
-// responding to too long (potentially bad) requests
+// responding to too long (potentially bad) requests.
+// If the body has already been limited, the first limit is kept,
+// so that a route can override the default one.
 func LimitBodySize(maxBytes int64) route.Middleware {
 	return func(handler route.Handler) route.Handler {
 		return func(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.RequestContext) {
-			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			if _, isLimited := r.Body.(limitedBody); !isLimited {
+				r.Body = limitedBody{http.MaxBytesReader(w, r.Body, maxBytes)}
+			}
 			handler(w, r, params, context)
 		}
 	}
 }
+
+// limitedBody marks a request body already limited by LimitBodySize
+type limitedBody struct {
+	io.ReadCloser
+}
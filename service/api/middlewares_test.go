@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+)
+
+func TestLimitBodySizeOverride(t *testing.T) {
+	var readErr error
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.RequestContext) {
+		_, readErr = io.ReadAll(r.Body)
+	}
+
+	// As in the router, the limit of the route wraps the default one
+	limited := LimitBodySize(100)(LimitBodySize(10)(handler))
+
+	limited(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 50))), nil, route.RequestContext{})
+	if readErr != nil {
+		t.Errorf("expected the limit of the route to replace the default one, got %v", readErr)
+	}
+
+	limited(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 150))), nil, route.RequestContext{})
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) {
+		t.Errorf("expected http.MaxBytesError, got %v", readErr)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -132,6 +133,14 @@ func ValidateParsedStruct[T any](parsedStruct *T, logger logrus.FieldLogger) *Ma
 		return &MalformedRequestError{StatusCode: http.StatusInternalServerError, Message: err.Error()}
 	}
 
+	if err := validate.RegisterValidation("weburl", func(field validator.FieldLevel) bool {
+		// Only links which can be safely opened in a browser
+		parsedUrl, err := url.Parse(field.Field().String())
+		return err == nil && (parsedUrl.Scheme == "http" || parsedUrl.Scheme == "https") && parsedUrl.Host != ""
+	}); err != nil {
+		return &MalformedRequestError{StatusCode: http.StatusInternalServerError, Message: err.Error()}
+	}
+
 	if err := validate.Struct(parsedStruct); err != nil {
 		validationError := validator.ValidationErrors{}
 		if !errors.As(err, &validationError) {
@@ -22,5 +22,5 @@ type SecureHandler func(
 type Middleware = func(handler Handler) Handler
 
 type AuthMiddleware interface {
-	Intercept(handler SecureHandler) Handler
+	Intercept(handler SecureHandler, requiredScope Scope, requiredRole Role) Handler
 }
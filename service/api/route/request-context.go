@@ -16,11 +16,26 @@ type RequestContext struct {
 	// ReqUUID is the request unique ID
 	ReqUUID uuid.UUID
 
+	// RemoteIp is the IP address of the client, without the port
+	RemoteIp string
+
+	// UserAgent is the User-Agent header sent by the client
+	UserAgent string
+
 	// Logger is a custom field logger for the request
 	Logger logrus.FieldLogger
 }
 
 type SecureRequestContext struct {
 	UserId string
+
+	// SessionId is the ID of the session the auth token belongs to.
+	// It's empty when a personal access token is used,
+	// which can only happen on routes with a Scope.
+	SessionId string
+
+	// Role of the user performing the request
+	Role Role
+
 	RequestContext
 }
@@ -0,0 +1,39 @@
+package route
+
+// Role is the set of privileges a user has on the whole platform,
+// regardless of the data they own.
+type Role string
+
+const (
+	// RoleUser is the role of every ordinary user
+	RoleUser Role = "user"
+
+	// RoleModerator can also delete any photo or comment
+	RoleModerator Role = "moderator"
+
+	// RoleAdmin can also manage the roles of the other users
+	RoleAdmin Role = "admin"
+)
+
+// AllRoles lists every role, from the least to the most privileged
+var AllRoles = []Role{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+// Includes checks if this role has at least all the privileges of the other one
+func (role Role) Includes(other Role) bool {
+	return role.level() >= other.level()
+}
+
+func (role Role) level() int {
+	for i, knownRole := range AllRoles {
+		if role == knownRole {
+			return i
+		}
+	}
+
+	// Unknown roles have no privileges at all
+	return -1
+}
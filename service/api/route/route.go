@@ -28,6 +28,18 @@ type SecureRoute struct {
 	Method  string
 	Path    string
 	Handler SecureHandler
+
+	// Scope is required to a personal access token to use this route.
+	// If not specified, only user sessions are allowed.
+	Scope Scope
+
+	// RequiredRole is the minimum role the user must have to use this route.
+	// If not specified, every user is allowed.
+	RequiredRole Role
+
+	// MaxBodySize replaces the default limit of the request body size, in bytes.
+	// If not specified, the default one is used.
+	MaxBodySize int64
 }
 
 func (route SecureRoute) GetMethod() string { return route.Method }
@@ -0,0 +1,32 @@
+package route
+
+// Scope is a permission which can be granted to a personal access token.
+// Each SecureRoute declares the scope it requires.
+type Scope string
+
+const (
+	// ScopeSessionOnly is the zero value of Scope:
+	// the route can only be used with a user session, never with a personal access token.
+	ScopeSessionOnly Scope = ""
+
+	ScopeRead          Scope = "read"
+	ScopeProfileWrite  Scope = "profile:write"
+	ScopeBansWrite     Scope = "bans:write"
+	ScopeMutesWrite    Scope = "mutes:write"
+	ScopeFollowsWrite  Scope = "follows:write"
+	ScopePhotosWrite   Scope = "photos:write"
+	ScopeLikesWrite    Scope = "likes:write"
+	ScopeCommentsWrite Scope = "comments:write"
+)
+
+// AllScopes lists every scope which can be granted to a personal access token
+var AllScopes = []Scope{
+	ScopeRead,
+	ScopeProfileWrite,
+	ScopeBansWrite,
+	ScopeMutesWrite,
+	ScopeFollowsWrite,
+	ScopePhotosWrite,
+	ScopeLikesWrite,
+	ScopeCommentsWrite,
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/sirupsen/logrus"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func HandleErrorsResponse(err error, w http.ResponseWriter, defaultSuccessStatus int, logger logrus.FieldLogger) {
@@ -27,8 +30,27 @@ func HandleErrorsResponse(err error, w http.ResponseWriter, defaultSuccessStatus
 		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
 	case errors.Is(err, ErrOthersData):
 		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrPrivateAccount):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrWrongCredentials):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrWrongPassword):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrSecondFactorRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrWrongOtp):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
 	case errors.Is(err, ErrThirdParty):
 		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrTooManyRequests):
+		var retryAfterErr RetryAfterError
+		if errors.As(err, &retryAfterErr) {
+			retryAfterSeconds := math.Max(1, math.Ceil(retryAfterErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfterSeconds)))
+		}
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
 	case errors.Is(err, nil):
 		w.WriteHeader(defaultSuccessStatus)
 	default:
@@ -76,6 +98,11 @@ var ErrWrongCursor = errors.New("wrong page cursor format")
 // by the owner of that data.
 var ErrUserBanned = errors.New("forbidden because of user ban")
 
+// ErrPrivateAccount is used in case the current user has no permission
+// to read the requested information because it belongs to a private account
+// they are not following.
+var ErrPrivateAccount = errors.New("forbidden because the account is private")
+
 // ErrMedia indicates a wrong media type
 var ErrMedia = errors.New("wrong media content supplied")
 
@@ -87,3 +114,39 @@ var ErrOthersData = errors.New("you are only allowed to operate on data of yours
 // even if this server works as expected,
 // it needs a third-party service which is currently not working.
 var ErrThirdParty = errors.New("a third-party service required to fulfill the request is not available")
+
+// ErrWrongCredentials indicates the supplied credentials don't match any user.
+// It deliberately doesn't tell if the username or the password is wrong.
+var ErrWrongCredentials = errors.New("wrong username or password")
+
+// ErrWrongPassword indicates an already authenticated user
+// supplied a password which is not their current one.
+var ErrWrongPassword = errors.New("wrong current password")
+
+// ErrSecondFactorRequired indicates the password is right,
+// but the user must also supply a one-time password to log in.
+var ErrSecondFactorRequired = errors.New("second factor required")
+
+// ErrWrongOtp indicates the supplied one-time password is not valid
+var ErrWrongOtp = errors.New("wrong one-time password")
+
+// ErrAlreadyEnabled is used if a user tries to enable something that's already enabled
+var ErrAlreadyEnabled = errors.New("already enabled")
+
+// ErrTooManyRequests indicates the client is performing too many attempts,
+// so it must wait before trying again.
+var ErrTooManyRequests = errors.New("too many attempts, try again later")
+
+// RetryAfterError is an ErrTooManyRequests which also tells
+// how long the client must wait before trying again.
+type RetryAfterError struct {
+	RetryAfter time.Duration
+}
+
+func (err RetryAfterError) Error() string {
+	return ErrTooManyRequests.Error()
+}
+
+func (err RetryAfterError) Unwrap() error {
+	return ErrTooManyRequests
+}
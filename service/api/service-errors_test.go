@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHandleErrorsResponseRetryAfter(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// Seconds are rounded up, and never less than 1
+	testCases := map[time.Duration]string{
+		15 * time.Second:        "15",
+		1500 * time.Millisecond: "2",
+		200 * time.Millisecond:  "1",
+		15 * time.Minute:        "900",
+	}
+
+	for retryAfter, expected := range testCases {
+		recorder := httptest.NewRecorder()
+		HandleErrorsResponse(fmt.Errorf("wrapped: %w", RetryAfterError{RetryAfter: retryAfter}), recorder, http.StatusOK, logger)
+
+		if recorder.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, recorder.Code)
+		}
+		if header := recorder.Header().Get("Retry-After"); header != expected {
+			t.Errorf("Retry-After for %v is %q, expected %q", retryAfter, header, expected)
+		}
+	}
+}
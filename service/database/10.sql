@@ -0,0 +1,24 @@
+--
+-- Login through external OpenID Connect providers
+--
+
+-- Link between a user of an external provider and a local user
+CREATE TABLE IF NOT EXISTS ExternalIdentity
+(
+	issuer       TEXT NOT NULL,
+	subject      TEXT NOT NULL,
+	userId       BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	creationDate TEXT NOT NULL,
+	PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS ExternalIdentityUser ON ExternalIdentity (userId);
+
+-- Logins started but not completed yet
+CREATE TABLE IF NOT EXISTS OidcLoginState
+(
+	state          TEXT NOT NULL PRIMARY KEY,
+	codeVerifier   TEXT NOT NULL,
+	nonce          TEXT NOT NULL,
+	expirationDate TEXT NOT NULL
+);
@@ -0,0 +1,14 @@
+--
+-- Brute-force protection of the login
+--
+
+-- Recent failed logins, counted per username and per remote IP address
+CREATE TABLE IF NOT EXISTS LoginFailure
+(
+	kind            TEXT    NOT NULL CHECK (kind IN ('username', 'ip')),
+	subject         TEXT    NOT NULL,
+	failures        INTEGER NOT NULL,
+	lastFailureDate TEXT    NOT NULL,
+	lockedUntilDate TEXT    NOT NULL,
+	PRIMARY KEY (kind, subject)
+);
@@ -0,0 +1,142 @@
+--
+-- Account deletion
+--
+
+-- SQLite can't alter a foreign key, so Photo must be rebuilt
+-- to delete the photos of a user along with it.
+-- Since foreign keys are enforced during migrations, dropping the old Photo table
+-- would also delete comments and likes: rebuild them too, referencing the new table,
+-- before dropping the old ones.
+-- Views must be dropped first, since they reference the tables being renamed.
+DROP VIEW IF EXISTS CommentIdWithAuthorAndPhoto;
+DROP VIEW IF EXISTS CommentWithAuthor;
+DROP VIEW IF EXISTS PhotoAuthorInfo;
+DROP VIEW IF EXISTS PhotoInfo;
+DROP VIEW IF EXISTS PhotoComments;
+DROP VIEW IF EXISTS PhotoLikes;
+DROP VIEW IF EXISTS UserInfo;
+DROP VIEW IF EXISTS UserPhotosCount;
+
+CREATE TABLE PhotoNew
+(
+	id          BLOB NOT NULL PRIMARY KEY,
+	imageUrl    TEXT NOT NULL,
+	authorId    BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	publishDate TEXT NOT NULL
+);
+INSERT INTO PhotoNew (id, imageUrl, authorId, publishDate)
+SELECT id, imageUrl, authorId, publishDate
+FROM Photo;
+
+CREATE TABLE CommentNew
+(
+	id          BLOB NOT NULL PRIMARY KEY,
+	`text`      TEXT NOT NULL,
+	publishDate TEXT NOT NULL,
+	authorId    BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	photoId     BLOB NOT NULL REFERENCES PhotoNew (id) ON DELETE CASCADE
+);
+INSERT INTO CommentNew (id, `text`, publishDate, authorId, photoId)
+SELECT id, `text`, publishDate, authorId, photoId
+FROM Comment;
+
+CREATE TABLE LikesNew
+(
+	userId  BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	photoId BLOB NOT NULL REFERENCES PhotoNew (id) ON DELETE CASCADE,
+	PRIMARY KEY (userId, photoId)
+);
+INSERT INTO LikesNew (userId, photoId)
+SELECT userId, photoId
+FROM Likes;
+
+DROP TABLE Likes;
+DROP TABLE Comment;
+DROP TABLE Photo;
+
+-- Renaming also updates the references in CommentNew and LikesNew
+ALTER TABLE PhotoNew RENAME TO Photo;
+ALTER TABLE CommentNew RENAME TO Comment;
+ALTER TABLE LikesNew RENAME TO Likes;
+
+CREATE INDEX IF NOT EXISTS PhotoAuthor ON Photo (authorId);
+
+-- Recreate the views, as they were
+CREATE VIEW UserPhotosCount AS
+SELECT User.id AS authorId, COALESCE(COUNT(Photo.id), 0) AS photosCount
+FROM User
+		 LEFT JOIN Photo on User.id = Photo.authorId
+GROUP BY User.id;
+
+CREATE VIEW UserInfo AS
+SELECT User.id,
+	   User.name,
+	   User.surname,
+	   User.username,
+	   followersCount,
+	   followingsCount,
+	   photosCount
+FROM User
+		 LEFT JOIN Followers ON Followers.followedId = User.id
+		 LEFT JOIN Followings ON Followings.followerId = User.id
+		 LEFT JOIN UserPhotosCount ON UserPhotosCount.authorId = User.id;
+
+CREATE VIEW PhotoLikes AS
+SELECT Photo.id AS photoId, COALESCE(COUNT(Likes.userId), 0) AS likesCount
+FROM Photo
+		 LEFT JOIN Likes on Photo.id = Likes.photoId
+GROUP BY Photo.id;
+
+CREATE VIEW PhotoComments AS
+SELECT Photo.id AS photoId, COALESCE(COUNT(Comment.id), 0) AS commentsCount
+FROM Photo
+		 LEFT JOIN Comment on Photo.id = Comment.photoId
+GROUP BY Photo.id;
+
+CREATE VIEW PhotoInfo AS
+SELECT Photo.*,
+	   PhotoLikes.likesCount,
+	   PhotoComments.commentsCount
+FROM Photo
+		 LEFT JOIN PhotoLikes ON Photo.id = PhotoLikes.photoId
+		 LEFT JOIN PhotoComments ON Photo.id = PhotoComments.photoId;
+
+CREATE VIEW PhotoAuthorInfo AS
+SELECT PhotoInfo.*,
+	   U.name,
+	   U.surname,
+	   U.username,
+	   U.followersCount,
+	   U.followingsCount,
+	   U.photosCount
+FROM PhotoInfo
+		 LEFT JOIN UserInfo U on PhotoInfo.authorId = U.id;
+
+CREATE VIEW CommentWithAuthor AS
+SELECT Comment.*,
+	   UserInfo.name,
+	   UserInfo.surname,
+	   UserInfo.username,
+	   UserInfo.followersCount,
+	   UserInfo.followingsCount,
+	   UserInfo.photosCount
+FROM Comment
+		 LEFT JOIN UserInfo ON UserInfo.id = Comment.authorId;
+
+CREATE VIEW CommentIdWithAuthorAndPhoto AS
+SELECT Comment.id       AS commentId,
+	   Comment.authorId AS commentAuthorId,
+	   Photo.id         AS photoId,
+	   Photo.authorId   AS photoAuthorId
+FROM Comment
+		 LEFT JOIN Photo on Comment.photoId = Photo.id;
+
+-- Accounts which will be deleted at the end of the grace period, unless cancelled
+CREATE TABLE IF NOT EXISTS AccountDeletion
+(
+	userId       BLOB NOT NULL PRIMARY KEY REFERENCES User (id) ON DELETE CASCADE,
+	requestDate  TEXT NOT NULL,
+	deletionDate TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS AccountDeletionDate ON AccountDeletion (deletionDate);
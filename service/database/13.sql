@@ -0,0 +1,5 @@
+--
+-- Role-based access control
+--
+
+ALTER TABLE User ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
@@ -0,0 +1,22 @@
+--
+-- Email verification and password reset
+--
+
+-- Users created before this migration don't have an email yet (NULL)
+ALTER TABLE User ADD COLUMN email TEXT;
+ALTER TABLE User ADD COLUMN emailVerified INTEGER NOT NULL DEFAULT FALSE;
+
+-- ALTER TABLE can't add a UNIQUE column, but multiple NULL values are allowed anyway
+CREATE UNIQUE INDEX IF NOT EXISTS UserEmail ON User (email);
+
+-- Single-use tokens sent by email
+CREATE TABLE IF NOT EXISTS EmailToken
+(
+	tokenHash      BLOB NOT NULL PRIMARY KEY,
+	userId         BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	purpose        TEXT NOT NULL CHECK (purpose IN ('verification', 'passwordReset')),
+	email          TEXT NOT NULL, -- Address the token has been sent to
+	expirationDate TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS EmailTokenUser ON EmailToken (userId);
@@ -0,0 +1,48 @@
+--
+-- Profile pictures: NULL means the user has no avatar
+--
+
+ALTER TABLE User ADD COLUMN avatarUrl TEXT;
+
+-- Expose the avatar wherever a user is shown
+DROP VIEW IF EXISTS CommentWithAuthor;
+DROP VIEW IF EXISTS PhotoAuthorInfo;
+DROP VIEW IF EXISTS UserInfo;
+
+CREATE VIEW UserInfo AS
+SELECT User.id,
+	   User.name,
+	   User.surname,
+	   User.username,
+	   COALESCE(User.avatarUrl, '') AS avatarUrl,
+	   followersCount,
+	   followingsCount,
+	   photosCount
+FROM User
+		 LEFT JOIN Followers ON Followers.followedId = User.id
+		 LEFT JOIN Followings ON Followings.followerId = User.id
+		 LEFT JOIN UserPhotosCount ON UserPhotosCount.authorId = User.id;
+
+CREATE VIEW PhotoAuthorInfo AS
+SELECT PhotoInfo.*,
+	   U.name,
+	   U.surname,
+	   U.username,
+	   U.avatarUrl,
+	   U.followersCount,
+	   U.followingsCount,
+	   U.photosCount
+FROM PhotoInfo
+		 LEFT JOIN UserInfo U on PhotoInfo.authorId = U.id;
+
+CREATE VIEW CommentWithAuthor AS
+SELECT Comment.*,
+	   UserInfo.name,
+	   UserInfo.surname,
+	   UserInfo.username,
+	   UserInfo.avatarUrl,
+	   UserInfo.followersCount,
+	   UserInfo.followingsCount,
+	   UserInfo.photosCount
+FROM Comment
+		 LEFT JOIN UserInfo ON UserInfo.id = Comment.authorId;
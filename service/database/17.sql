@@ -0,0 +1,72 @@
+--
+-- Private accounts, whose followers must be approved
+--
+
+ALTER TABLE User ADD COLUMN private INTEGER NOT NULL DEFAULT FALSE;
+
+-- Pending requests to follow a private account
+CREATE TABLE IF NOT EXISTS FollowRequest
+(
+	requesterId BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	targetId    BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	requestDate TEXT NOT NULL,
+	PRIMARY KEY (requesterId, targetId),
+	CHECK (requesterId != targetId)
+);
+
+CREATE INDEX IF NOT EXISTS FollowRequestTarget ON FollowRequest (targetId);
+
+-- Expose the flag wherever a user is shown
+DROP VIEW IF EXISTS CommentWithAuthor;
+DROP VIEW IF EXISTS PhotoAuthorInfo;
+DROP VIEW IF EXISTS UserInfo;
+
+CREATE VIEW UserInfo AS
+SELECT User.id,
+	   User.name,
+	   User.surname,
+	   User.username,
+	   User.bio,
+	   User.website,
+	   User.pronouns,
+	   User.private,
+	   COALESCE(User.avatarUrl, '') AS avatarUrl,
+	   followersCount,
+	   followingsCount,
+	   photosCount
+FROM User
+		 LEFT JOIN Followers ON Followers.followedId = User.id
+		 LEFT JOIN Followings ON Followings.followerId = User.id
+		 LEFT JOIN UserPhotosCount ON UserPhotosCount.authorId = User.id;
+
+CREATE VIEW PhotoAuthorInfo AS
+SELECT PhotoInfo.*,
+	   U.name,
+	   U.surname,
+	   U.username,
+	   U.bio,
+	   U.website,
+	   U.pronouns,
+	   U.private,
+	   U.avatarUrl,
+	   U.followersCount,
+	   U.followingsCount,
+	   U.photosCount
+FROM PhotoInfo
+		 LEFT JOIN UserInfo U on PhotoInfo.authorId = U.id;
+
+CREATE VIEW CommentWithAuthor AS
+SELECT Comment.*,
+	   UserInfo.name,
+	   UserInfo.surname,
+	   UserInfo.username,
+	   UserInfo.bio,
+	   UserInfo.website,
+	   UserInfo.pronouns,
+	   UserInfo.private,
+	   UserInfo.avatarUrl,
+	   UserInfo.followersCount,
+	   UserInfo.followingsCount,
+	   UserInfo.photosCount
+FROM Comment
+		 LEFT JOIN UserInfo ON UserInfo.id = Comment.authorId;
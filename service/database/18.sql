@@ -0,0 +1,6 @@
+--
+-- Full-text search on users
+--
+
+-- The UserSearch index requires SQLite to be compiled with FTS5 (go build tag "sqlite_fts5"),
+-- so it's created at startup only if available. See fulltext.go
@@ -0,0 +1,9 @@
+--
+-- Indexes for the follow suggestions
+--
+
+-- Followers of a user (the primary key only covers the followings)
+CREATE INDEX IF NOT EXISTS FollowFollowed ON Follow (followedId, followerId);
+
+-- Users who liked a photo (the primary key only covers the likes of a user)
+CREATE INDEX IF NOT EXISTS LikesPhoto ON Likes (photoId, userId);
@@ -0,0 +1,11 @@
+--
+-- Muted users, whose photos and comments are hidden to who muted them
+--
+
+CREATE TABLE IF NOT EXISTS Mute
+(
+	muterId BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	mutedId BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	PRIMARY KEY (muterId, mutedId),
+	CHECK (muterId != mutedId)
+);
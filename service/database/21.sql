@@ -0,0 +1,6 @@
+--
+-- Date of each ban, to list them
+--
+
+-- Unknown for the bans made before this migration
+ALTER TABLE Ban ADD COLUMN banDate TEXT;
@@ -0,0 +1,16 @@
+--
+-- Close friends, and the audience of each photo
+--
+
+-- Users each user chose as close friends
+CREATE TABLE IF NOT EXISTS CloseFriend
+(
+	ownerId  BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	friendId BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	PRIMARY KEY (ownerId, friendId),
+	CHECK (ownerId != friendId)
+);
+
+-- Who can see a photo, other than its author
+ALTER TABLE Photo ADD COLUMN visibility TEXT NOT NULL DEFAULT 'everyone'
+	CHECK (visibility IN ('everyone', 'followers', 'closeFriends'));
@@ -0,0 +1,18 @@
+--
+-- Personal data export
+--
+
+-- Archives with all the data of a user, built in the background
+CREATE TABLE IF NOT EXISTS DataExport
+(
+	id                 BLOB NOT NULL PRIMARY KEY,
+	userId             BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	status             TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+	requestDate        TEXT NOT NULL,
+	completionDate     TEXT,
+	expirationDate     TEXT, -- When the archive is deleted, once ready
+	linkTokenHash      BLOB UNIQUE, -- Hash of the token in the current download link, if any
+	linkExpirationDate TEXT
+);
+
+CREATE INDEX IF NOT EXISTS DataExportUser ON DataExport (userId);
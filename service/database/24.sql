@@ -0,0 +1,13 @@
+--
+-- Account import
+--
+
+-- New IDs given to the imported photos and comments whose original ID was already taken,
+-- so that importing the same archive again doesn't duplicate them
+CREATE TABLE IF NOT EXISTS ImportedId
+(
+	userId     BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	originalId BLOB NOT NULL,
+	newId      BLOB NOT NULL,
+	PRIMARY KEY (userId, originalId)
+);
@@ -0,0 +1,41 @@
+--
+-- Temporary account deactivation
+--
+
+-- A deactivated user is hidden to everyone else, until they log in again
+ALTER TABLE User ADD COLUMN deactivated INTEGER NOT NULL DEFAULT FALSE;
+
+-- Don't count follows, likes and comments of deactivated users.
+-- The views using these ones don't need to be recreated, since they're resolved by name.
+DROP VIEW IF EXISTS Followers;
+DROP VIEW IF EXISTS Followings;
+DROP VIEW IF EXISTS PhotoLikes;
+DROP VIEW IF EXISTS PhotoComments;
+
+CREATE VIEW Followers AS
+SELECT User.id AS followedId, COALESCE(COUNT(Follower.id), 0) AS followersCount
+FROM User
+		 LEFT JOIN Follow ON User.id = Follow.followedId
+		 LEFT JOIN User Follower ON Follower.id = Follow.followerId AND NOT Follower.deactivated
+GROUP BY User.id;
+
+CREATE VIEW Followings AS
+SELECT User.id AS followerId, COALESCE(COUNT(Followed.id), 0) AS followingsCount
+FROM User
+		 LEFT JOIN Follow ON User.id = Follow.followerId
+		 LEFT JOIN User Followed ON Followed.id = Follow.followedId AND NOT Followed.deactivated
+GROUP BY User.id;
+
+CREATE VIEW PhotoLikes AS
+SELECT Photo.id AS photoId, COALESCE(COUNT(Liker.id), 0) AS likesCount
+FROM Photo
+		 LEFT JOIN Likes ON Photo.id = Likes.photoId
+		 LEFT JOIN User Liker ON Liker.id = Likes.userId AND NOT Liker.deactivated
+GROUP BY Photo.id;
+
+CREATE VIEW PhotoComments AS
+SELECT Photo.id AS photoId, COALESCE(COUNT(Author.id), 0) AS commentsCount
+FROM Photo
+		 LEFT JOIN Comment ON Photo.id = Comment.photoId
+		 LEFT JOIN User Author ON Author.id = Comment.authorId AND NOT Author.deactivated
+GROUP BY Photo.id;
@@ -0,0 +1,16 @@
+--
+-- Username changes history
+--
+
+-- Usernames released by each user.
+-- Until reservedUntil, nobody else can take it, and it still leads to the user who released it.
+CREATE TABLE IF NOT EXISTS UsernameChange
+(
+	userId        BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	username      TEXT NOT NULL,
+	changeDate    TEXT NOT NULL,
+	reservedUntil TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS UsernameChangeUsername ON UsernameChange (username, reservedUntil);
+CREATE INDEX IF NOT EXISTS UsernameChangeUser ON UsernameChange (userId, changeDate);
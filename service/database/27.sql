@@ -0,0 +1,30 @@
+--
+-- Multiple renditions (sizes) of each photo
+--
+
+-- Every rendition of a photo. The full one has the same URL as Photo.imageUrl.
+-- Photos uploaded before this table was introduced don't have any:
+-- their only image is Photo.imageUrl, whose size is unknown.
+CREATE TABLE IF NOT EXISTS PhotoImage
+(
+	photoId BLOB    NOT NULL REFERENCES Photo (id) ON DELETE CASCADE,
+	size    TEXT    NOT NULL,
+	url     TEXT    NOT NULL,
+	width   INTEGER NOT NULL,
+	height  INTEGER NOT NULL,
+	PRIMARY KEY (photoId, size)
+);
+
+-- Expose the renditions along with the photo, as a JSON array from the smallest one.
+-- The views using this one don't need to be recreated, since they're resolved by name.
+DROP VIEW IF EXISTS PhotoInfo;
+
+CREATE VIEW PhotoInfo AS
+SELECT Photo.*,
+	   PhotoLikes.likesCount,
+	   PhotoComments.commentsCount,
+	   (SELECT json_group_array(json_object('size', I.size, 'url', I.url, 'width', I.width, 'height', I.height))
+		FROM (SELECT * FROM PhotoImage WHERE PhotoImage.photoId = Photo.id ORDER BY width) I) AS images
+FROM Photo
+		 LEFT JOIN PhotoLikes ON Photo.id = PhotoLikes.photoId
+		 LEFT JOIN PhotoComments ON Photo.id = PhotoComments.photoId;
@@ -0,0 +1,21 @@
+--
+-- Rate limit of the emails sent on request
+--
+
+-- Emails sent to an address, and requested from an IP address, are counted like failed logins.
+-- SQLite can't alter a CHECK constraint, so the table must be rebuilt.
+CREATE TABLE LoginFailureNew
+(
+	kind            TEXT    NOT NULL CHECK (kind IN ('username', 'ip', 'emailAddress', 'emailIp')),
+	subject         TEXT    NOT NULL,
+	failures        INTEGER NOT NULL,
+	lastFailureDate TEXT    NOT NULL,
+	lockedUntilDate TEXT    NOT NULL,
+	PRIMARY KEY (kind, subject)
+);
+INSERT INTO LoginFailureNew (kind, subject, failures, lastFailureDate, lockedUntilDate)
+SELECT kind, subject, failures, lastFailureDate, lockedUntilDate
+FROM LoginFailure;
+
+DROP TABLE LoginFailure;
+ALTER TABLE LoginFailureNew RENAME TO LoginFailure;
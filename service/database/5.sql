@@ -0,0 +1,15 @@
+--
+-- Authentication sessions
+--
+
+CREATE TABLE IF NOT EXISTS Session
+(
+	id             BLOB NOT NULL PRIMARY KEY,
+	tokenHash      BLOB NOT NULL UNIQUE,
+	userId         BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	creationDate   TEXT NOT NULL,
+	lastUseDate    TEXT NOT NULL,
+	expirationDate TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS SessionUser ON Session (userId);
@@ -0,0 +1,22 @@
+--
+-- Password based authentication
+--
+
+-- Users created before this migration don't have a password yet (NULL)
+ALTER TABLE User ADD COLUMN passwordHash TEXT;
+
+-- Aggregate all useful information about a user,
+-- explicitly listing the columns to avoid exposing credentials
+DROP VIEW IF EXISTS UserInfo;
+CREATE VIEW UserInfo AS
+SELECT User.id,
+	   User.name,
+	   User.surname,
+	   User.username,
+	   followersCount,
+	   followingsCount,
+	   photosCount
+FROM User
+		 LEFT JOIN Followers ON Followers.followedId = User.id
+		 LEFT JOIN Followings ON Followings.followerId = User.id
+		 LEFT JOIN UserPhotosCount ON UserPhotosCount.authorId = User.id;
@@ -0,0 +1,6 @@
+--
+-- Keep track of the device which is using each session
+--
+
+ALTER TABLE Session ADD COLUMN userAgent TEXT NOT NULL DEFAULT '';
+ALTER TABLE Session ADD COLUMN remoteIp TEXT NOT NULL DEFAULT '';
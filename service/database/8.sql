@@ -0,0 +1,21 @@
+--
+-- TOTP two-factor authentication
+--
+
+-- A TOTP secret is pending until the user confirms it with a valid code
+CREATE TABLE IF NOT EXISTS Totp
+(
+	userId       BLOB    NOT NULL PRIMARY KEY REFERENCES User (id) ON DELETE CASCADE,
+	secret       BLOB    NOT NULL,
+	confirmed    INTEGER NOT NULL DEFAULT FALSE,
+	lastUsedStep INTEGER NOT NULL DEFAULT 0,
+	creationDate TEXT    NOT NULL
+);
+
+-- Single-use codes to log in when the authenticator app is not available
+CREATE TABLE IF NOT EXISTS TotpRecoveryCode
+(
+	userId   BLOB NOT NULL REFERENCES Totp (userId) ON DELETE CASCADE,
+	codeHash BLOB NOT NULL,
+	PRIMARY KEY (userId, codeHash)
+);
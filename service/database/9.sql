@@ -0,0 +1,16 @@
+--
+-- Personal access tokens, for bots and integrations
+--
+
+CREATE TABLE IF NOT EXISTS PersonalToken
+(
+	id           BLOB NOT NULL PRIMARY KEY,
+	userId       BLOB NOT NULL REFERENCES User (id) ON DELETE CASCADE,
+	name         TEXT NOT NULL,
+	tokenHash    BLOB NOT NULL UNIQUE,
+	scopes       TEXT NOT NULL, -- Space separated list
+	creationDate TEXT NOT NULL,
+	lastUseDate  TEXT NOT NULL DEFAULT '' -- Empty if never used
+);
+
+CREATE INDEX IF NOT EXISTS PersonalTokenUser ON PersonalToken (userId);
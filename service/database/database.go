@@ -14,6 +14,7 @@ type AppDatabase interface {
 	Exec(query string, args ...any) error
 	ExecRows(query string, args ...any) (int64, error)
 	BeginTx() (transaction, error)
+	HasFullTextSearch() bool
 }
 
 var ErrNoResult = sql.ErrNoRows
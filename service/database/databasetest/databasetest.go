@@ -0,0 +1,37 @@
+// Package databasetest provides a real database to the tests of the DAOs and the services.
+package databasetest
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/sirupsen/logrus"
+)
+
+// New creates a new empty database, with all the migrations applied,
+// which is deleted at the end of the test
+func New(t *testing.T) database.AppDatabase {
+	t.Helper()
+
+	dbConn, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "wasaphoto.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = dbConn.Close()
+	})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	appDatabase, err := database.New(dbConn, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return appDatabase
+}
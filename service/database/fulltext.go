@@ -0,0 +1,106 @@
+package database
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// userSearchSetup creates the full-text search index on users, along with the triggers
+// which keep it in sync with the User table.
+// The trigram tokenizer allows to match any part of a word, like LIKE '%text%' did.
+//
+// Rows are matched by the user ID, since the implicit rowid of the User table
+// can change at any time (e.g.: after a VACUUM).
+const userSearchSetup = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS UserSearch USING fts5
+	(
+		id UNINDEXED,
+		username,
+		name,
+		surname,
+		tokenize = 'trigram'
+	);
+
+	DELETE FROM UserSearch;
+	INSERT INTO UserSearch (id, username, name, surname)
+	SELECT id, username, name, surname
+	FROM User;
+
+	CREATE TRIGGER UserSearchInsert
+		AFTER INSERT
+		ON User
+	BEGIN
+		INSERT INTO UserSearch (id, username, name, surname)
+		VALUES (NEW.id, NEW.username, NEW.name, NEW.surname);
+	END;
+
+	CREATE TRIGGER UserSearchUpdate
+		AFTER UPDATE OF username, name, surname
+		ON User
+	BEGIN
+		UPDATE UserSearch
+		SET username = NEW.username,
+			name     = NEW.name,
+			surname  = NEW.surname
+		WHERE id = NEW.id;
+	END;
+
+	CREATE TRIGGER UserSearchDelete
+		AFTER DELETE
+		ON User
+	BEGIN
+		DELETE FROM UserSearch WHERE id = OLD.id;
+	END;
+`
+
+// userSearchTeardown removes the triggers, which would make every change on User fail
+// if SQLite has been compiled without FTS5.
+const userSearchTeardown = `
+	DROP TRIGGER IF EXISTS UserSearchInsert;
+	DROP TRIGGER IF EXISTS UserSearchUpdate;
+	DROP TRIGGER IF EXISTS UserSearchDelete;
+`
+
+// setupFullTextSearch enables the full-text search on users, if SQLite supports FTS5
+// (go build tag "sqlite_fts5"), returning whether it's available.
+//
+// The index is built from scratch only when its triggers are missing:
+// the first time, or if it's been used without FTS5 in the meantime.
+func (db appSqlDatabase) setupFullTextSearch(logger logrus.FieldLogger) (bool, error) {
+	var available bool
+	err := db.DB.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available)
+	if err != nil {
+		return false, err
+	}
+
+	if !available {
+		logger.Warn("SQLite has been compiled without FTS5 (go build tag \"sqlite_fts5\"), users search will be slower")
+		_, err := db.DB.Exec(userSearchTeardown)
+		return false, err
+	}
+
+	var triggersCount int
+	err = db.DB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'UserSearch%'").Scan(&triggersCount)
+	if err != nil {
+		return false, err
+	} else if triggersCount == 3 {
+		return true, nil
+	}
+
+	logger.Info("Building the users search index")
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(userSearchTeardown + userSearchSetup); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// HasFullTextSearch tells if the UserSearch full-text index is available
+func (db appSqlDatabase) HasFullTextSearch() bool {
+	return db.FullTextSearch
+}
@@ -19,17 +19,27 @@ func New(db *sqlx.DB, logger logrus.FieldLogger) (AppDatabase, error) {
 		return nil, err
 	}
 
+	fullTextSearch, err := appDatabase.setupFullTextSearch(logger)
+	if err != nil {
+		return nil, err
+	}
+	appDatabase.FullTextSearch = fullTextSearch
+
 	return appDatabase, nil
 }
 
 type appSqlDatabase struct {
 	DB     databaseInterface // This may be a *sqlx.DB or *sqlx.Tx
 	PingDB pingableDatabase
+
+	// FullTextSearch is set when SQLite supports FTS5
+	FullTextSearch bool
 }
 
 type databaseInterface interface {
 	Exec(query string, args ...any) (sql.Result, error)
 	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 	Queryx(query string, args ...any) (*sqlx.Rows, error)
 }
 
@@ -0,0 +1,112 @@
+package account
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"net/http"
+)
+
+type Controller struct {
+	Service Service
+}
+
+func (controller Controller) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId",
+			Handler: controller.scheduleDeletion,
+		},
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/deletion",
+			Handler: controller.getDeletion,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/deletion",
+			Handler: controller.cancelDeletion,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/deactivation",
+			Handler: controller.deactivate,
+		},
+	}
+}
+
+func (controller Controller) scheduleDeletion(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	deletion, err := controller.Service.ScheduleDeletion(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusAccepted, context.Logger)
+	} else {
+		api.SendJson(w, deletion, http.StatusAccepted, context.Logger)
+	}
+}
+
+func (controller Controller) getDeletion(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	deletion, err := controller.Service.GetDeletion(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else if deletion == nil {
+		http.Error(w, "no deletion scheduled", http.StatusNotFound)
+	} else {
+		api.SendJson(w, deletion, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller Controller) cancelDeletion(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.CancelDeletion(args.UserId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+func (controller Controller) deactivate(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.Deactivate(args.UserId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
@@ -0,0 +1,118 @@
+package account
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+type Dao interface {
+	InsertDeletion(deletion entityAccountDeletion) error
+	GetDeletion(userUuid uuid.UUID) (*entityAccountDeletion, error)
+	DeleteDeletion(userUuid uuid.UUID) (bool, error)
+	GetExpiredDeletions(now string) ([]entityAccountDeletion, error)
+	DeleteUser(userUuid uuid.UUID) error
+	DeactivateUser(userUuid uuid.UUID) (bool, error)
+}
+
+type DbDao struct {
+	Db database.AppDatabase
+}
+
+func (db DbDao) InsertDeletion(deletion entityAccountDeletion) error {
+	return db.Db.Exec("INSERT INTO AccountDeletion (userId, requestDate, deletionDate) VALUES (?, ?, ?)",
+		deletion.UserId,
+		deletion.RequestDate,
+		deletion.DeletionDate,
+	)
+}
+
+func (db DbDao) GetDeletion(userUuid uuid.UUID) (*entityAccountDeletion, error) {
+	deletion := &entityAccountDeletion{}
+	err := db.Db.QueryStructRow(deletion, "SELECT * FROM AccountDeletion WHERE userId = ?", userUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return deletion, nil
+	}
+}
+
+func (db DbDao) DeleteDeletion(userUuid uuid.UUID) (bool, error) {
+	rows, err := db.Db.ExecRows("DELETE FROM AccountDeletion WHERE userId = ?", userUuid.Bytes())
+	return rows > 0, err
+}
+
+func (db DbDao) GetExpiredDeletions(now string) ([]entityAccountDeletion, error) {
+	rows, err := db.Db.QueryStructRows(entityAccountDeletion{}, "SELECT * FROM AccountDeletion WHERE deletionDate <= ?", now)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deletions []entityAccountDeletion
+		entity    any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		deletion, ok := entity.(entityAccountDeletion)
+		if !ok {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+		deletions = append(deletions, deletion)
+	}
+	if !errors.Is(err, database.ErrNoResult) {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// DeleteUser deletes the user and, thanks to foreign keys,
+// everything related to it: photos, comments, likes, follows, bans, sessions, etc.
+func (db DbDao) DeleteUser(userUuid uuid.UUID) error {
+	return db.Db.Exec("DELETE FROM User WHERE id = ?", userUuid.Bytes())
+}
+
+// DeactivateUser hides the user to everyone else, logs out all their sessions
+// and revokes their personal access tokens,
+// so that they must log in again to reactivate the account.
+// It returns false if the user doesn't exist.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) DeactivateUser(userUuid uuid.UUID) (bool, error) {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec("UPDATE User SET deactivated = TRUE WHERE id = ?", userUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+	if found, err := hasAffectedRows(result); err != nil || !found {
+		return false, err
+	}
+
+	_, err = tx.Exec("DELETE FROM Session WHERE userId = ?", userUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec("DELETE FROM PersonalToken WHERE userId = ?", userUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func hasAffectedRows(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
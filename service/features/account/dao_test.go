@@ -0,0 +1,42 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+)
+
+func TestDeactivateUserRevokesTokens(t *testing.T) {
+	dao := DbDao{Db: databasetest.New(t)}
+	userId := uuid.Must(uuid.NewV4())
+
+	err := dao.Db.Exec("INSERT INTO User (id, name, surname, username) VALUES (?, 'John', 'Doe', 'john_doe')", userId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dao.Db.Exec("INSERT INTO Session (id, userId, tokenHash, creationDate, lastUseDate, expirationDate) VALUES (?, ?, X'01', '2023-01-15 12:00:00', '2023-01-15 12:00:00', '2023-02-15 12:00:00')", uuid.Must(uuid.NewV4()).Bytes(), userId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dao.Db.Exec("INSERT INTO PersonalToken (id, userId, name, tokenHash, scopes, creationDate) VALUES (?, ?, 'bot', X'02', 'read', '2023-01-15 12:00:00')", uuid.Must(uuid.NewV4()).Bytes(), userId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := dao.DeactivateUser(userId); err != nil || !found {
+		t.Fatalf("expected the user to be deactivated, got %v, %v", found, err)
+	}
+
+	var result struct {
+		Sessions int64 `json:"sessions"`
+		Tokens   int64 `json:"tokens"`
+	}
+	err = dao.Db.QueryStructRow(&result, "SELECT (SELECT COUNT(*) FROM Session WHERE userId = ?) AS sessions, (SELECT COUNT(*) FROM PersonalToken WHERE userId = ?) AS tokens", userId.Bytes(), userId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Sessions != 0 || result.Tokens != 0 {
+		t.Errorf("%d sessions and %d personal tokens left", result.Sessions, result.Tokens)
+	}
+}
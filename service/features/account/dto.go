@@ -0,0 +1,6 @@
+package account
+
+type accountDeletion struct {
+	RequestDate  string `json:"requestDate"`
+	DeletionDate string `json:"deletionDate"`
+}
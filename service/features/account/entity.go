@@ -0,0 +1,15 @@
+package account
+
+// entityAccountDeletion is the entity for the AccountDeletion database table
+type entityAccountDeletion struct {
+	UserId       []byte `json:"userId"`
+	RequestDate  string `json:"requestDate"`
+	DeletionDate string `json:"deletionDate"`
+}
+
+func (entity entityAccountDeletion) toDto() accountDeletion {
+	return accountDeletion{
+		RequestDate:  entity.RequestDate,
+		DeletionDate: entity.DeletionDate,
+	}
+}
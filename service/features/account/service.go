@@ -0,0 +1,156 @@
+package account
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/export"
+	"github.com/simonesestito/wasaphoto/service/features/photo"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"time"
+)
+
+type Service interface {
+	ScheduleDeletion(userId string) (accountDeletion, error)
+	GetDeletion(userId string) (*accountDeletion, error)
+	CancelDeletion(userId string) error
+	DeleteExpiredAccounts() error
+	Deactivate(userId string) error
+}
+
+// deletionGracePeriod is how long the user has to change their mind,
+// before the account is actually deleted
+const deletionGracePeriod = 14 * 24 * time.Hour
+
+type ServiceImpl struct {
+	Db            Dao
+	PhotoService  photo.Service
+	AvatarService user.AvatarService
+	ExportService export.Service
+	Time          timeprovider.TimeProvider
+}
+
+// ScheduleDeletion requests the deletion of the account at the end of the grace period.
+// If the deletion has already been requested, the existing one is returned.
+func (service ServiceImpl) ScheduleDeletion(userId string) (accountDeletion, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return accountDeletion{}, api.ErrWrongUUID
+	}
+
+	now := service.Time.Now()
+	deletion := entityAccountDeletion{
+		UserId:       userUuid.Bytes(),
+		RequestDate:  timeprovider.DateToUTCString(now),
+		DeletionDate: timeprovider.DateToUTCString(now.Add(deletionGracePeriod)),
+	}
+
+	err := service.Db.InsertDeletion(deletion)
+	switch {
+	case errors.Is(err, database.ErrDuplicated):
+		existing, err := service.Db.GetDeletion(userUuid)
+		if err != nil {
+			return accountDeletion{}, err
+		} else if existing == nil {
+			// Cancelled in the meantime
+			return accountDeletion{}, api.ErrNotFound
+		}
+		return existing.toDto(), nil
+	case errors.Is(err, database.ErrForeignKey):
+		return accountDeletion{}, api.ErrNotFound
+	case err != nil:
+		return accountDeletion{}, err
+	default:
+		return deletion.toDto(), nil
+	}
+}
+
+// GetDeletion returns the scheduled deletion of the account, or nil if there's none
+func (service ServiceImpl) GetDeletion(userId string) (*accountDeletion, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return nil, api.ErrWrongUUID
+	}
+
+	deletion, err := service.Db.GetDeletion(userUuid)
+	if err != nil || deletion == nil {
+		return nil, err
+	}
+
+	dto := deletion.toDto()
+	return &dto, nil
+}
+
+// CancelDeletion cancels the scheduled deletion, or returns api.ErrNotFound if there's none
+func (service ServiceImpl) CancelDeletion(userId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	found, err := service.Db.DeleteDeletion(userUuid)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredAccounts deletes the accounts whose grace period is over.
+//
+// Photo, avatar and export files are deleted first, so if something fails,
+// the account is still there and its deletion will be retried on the next run.
+// It goes on with the other accounts anyway, returning the first error encountered.
+func (service ServiceImpl) DeleteExpiredAccounts() error {
+	deletions, err := service.Db.GetExpiredDeletions(service.Time.UTCString())
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, deletion := range deletions {
+		if err := service.deleteAccount(uuid.FromBytesOrNil(deletion.UserId)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Deactivate hides the account to everyone else, until the user logs in again.
+// All the sessions and personal access tokens are revoked, including the current session.
+func (service ServiceImpl) Deactivate(userId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	found, err := service.Db.DeactivateUser(userUuid)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+func (service ServiceImpl) deleteAccount(userUuid uuid.UUID) error {
+	if err := service.PhotoService.DeleteAllFilesOf(userUuid.String()); err != nil {
+		return err
+	}
+
+	if err := service.AvatarService.DeleteAvatar(userUuid.String()); err != nil {
+		return err
+	}
+
+	if err := service.ExportService.DeleteAllExportsOf(userUuid.String()); err != nil {
+		return err
+	}
+
+	return service.Db.DeleteUser(userUuid)
+}
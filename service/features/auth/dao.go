@@ -0,0 +1,633 @@
+package auth
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+)
+
+type Dao interface {
+	InsertUserWithPassword(newUser user.ModelUser, passwordHash string, email string, now string) error
+	GetCredentialsByUsername(username string) (*entityUserCredentials, error)
+	GetCredentialsById(userUuid uuid.UUID) (*entityUserCredentials, error)
+	GetUserRole(userUuid uuid.UUID) (string, error)
+	UpdatePasswordHashRevokingAccess(userUuid uuid.UUID, passwordHash string, exceptSessionUuid uuid.UUID) error
+	ReactivateUser(userUuid uuid.UUID) error
+	InsertSession(session entitySession) error
+	GetSessionByTokenHash(tokenHash []byte, now string) (*entitySession, error)
+	UpdateSessionUsage(sessionUuid uuid.UUID, lastUseDate string, expirationDate string, userAgent string, remoteIp string) error
+	GetUserSessions(userUuid uuid.UUID, now string) ([]entitySession, error)
+	DeleteSession(sessionUuid uuid.UUID) error
+	DeleteUserSession(userUuid uuid.UUID, sessionUuid uuid.UUID) (bool, error)
+	DeleteExpiredSessions(now string) error
+	RevokeUserAccessExcept(userUuid uuid.UUID, exceptSessionUuid uuid.UUID) error
+	GetTotp(userUuid uuid.UUID) (*entityTotp, error)
+	SetPendingTotp(totp entityTotp) error
+	ConfirmTotp(userUuid uuid.UUID, usedStep int64, recoveryCodeHashes [][]byte) error
+	UseTotpStep(userUuid uuid.UUID, usedStep int64) (bool, error)
+	UseTotpRecoveryCode(userUuid uuid.UUID, codeHash []byte) (bool, error)
+	DeleteTotp(userUuid uuid.UUID) error
+	InsertPersonalToken(token entityPersonalToken) error
+	GetPersonalTokenByHash(tokenHash []byte) (*entityPersonalToken, error)
+	GetUserPersonalTokens(userUuid uuid.UUID) ([]entityPersonalToken, error)
+	UpdatePersonalTokenUsage(tokenUuid uuid.UUID, lastUseDate string) error
+	DeleteUserPersonalToken(userUuid uuid.UUID, tokenUuid uuid.UUID) (bool, error)
+	InsertOidcLoginState(loginState entityOidcLoginState) error
+	ConsumeOidcLoginState(state string, now string) (*entityOidcLoginState, error)
+	DeleteExpiredOidcLoginStates(now string) error
+	GetExternalIdentityUserId(issuer string, subject string) (uuid.UUID, error)
+	InsertUserWithExternalIdentity(newUser user.ModelUser, issuer string, subject string, now string) error
+	GetLoginFailure(kind string, subject string) (*entityLoginFailure, error)
+	IncrementLoginFailures(kind string, subject string, now string) (int64, error)
+	ExtendLoginLockout(kind string, subject string, lockedUntil string) error
+	DeleteLoginFailure(kind string, subject string) error
+	DeleteStaleLoginFailures(before string) error
+	GetUserEmail(userUuid uuid.UUID) (*entityUserEmail, error)
+	GetUserIdByVerifiedEmail(email string) (uuid.UUID, error)
+	SetUserEmail(userUuid uuid.UUID, email string) error
+	SetEmailVerified(userUuid uuid.UUID, email string) (bool, error)
+	InsertEmailToken(token entityEmailToken) error
+	ConsumeEmailToken(tokenHash []byte, purpose string, now string) (*entityEmailToken, error)
+	DeleteUserEmailTokens(userUuid uuid.UUID, purpose string) error
+	DeleteExpiredEmailTokens(now string) error
+}
+
+type DbDao struct {
+	Db database.AppDatabase
+}
+
+// InsertUserWithPassword creates the user, or returns database.ErrDuplicated
+// if the username is taken, or reserved by who recently released it.
+func (db DbDao) InsertUserWithPassword(newUser user.ModelUser, passwordHash string, email string, now string) error {
+	query := `
+		INSERT INTO User (id, name, surname, username, passwordHash, email)
+		SELECT ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS(SELECT * FROM UsernameChange WHERE username = ? AND reservedUntil > ?)`
+	rows, err := db.Db.ExecRows(query,
+		newUser.Id,
+		newUser.Name,
+		newUser.Surname,
+		newUser.Username,
+		passwordHash,
+		email,
+		newUser.Username,
+		now,
+	)
+	if err == nil && rows == 0 {
+		return database.ErrDuplicated
+	}
+	return err
+}
+
+func (db DbDao) GetCredentialsByUsername(username string) (*entityUserCredentials, error) {
+	return db.getCredentials("username = ?", username)
+}
+
+func (db DbDao) GetCredentialsById(userUuid uuid.UUID) (*entityUserCredentials, error) {
+	return db.getCredentials("id = ?", userUuid.Bytes())
+}
+
+func (db DbDao) GetUserRole(userUuid uuid.UUID) (string, error) {
+	var result struct {
+		Role string `json:"role"`
+	}
+	err := db.Db.QueryStructRow(&result, "SELECT role FROM User WHERE id = ?", userUuid.Bytes())
+	return result.Role, err
+}
+
+func (db DbDao) getCredentials(condition string, args ...any) (*entityUserCredentials, error) {
+	query := `
+		SELECT id, COALESCE(passwordHash, '') AS passwordHash
+		FROM User
+		WHERE ` + condition
+
+	credentials := &entityUserCredentials{}
+	err := db.Db.QueryStructRow(credentials, query, args...)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return credentials, nil
+	}
+}
+
+// UpdatePasswordHashRevokingAccess replaces the password of the user,
+// revoking all its sessions (except the given one) and all its personal tokens,
+// so that whoever knew the old password loses access.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) UpdatePasswordHashRevokingAccess(userUuid uuid.UUID, passwordHash string, exceptSessionUuid uuid.UUID) error {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("UPDATE User SET passwordHash = ? WHERE id = ?", passwordHash, userUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM Session WHERE userId = ? AND id != ?", userUuid.Bytes(), exceptSessionUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM PersonalToken WHERE userId = ?", userUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db DbDao) ReactivateUser(userUuid uuid.UUID) error {
+	return db.Db.Exec("UPDATE User SET deactivated = FALSE WHERE id = ? AND deactivated", userUuid.Bytes())
+}
+
+func (db DbDao) InsertSession(session entitySession) error {
+	return db.Db.Exec("INSERT INTO Session (id, tokenHash, userId, creationDate, lastUseDate, expirationDate, userAgent, remoteIp) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		session.Id,
+		session.TokenHash,
+		session.UserId,
+		session.CreationDate,
+		session.LastUseDate,
+		session.ExpirationDate,
+		session.UserAgent,
+		session.RemoteIp,
+	)
+}
+
+// GetSessionByTokenHash returns the session identified by the given token hash,
+// only if it's not expired yet at the given time.
+func (db DbDao) GetSessionByTokenHash(tokenHash []byte, now string) (*entitySession, error) {
+	session := &entitySession{}
+	err := db.Db.QueryStructRow(session, "SELECT * FROM Session WHERE tokenHash = ? AND expirationDate > ?", tokenHash, now)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return session, nil
+	}
+}
+
+// UpdateSessionUsage records the last time and the device which used the session,
+// postponing its expiration.
+func (db DbDao) UpdateSessionUsage(sessionUuid uuid.UUID, lastUseDate string, expirationDate string, userAgent string, remoteIp string) error {
+	return db.Db.Exec("UPDATE Session SET lastUseDate = ?, expirationDate = ?, userAgent = ?, remoteIp = ? WHERE id = ?",
+		lastUseDate, expirationDate, userAgent, remoteIp, sessionUuid.Bytes())
+}
+
+// GetUserSessions returns all the sessions of the given user
+// which are not expired yet at the given time, most recently used first.
+func (db DbDao) GetUserSessions(userUuid uuid.UUID, now string) ([]entitySession, error) {
+	rows, err := db.Db.QueryStructRows(
+		entitySession{},
+		"SELECT * FROM Session WHERE userId = ? AND expirationDate > ? ORDER BY lastUseDate DESC, id",
+		userUuid.Bytes(),
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sessions []entitySession
+		entity   any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		session, ok := entity.(entitySession)
+		if !ok {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+		sessions = append(sessions, session)
+	}
+	if !errors.Is(err, database.ErrNoResult) {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (db DbDao) DeleteSession(sessionUuid uuid.UUID) error {
+	return db.Db.Exec("DELETE FROM Session WHERE id = ?", sessionUuid.Bytes())
+}
+
+// DeleteUserSession deletes a session only if it belongs to the given user.
+// Boolean return value indicates whether the session was found.
+func (db DbDao) DeleteUserSession(userUuid uuid.UUID, sessionUuid uuid.UUID) (bool, error) {
+	rows, err := db.Db.ExecRows("DELETE FROM Session WHERE id = ? AND userId = ?", sessionUuid.Bytes(), userUuid.Bytes())
+	return rows > 0, err
+}
+
+func (db DbDao) DeleteExpiredSessions(now string) error {
+	return db.Db.Exec("DELETE FROM Session WHERE expirationDate <= ?", now)
+}
+
+// RevokeUserAccessExcept deletes all the sessions of the user, except the given one,
+// and all its personal tokens.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) RevokeUserAccessExcept(userUuid uuid.UUID, exceptSessionUuid uuid.UUID) error {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("DELETE FROM Session WHERE userId = ? AND id != ?", userUuid.Bytes(), exceptSessionUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM PersonalToken WHERE userId = ?", userUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db DbDao) GetTotp(userUuid uuid.UUID) (*entityTotp, error) {
+	totp := &entityTotp{}
+	err := db.Db.QueryStructRow(totp, "SELECT * FROM Totp WHERE userId = ?", userUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return totp, nil
+	}
+}
+
+// SetPendingTotp saves a new secret, not confirmed yet,
+// replacing any previous pending one.
+func (db DbDao) SetPendingTotp(totp entityTotp) error {
+	return db.Db.Exec(`
+		INSERT INTO Totp (userId, secret, confirmed, lastUsedStep, creationDate) VALUES (?, ?, FALSE, 0, ?)
+		ON CONFLICT (userId) DO UPDATE SET secret       = excluded.secret,
+		                                   confirmed    = FALSE,
+		                                   lastUsedStep = 0,
+		                                   creationDate = excluded.creationDate`,
+		totp.UserId,
+		totp.Secret,
+		totp.CreationDate,
+	)
+}
+
+// ConfirmTotp enables the pending TOTP of the user,
+// replacing all the recovery codes with the given ones.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) ConfirmTotp(userUuid uuid.UUID, usedStep int64, recoveryCodeHashes [][]byte) error {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("UPDATE Totp SET confirmed = TRUE, lastUsedStep = ? WHERE userId = ?", usedStep, userUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM TotpRecoveryCode WHERE userId = ?", userUuid.Bytes())
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec("INSERT INTO TotpRecoveryCode (userId, codeHash) VALUES (?, ?)", userUuid.Bytes(), codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseTotpStep marks the given time step as used.
+// It returns false if the same or a later step has already been used,
+// so a code cannot be used twice even by concurrent requests.
+func (db DbDao) UseTotpStep(userUuid uuid.UUID, usedStep int64) (bool, error) {
+	rows, err := db.Db.ExecRows("UPDATE Totp SET lastUsedStep = ? WHERE userId = ? AND lastUsedStep < ?",
+		usedStep, userUuid.Bytes(), usedStep)
+	return rows > 0, err
+}
+
+// UseTotpRecoveryCode consumes a recovery code.
+// Boolean return value indicates whether the code was valid.
+func (db DbDao) UseTotpRecoveryCode(userUuid uuid.UUID, codeHash []byte) (bool, error) {
+	rows, err := db.Db.ExecRows("DELETE FROM TotpRecoveryCode WHERE userId = ? AND codeHash = ?", userUuid.Bytes(), codeHash)
+	return rows > 0, err
+}
+
+func (db DbDao) DeleteTotp(userUuid uuid.UUID) error {
+	return db.Db.Exec("DELETE FROM Totp WHERE userId = ?", userUuid.Bytes())
+}
+
+func (db DbDao) InsertPersonalToken(token entityPersonalToken) error {
+	return db.Db.Exec("INSERT INTO PersonalToken (id, userId, name, tokenHash, scopes, creationDate) VALUES (?, ?, ?, ?, ?, ?)",
+		token.Id,
+		token.UserId,
+		token.Name,
+		token.TokenHash,
+		token.Scopes,
+		token.CreationDate,
+	)
+}
+
+func (db DbDao) GetPersonalTokenByHash(tokenHash []byte) (*entityPersonalToken, error) {
+	token := &entityPersonalToken{}
+	err := db.Db.QueryStructRow(token, "SELECT * FROM PersonalToken WHERE tokenHash = ?", tokenHash)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return token, nil
+	}
+}
+
+// GetUserPersonalTokens returns all the personal tokens of the given user, newest first
+func (db DbDao) GetUserPersonalTokens(userUuid uuid.UUID) ([]entityPersonalToken, error) {
+	rows, err := db.Db.QueryStructRows(
+		entityPersonalToken{},
+		"SELECT * FROM PersonalToken WHERE userId = ? ORDER BY creationDate DESC, id",
+		userUuid.Bytes(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tokens []entityPersonalToken
+		entity any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		token, ok := entity.(entityPersonalToken)
+		if !ok {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+		tokens = append(tokens, token)
+	}
+	if !errors.Is(err, database.ErrNoResult) {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (db DbDao) UpdatePersonalTokenUsage(tokenUuid uuid.UUID, lastUseDate string) error {
+	return db.Db.Exec("UPDATE PersonalToken SET lastUseDate = ? WHERE id = ?", lastUseDate, tokenUuid.Bytes())
+}
+
+// DeleteUserPersonalToken deletes a personal token only if it belongs to the given user.
+// Boolean return value indicates whether the token was found.
+func (db DbDao) DeleteUserPersonalToken(userUuid uuid.UUID, tokenUuid uuid.UUID) (bool, error) {
+	rows, err := db.Db.ExecRows("DELETE FROM PersonalToken WHERE id = ? AND userId = ?", tokenUuid.Bytes(), userUuid.Bytes())
+	return rows > 0, err
+}
+
+func (db DbDao) InsertOidcLoginState(loginState entityOidcLoginState) error {
+	return db.Db.Exec("INSERT INTO OidcLoginState (state, codeVerifier, nonce, expirationDate) VALUES (?, ?, ?, ?)",
+		loginState.State,
+		loginState.CodeVerifier,
+		loginState.Nonce,
+		loginState.ExpirationDate,
+	)
+}
+
+// ConsumeOidcLoginState returns the login state, if not expired yet, and deletes it.
+// In this way, each state can be used only once, even by concurrent requests.
+func (db DbDao) ConsumeOidcLoginState(state string, now string) (*entityOidcLoginState, error) {
+	loginState := &entityOidcLoginState{}
+	err := db.Db.QueryStructRow(loginState, "SELECT * FROM OidcLoginState WHERE state = ? AND expirationDate > ?", state, now)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	deleted, err := db.Db.ExecRows("DELETE FROM OidcLoginState WHERE state = ?", state)
+	if err != nil {
+		return nil, err
+	} else if deleted == 0 {
+		// Someone else consumed it in the meantime
+		return nil, nil
+	}
+
+	return loginState, nil
+}
+
+func (db DbDao) DeleteExpiredOidcLoginStates(now string) error {
+	return db.Db.Exec("DELETE FROM OidcLoginState WHERE expirationDate <= ?", now)
+}
+
+// GetExternalIdentityUserId returns the ID of the user linked to the external identity,
+// or uuid.Nil if it's not linked yet.
+func (db DbDao) GetExternalIdentityUserId(issuer string, subject string) (uuid.UUID, error) {
+	var result struct {
+		UserId []byte `json:"userId"`
+	}
+	err := db.Db.QueryStructRow(&result, "SELECT userId FROM ExternalIdentity WHERE issuer = ? AND subject = ?", issuer, subject)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.UserId), nil
+	}
+}
+
+// InsertUserWithExternalIdentity creates a new user, linked to the given external identity.
+// If the username is already taken, it returns database.ErrDuplicated
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) InsertUserWithExternalIdentity(newUser user.ModelUser, issuer string, subject string, now string) error {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Check if the username is available, and not reserved by who recently released it
+	result, err := tx.Query(`
+		SELECT id FROM User WHERE username = ?
+		UNION ALL
+		SELECT userId FROM UsernameChange WHERE username = ? AND reservedUntil > ?`,
+		newUser.Username, newUser.Username, now)
+	if err != nil {
+		return err
+	}
+	usernameTaken := result.Next()
+	_ = result.Close()
+	if usernameTaken {
+		return database.ErrDuplicated
+	}
+
+	_, err = tx.Exec("INSERT INTO User (id, name, surname, username) VALUES (?, ?, ?, ?)",
+		newUser.Id, newUser.Name, newUser.Surname, newUser.Username)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO ExternalIdentity (issuer, subject, userId, creationDate) VALUES (?, ?, ?, ?)",
+		issuer, subject, newUser.Id, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db DbDao) GetLoginFailure(kind string, subject string) (*entityLoginFailure, error) {
+	failure := &entityLoginFailure{}
+	err := db.Db.QueryStructRow(failure, "SELECT * FROM LoginFailure WHERE kind = ? AND subject = ?", kind, subject)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return failure, nil
+	}
+}
+
+// IncrementLoginFailures counts a failed login, returning the updated number of failures.
+// The increment is done by the database, so that concurrent failures are all counted.
+func (db DbDao) IncrementLoginFailures(kind string, subject string, now string) (int64, error) {
+	var result struct {
+		Failures int64 `json:"failures"`
+	}
+	err := db.Db.QueryStructRow(&result, `INSERT INTO LoginFailure (kind, subject, failures, lastFailureDate, lockedUntilDate)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (kind, subject) DO UPDATE SET failures        = failures + 1,
+		                                          lastFailureDate = excluded.lastFailureDate
+		RETURNING failures`,
+		kind,
+		subject,
+		now,
+		now,
+	)
+	return result.Failures, err
+}
+
+// ExtendLoginLockout locks the counter until the given date, unless it's already locked for longer
+func (db DbDao) ExtendLoginLockout(kind string, subject string, lockedUntil string) error {
+	return db.Db.Exec("UPDATE LoginFailure SET lockedUntilDate = MAX(lockedUntilDate, ?) WHERE kind = ? AND subject = ?",
+		lockedUntil,
+		kind,
+		subject,
+	)
+}
+
+func (db DbDao) DeleteLoginFailure(kind string, subject string) error {
+	return db.Db.Exec("DELETE FROM LoginFailure WHERE kind = ? AND subject = ?", kind, subject)
+}
+
+// DeleteStaleLoginFailures deletes the counters whose last failure happened before the given date
+func (db DbDao) DeleteStaleLoginFailures(before string) error {
+	return db.Db.Exec("DELETE FROM LoginFailure WHERE lastFailureDate < ? AND lockedUntilDate < ?", before, before)
+}
+
+func (db DbDao) GetUserEmail(userUuid uuid.UUID) (*entityUserEmail, error) {
+	email := &entityUserEmail{}
+	err := db.Db.QueryStructRow(email, "SELECT COALESCE(email, '') AS email, emailVerified FROM User WHERE id = ?", userUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return email, nil
+	}
+}
+
+// GetUserIdByVerifiedEmail returns the ID of the user with the given verified email,
+// or uuid.Nil if there's none.
+func (db DbDao) GetUserIdByVerifiedEmail(email string) (uuid.UUID, error) {
+	var result struct {
+		Id []byte `json:"id"`
+	}
+	err := db.Db.QueryStructRow(&result, "SELECT id FROM User WHERE email = ? AND emailVerified", email)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.Id), nil
+	}
+}
+
+// SetUserEmail replaces the email of the user, which must be verified again
+func (db DbDao) SetUserEmail(userUuid uuid.UUID, email string) error {
+	return db.Db.Exec("UPDATE User SET email = ?, emailVerified = FALSE WHERE id = ?", email, userUuid.Bytes())
+}
+
+// SetEmailVerified marks the email of the user as verified,
+// only if it's still the given one.
+func (db DbDao) SetEmailVerified(userUuid uuid.UUID, email string) (bool, error) {
+	rows, err := db.Db.ExecRows("UPDATE User SET emailVerified = TRUE WHERE id = ? AND email = ?", userUuid.Bytes(), email)
+	return rows > 0, err
+}
+
+func (db DbDao) InsertEmailToken(token entityEmailToken) error {
+	return db.Db.Exec("INSERT INTO EmailToken (tokenHash, userId, purpose, email, expirationDate) VALUES (?, ?, ?, ?, ?)",
+		token.TokenHash,
+		token.UserId,
+		token.Purpose,
+		token.Email,
+		token.ExpirationDate,
+	)
+}
+
+// ConsumeEmailToken returns the token, if not expired yet, and deletes it.
+// In this way, each token can be used only once, even by concurrent requests.
+func (db DbDao) ConsumeEmailToken(tokenHash []byte, purpose string, now string) (*entityEmailToken, error) {
+	token := &entityEmailToken{}
+	query := "SELECT * FROM EmailToken WHERE tokenHash = ? AND purpose = ? AND expirationDate > ?"
+	err := db.Db.QueryStructRow(token, query, tokenHash, purpose, now)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	deleted, err := db.Db.ExecRows("DELETE FROM EmailToken WHERE tokenHash = ?", tokenHash)
+	if err != nil {
+		return nil, err
+	} else if deleted == 0 {
+		// Someone else consumed it in the meantime
+		return nil, nil
+	}
+
+	return token, nil
+}
+
+func (db DbDao) DeleteUserEmailTokens(userUuid uuid.UUID, purpose string) error {
+	return db.Db.Exec("DELETE FROM EmailToken WHERE userId = ? AND purpose = ?", userUuid.Bytes(), purpose)
+}
+
+func (db DbDao) DeleteExpiredEmailTokens(now string) error {
+	return db.Db.Exec("DELETE FROM EmailToken WHERE expirationDate <= ?", now)
+}
@@ -1,9 +1,115 @@
 package auth
 
+import (
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+)
+
 type userLoginCredentials struct {
 	Username string `json:"username" validate:"required,username"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Otp      string `json:"otp" validate:"omitempty,max=32"`
+}
+
+type userSignup struct {
+	userLoginCredentials
+	Email string `json:"email" validate:"required,email,max=254"`
 }
 
 type userLoginResult struct {
 	UserId string `json:"userId"`
+	Token  string `json:"token"`
+}
+
+type passwordChange struct {
+	OldPassword string `json:"oldPassword" validate:"max=72"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=72"`
+}
+
+type activeSession struct {
+	Id             string `json:"id"`
+	CreationDate   string `json:"creationDate"`
+	LastUseDate    string `json:"lastUseDate"`
+	ExpirationDate string `json:"expirationDate"`
+	UserAgent      string `json:"userAgent"`
+	RemoteIp       string `json:"remoteIp"`
+	Current        bool   `json:"current"`
+}
+
+type sessionParams struct {
+	user.IdParams
+	SessionId string `json:"sessionId" validate:"required,uuid"`
+}
+
+type totpEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type totpCode struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type totpRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type totpDisableParams struct {
+	user.IdParams
+	totpCode
+}
+
+type newPersonalToken struct {
+	Name   string        `json:"name" validate:"required,min=1,max=64"`
+	Scopes []route.Scope `json:"scopes" validate:"required,min=1,max=16,unique,dive,oneof=read profile:write bans:write mutes:write follows:write photos:write likes:write comments:write"`
+}
+
+type personalToken struct {
+	Id           string        `json:"id"`
+	Name         string        `json:"name"`
+	Scopes       []route.Scope `json:"scopes"`
+	CreationDate string        `json:"creationDate"`
+	LastUseDate  *string       `json:"lastUseDate"`
+}
+
+type createdPersonalToken struct {
+	personalToken
+	Token string `json:"token"`
+}
+
+type personalTokenParams struct {
+	user.IdParams
+	TokenId string `json:"tokenId" validate:"required,uuid"`
+}
+
+type externalLoginStart struct {
+	AuthorizationUrl string `json:"authorizationUrl"`
+	State            string `json:"state"`
+}
+
+type externalLoginCallback struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=64"`
+}
+
+type userEmail struct {
+	Email    *string `json:"email"`
+	Verified bool    `json:"verified"`
+}
+
+type emailChange struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type emailTokenBody struct {
+	Token string `json:"token" validate:"required,max=64"`
+}
+
+type passwordResetRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type passwordReset struct {
+	Token       string `json:"token" validate:"required,max=64"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=72"`
 }
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"net/http"
+)
+
+type EmailController struct {
+	Service EmailService
+}
+
+func (controller EmailController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/email",
+			Handler: controller.getEmail,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/email",
+			Handler: controller.changeEmail,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/:userId/email/verification",
+			Handler: controller.resendVerification,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodPost,
+			Path:    "/email-verification",
+			Handler: controller.verifyEmail,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodPost,
+			Path:    "/password-reset",
+			Handler: controller.requestPasswordReset,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodPut,
+			Path:    "/password-reset",
+			Handler: controller.resetPassword,
+		},
+	}
+}
+
+func (controller EmailController) getEmail(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	email, err := controller.Service.GetEmail(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, email, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller EmailController) changeEmail(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, body, bodyErr := api.ParseVariablesAndBody(r, params, &user.IdParams{}, &emailChange{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.ChangeEmail(args.UserId, body.Email, context.RemoteIp)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+		return
+	}
+
+	email, err := controller.Service.GetEmail(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, email, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller EmailController) resendVerification(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.ResendVerification(args.UserId, context.RemoteIp)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+func (controller EmailController) verifyEmail(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	body, bodyErr := api.ParseAndValidateBody(r, &emailTokenBody{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	err := controller.Service.VerifyEmail(body.Token)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, ctx.Logger)
+}
+
+func (controller EmailController) requestPasswordReset(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	body, bodyErr := api.ParseAndValidateBody(r, &passwordResetRequest{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	err := controller.Service.RequestPasswordReset(body.Email, ctx.RemoteIp)
+	api.HandleErrorsResponse(err, w, http.StatusAccepted, ctx.Logger)
+}
+
+func (controller EmailController) resetPassword(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	body, bodyErr := api.ParseAndValidateBody(r, &passwordReset{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	err := controller.Service.ResetPassword(*body)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, ctx.Logger)
+}
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/mailer"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type EmailService interface {
+	EmailVerifier
+	GetEmail(userId string) (userEmail, error)
+	ChangeEmail(userId string, email string, remoteIp string) error
+	ResendVerification(userId string, remoteIp string) error
+	VerifyEmail(token string) error
+	RequestPasswordReset(email string, remoteIp string) error
+	ResetPassword(reset passwordReset) error
+	DeleteExpiredTokens() error
+}
+
+// EmailVerifier sends to a user the link to verify its email address
+type EmailVerifier interface {
+	StartVerification(userUuid uuid.UUID, email string) error
+}
+
+// Purposes of the tokens sent by email, as stored in the EmailToken table
+const (
+	emailTokenVerification  = "verification"
+	emailTokenPasswordReset = "passwordReset"
+)
+
+// How long the links sent by email are valid
+const (
+	verificationTokenDuration  = 24 * time.Hour
+	passwordResetTokenDuration = time.Hour
+)
+
+type EmailServiceImpl struct {
+	Db       Dao
+	Mailer   mailer.Mailer
+	Time     timeprovider.TimeProvider
+	Logger   logrus.FieldLogger
+	Throttle LoginThrottle // Optional
+
+	// PublicUrl is where the users reach the web UI,
+	// used to build the links in the emails.
+	PublicUrl string
+}
+
+func (service EmailServiceImpl) GetEmail(userId string) (userEmail, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return userEmail{}, api.ErrWrongUUID
+	}
+
+	email, err := service.Db.GetUserEmail(userUuid)
+	if err != nil {
+		return userEmail{}, err
+	} else if email == nil {
+		return userEmail{}, api.ErrNotFound
+	}
+
+	return email.toDto(), nil
+}
+
+// ChangeEmail sets a new email address for the user, which must be verified again.
+// If it's already in use by someone else, it returns api.ErrAlreadyTaken
+func (service EmailServiceImpl) ChangeEmail(userId string, email string, remoteIp string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	email = normalizeEmail(email)
+	if err := service.throttle(email, remoteIp); err != nil {
+		return err
+	}
+
+	err := service.Db.SetUserEmail(userUuid, email)
+	if errors.Is(err, database.ErrDuplicated) {
+		return api.ErrAlreadyTaken
+	} else if err != nil {
+		return err
+	}
+
+	// Links sent to the old address must not verify the new one
+	if err := service.Db.DeleteUserEmailTokens(userUuid, emailTokenVerification); err != nil {
+		return err
+	}
+
+	return service.StartVerification(userUuid, email)
+}
+
+// ResendVerification sends a new verification link,
+// in case the previous one got lost or expired.
+func (service EmailServiceImpl) ResendVerification(userId string, remoteIp string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	email, err := service.Db.GetUserEmail(userUuid)
+	if err != nil {
+		return err
+	} else if email == nil || email.Email == "" {
+		return api.ErrNotFound
+	} else if email.EmailVerified > 0 {
+		// Nothing left to verify
+		return nil
+	}
+
+	if err := service.throttle(email.Email, remoteIp); err != nil {
+		return err
+	}
+
+	return service.StartVerification(userUuid, email.Email)
+}
+
+// StartVerification sends the verification link to the given email address.
+// The email is sent in the background.
+func (service EmailServiceImpl) StartVerification(userUuid uuid.UUID, email string) error {
+	token, err := service.createToken(userUuid, email, emailTokenVerification, verificationTokenDuration)
+	if err != nil {
+		service.Logger.WithError(err).Error("unable to create email verification token")
+		return err
+	}
+
+	service.send(mailer.Message{
+		To:      email,
+		Subject: "Verify your WASAPhoto email",
+		Body: "Hi,\n\n" +
+			"please confirm this is your email address by opening the following link:\n\n" +
+			service.link("/verify-email", token) + "\n\n" +
+			"The link expires in 24 hours.\n" +
+			"If you didn't sign up to WASAPhoto, just ignore this email.\n",
+	})
+	return nil
+}
+
+// VerifyEmail marks the email address as verified, using the token sent to it.
+// If the token is invalid or expired, it returns api.ErrNotFound
+func (service EmailServiceImpl) VerifyEmail(token string) error {
+	found, err := service.Db.ConsumeEmailToken(securetoken.Hash(token), emailTokenVerification, service.Time.UTCString())
+	if err != nil {
+		return err
+	} else if found == nil {
+		return api.ErrNotFound
+	}
+
+	verified, err := service.Db.SetEmailVerified(uuid.FromBytesOrNil(found.UserId), found.Email)
+	if err != nil {
+		return err
+	} else if !verified {
+		// The user changed its email in the meantime
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// RequestPasswordReset sends a password reset link to the given email address,
+// if it belongs to a user and it has been verified.
+//
+// It never tells if the email has been found or not,
+// so that it cannot be used to find out who is registered:
+// the link is created and sent in the background, and any error is only logged.
+func (service EmailServiceImpl) RequestPasswordReset(email string, remoteIp string) error {
+	email = normalizeEmail(email)
+	if err := service.throttle(email, remoteIp); err != nil {
+		return err
+	}
+
+	userUuid, err := service.Db.GetUserIdByVerifiedEmail(email)
+	if err != nil {
+		return err
+	} else if !userUuid.IsNil() {
+		go service.sendPasswordReset(userUuid, email)
+	}
+
+	return nil
+}
+
+func (service EmailServiceImpl) sendPasswordReset(userUuid uuid.UUID, email string) {
+	token, err := service.createToken(userUuid, email, emailTokenPasswordReset, passwordResetTokenDuration)
+	if err != nil {
+		service.Logger.WithError(err).Error("unable to create password reset token")
+		return
+	}
+
+	service.send(mailer.Message{
+		To:      email,
+		Subject: "Reset your WASAPhoto password",
+		Body: "Hi,\n\n" +
+			"someone asked to reset the password of your WASAPhoto account.\n" +
+			"To choose a new password, open the following link:\n\n" +
+			service.link("/reset-password", token) + "\n\n" +
+			"The link expires in 1 hour.\n" +
+			"If it wasn't you, just ignore this email: your password won't change.\n",
+	})
+}
+
+// ResetPassword sets a new password, using the token sent by email.
+// All the sessions and personal tokens of the user are revoked.
+// If the token is invalid or expired, it returns api.ErrNotFound
+func (service EmailServiceImpl) ResetPassword(reset passwordReset) error {
+	found, err := service.Db.ConsumeEmailToken(securetoken.Hash(reset.Token), emailTokenPasswordReset, service.Time.UTCString())
+	if err != nil {
+		return err
+	} else if found == nil {
+		return api.ErrNotFound
+	}
+
+	userUuid := uuid.FromBytesOrNil(found.UserId)
+	passwordHash, err := hashPassword(reset.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := service.Db.UpdatePasswordHashRevokingAccess(userUuid, passwordHash, uuid.Nil); err != nil {
+		return err
+	}
+
+	// Other links sent so far are not needed anymore
+	return service.Db.DeleteUserEmailTokens(userUuid, emailTokenPasswordReset)
+}
+
+// DeleteExpiredTokens removes the tokens which cannot be used anymore
+func (service EmailServiceImpl) DeleteExpiredTokens() error {
+	return service.Db.DeleteExpiredEmailTokens(service.Time.UTCString())
+}
+
+// throttle limits the emails sent to the same address, or requested from the same IP address.
+// It returns an api.RetryAfterError if too many have been sent recently.
+func (service EmailServiceImpl) throttle(email string, remoteIp string) error {
+	if service.Throttle == nil {
+		return nil
+	}
+	return service.Throttle.RecordEmail(email, remoteIp)
+}
+
+// send delivers the email in the background, so that a slow or failing mail server
+// neither delays the response nor shows up in it
+func (service EmailServiceImpl) send(message mailer.Message) {
+	go func() {
+		if err := service.Mailer.Send(message); err != nil {
+			service.Logger.WithError(err).WithField("subject", message.Subject).Error("unable to send email")
+		}
+	}()
+}
+
+// createToken stores a new token for the given purpose, returning it
+func (service EmailServiceImpl) createToken(userUuid uuid.UUID, email string, purpose string, duration time.Duration) (string, error) {
+	token, tokenHash, err := securetoken.New()
+	if err != nil {
+		return "", err
+	}
+
+	err = service.Db.InsertEmailToken(entityEmailToken{
+		TokenHash:      tokenHash,
+		UserId:         userUuid.Bytes(),
+		Purpose:        purpose,
+		Email:          email,
+		ExpirationDate: timeprovider.DateToUTCString(service.Time.Now().Add(duration)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// link builds the URL of the given web UI page, carrying the token
+func (service EmailServiceImpl) link(page string, token string) string {
+	return fmt.Sprintf("%s/#%s?token=%s", strings.TrimSuffix(service.PublicUrl, "/"), page, url.QueryEscape(token))
+}
+
+// normalizeEmail makes sure the same address is always stored the same way
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/mailer"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestEmailService(t *testing.T) (EmailServiceImpl, *mailer.MemoryMailer) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	dao := DbDao{Db: databasetest.New(t)}
+	memoryMailer := &mailer.MemoryMailer{}
+
+	return EmailServiceImpl{
+		Db:        dao,
+		Mailer:    memoryMailer,
+		Time:      clock,
+		Logger:    logger,
+		Throttle:  LoginThrottleImpl{Db: dao, Time: clock},
+		PublicUrl: "http://localhost",
+	}, memoryMailer
+}
+
+// waitMessages waits for the emails sent in the background
+func waitMessages(t *testing.T, memoryMailer *mailer.MemoryMailer, expected int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(memoryMailer.Messages()) < expected && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if count := len(memoryMailer.Messages()); count != expected {
+		t.Fatalf("%d emails sent, expected %d", count, expected)
+	}
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	service, memoryMailer := newTestEmailService(t)
+
+	userUuid := uuid.Must(uuid.NewV4())
+	newUser := user.ModelUser{Id: userUuid.Bytes(), Name: "John", Username: "john_doe"}
+	if err := service.Db.InsertUserWithPassword(newUser, "", "john@example.com", service.Time.UTCString()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Db.SetEmailVerified(userUuid, "john@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The response is the same, whether the address is registered or not
+	if err := service.RequestPasswordReset("nobody@example.com", "192.0.2.1"); err != nil {
+		t.Errorf("expected no error for an unknown address, got %v", err)
+	}
+	if err := service.RequestPasswordReset(" John@Example.com ", "192.0.2.1"); err != nil {
+		t.Errorf("expected no error for a registered address, got %v", err)
+	}
+
+	waitMessages(t, memoryMailer, 1)
+	if to := memoryMailer.Messages()[0].To; to != "john@example.com" {
+		t.Errorf("email sent to %s", to)
+	}
+}
+
+func TestRequestPasswordResetThrottled(t *testing.T) {
+	service, _ := newTestEmailService(t)
+
+	// The first email over the free ones is still sent, but it locks the address
+	for i := 0; i <= emailAddressFreeSends; i++ {
+		if err := service.RequestPasswordReset("victim@example.com", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Even addresses which don't belong to any user are limited, not to reveal who is registered
+	err := service.RequestPasswordReset("victim@example.com", "192.0.2.2")
+	var retryAfterErr api.RetryAfterError
+	if !errors.As(err, &retryAfterErr) || retryAfterErr.RetryAfter != baseLockout {
+		t.Errorf("expected a lockout of %v, got %v", baseLockout, err)
+	}
+
+	// Requests from the same IP address are limited too, whatever the address
+	for i := emailAddressFreeSends + 1; i <= emailIpFreeSends; i++ {
+		if err := service.RequestPasswordReset(uuid.Must(uuid.NewV4()).String()+"@example.com", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.RequestPasswordReset("someone@example.com", "192.0.2.1"); !errors.Is(err, api.ErrTooManyRequests) {
+		t.Errorf("expected ErrTooManyRequests from the same IP address, got %v", err)
+	}
+}
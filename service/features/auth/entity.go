@@ -0,0 +1,101 @@
+package auth
+
+import "github.com/gofrs/uuid"
+
+// entitySession is the entity for the Session database table
+type entitySession struct {
+	Id             []byte `json:"id"`
+	TokenHash      []byte `json:"tokenHash"`
+	UserId         []byte `json:"userId"`
+	CreationDate   string `json:"creationDate"`
+	LastUseDate    string `json:"lastUseDate"`
+	ExpirationDate string `json:"expirationDate"`
+	UserAgent      string `json:"userAgent"`
+	RemoteIp       string `json:"remoteIp"`
+}
+
+// entityUserCredentials is the subset of the User table
+// with the data needed to authenticate a user
+type entityUserCredentials struct {
+	Id           []byte `json:"id"`
+	PasswordHash string `json:"passwordHash"`
+}
+
+// entityTotp is the entity for the Totp database table
+type entityTotp struct {
+	UserId       []byte `json:"userId"`
+	Secret       []byte `json:"secret"`
+	Confirmed    int64  `json:"confirmed"`
+	LastUsedStep int64  `json:"lastUsedStep"`
+	CreationDate string `json:"creationDate"`
+}
+
+// entityPersonalToken is the entity for the PersonalToken database table
+type entityPersonalToken struct {
+	Id           []byte `json:"id"`
+	UserId       []byte `json:"userId"`
+	Name         string `json:"name"`
+	TokenHash    []byte `json:"tokenHash"`
+	Scopes       string `json:"scopes"`
+	CreationDate string `json:"creationDate"`
+	LastUseDate  string `json:"lastUseDate"`
+}
+
+// entityOidcLoginState is the entity for the OidcLoginState database table
+type entityOidcLoginState struct {
+	State          string `json:"state"`
+	CodeVerifier   string `json:"codeVerifier"`
+	Nonce          string `json:"nonce"`
+	ExpirationDate string `json:"expirationDate"`
+}
+
+// entityLoginFailure is the entity for the LoginFailure database table
+type entityLoginFailure struct {
+	Kind            string `json:"kind"`
+	Subject         string `json:"subject"`
+	Failures        int64  `json:"failures"`
+	LastFailureDate string `json:"lastFailureDate"`
+	LockedUntilDate string `json:"lockedUntilDate"`
+}
+
+// entityUserEmail is the email address of a user, from the User database table
+type entityUserEmail struct {
+	Email         string `json:"email"`
+	EmailVerified int64  `json:"emailVerified"`
+}
+
+// entityEmailToken is the entity for the EmailToken database table
+type entityEmailToken struct {
+	TokenHash      []byte `json:"tokenHash"`
+	UserId         []byte `json:"userId"`
+	Purpose        string `json:"purpose"`
+	Email          string `json:"email"`
+	ExpirationDate string `json:"expirationDate"`
+}
+
+func (entity entityUserEmail) toDto() userEmail {
+	var email *string
+	if entity.Email != "" {
+		email = &entity.Email
+	}
+
+	return userEmail{
+		Email:    email,
+		Verified: entity.EmailVerified > 0,
+	}
+}
+
+func (entity entityPersonalToken) toDto() personalToken {
+	var lastUseDate *string
+	if entity.LastUseDate != "" {
+		lastUseDate = &entity.LastUseDate
+	}
+
+	return personalToken{
+		Id:           uuid.FromBytesOrNil(entity.Id).String(),
+		Name:         entity.Name,
+		Scopes:       parseScopes(entity.Scopes),
+		CreationDate: entity.CreationDate,
+		LastUseDate:  lastUseDate,
+	}
+}
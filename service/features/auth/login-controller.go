@@ -4,6 +4,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
 	"net/http"
 )
 
@@ -19,6 +20,21 @@ func (controller LoginController) ListRoutes() []route.Route {
 			Path:    "/session",
 			Handler: controller.handlePost,
 		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/session",
+			Handler: controller.handleDelete,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/",
+			Handler: controller.handleSignup,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/password",
+			Handler: controller.handleChangePassword,
+		},
 	}
 }
 
@@ -29,24 +45,62 @@ func (controller LoginController) handlePost(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	authToken, isNew, err := controller.AuthService.AuthenticateOrSignup(*body)
+	response, err := controller.AuthService.Authenticate(*body, clientFromContext(ctx))
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, ctx.Logger)
+		return
+	}
 
-	var responseStatus int
-	var logAction string
-	if isNew {
-		responseStatus = http.StatusCreated
-		logAction = "signing up"
-	} else {
-		responseStatus = http.StatusOK
-		logAction = "authenticating"
+	api.SendJson(w, response, http.StatusOK, ctx.Logger)
+}
+
+func (controller LoginController) handleSignup(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	body, bodyErr := api.ParseAndValidateBody(r, &userSignup{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
 	}
 
+	response, err := controller.AuthService.Signup(*body, clientFromContext(ctx))
 	if err != nil {
-		ctx.Logger.WithError(err).Warnf("Unexpected error %s user with username '%s'", logAction, body.Username)
-		api.HandleErrorsResponse(err, w, responseStatus, ctx.Logger)
+		api.HandleErrorsResponse(err, w, http.StatusCreated, ctx.Logger)
+		return
+	}
+
+	api.SendJson(w, response, http.StatusCreated, ctx.Logger)
+}
+
+func (controller LoginController) handleDelete(w http.ResponseWriter, _ *http.Request, _ httprouter.Params, context route.SecureRequestContext) {
+	err := controller.AuthService.Logout(context.SessionId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+func (controller LoginController) handleChangePassword(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
 		return
 	}
 
-	response := userLoginResult{UserId: authToken}
-	api.SendJson(w, response, responseStatus, ctx.Logger)
+	body, bodyErr := api.ParseAndValidateBody(r, &passwordChange{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	err := controller.AuthService.ChangePassword(args.UserId, *body, context.SessionId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+// clientFromContext extracts the information about the device performing the request
+func clientFromContext(ctx route.RequestContext) SessionClient {
+	return SessionClient{
+		UserAgent: ctx.UserAgent,
+		RemoteIp:  ctx.RemoteIp,
+	}
 }
@@ -3,60 +3,387 @@ package auth
 import (
 	"errors"
 	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+	"time"
 )
 
 type LoginService interface {
-	AuthenticateOrSignup(credentials userLoginCredentials) (authToken string, isNew bool, err error)
-	IsAuthenticated(authToken string) (userId string, err error)
+	Signup(signup userSignup, client SessionClient) (userLoginResult, error)
+	Authenticate(credentials userLoginCredentials, client SessionClient) (userLoginResult, error)
+	ChangePassword(userId string, change passwordChange, currentSessionId string) error
+	IsAuthenticated(authToken string, client SessionClient) (authInfo, error)
+	Logout(sessionId string) error
 }
 
+// authInfo describes who is performing a request, and how they authenticated
+type authInfo struct {
+	UserId string
+	Role   route.Role
+
+	// SessionId is set when authenticated with a user session
+	SessionId string
+
+	// TokenId and Scopes are set when authenticated with a personal access token
+	TokenId string
+	Scopes  []route.Scope
+}
+
+// allows checks if this authentication can be used on a route requiring the given scope.
+// A user session is allowed everywhere.
+func (info authInfo) allows(requiredScope route.Scope) bool {
+	if info.TokenId == "" {
+		return true
+	}
+
+	if requiredScope == route.ScopeSessionOnly {
+		return false
+	}
+
+	for _, scope := range info.Scopes {
+		if scope == requiredScope {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionClient describes the device which is using a session
+type SessionClient struct {
+	UserAgent string
+	RemoteIp  string
+}
+
+// sessionDuration is how long a session can be unused before it expires.
+// Every time a session is used, its expiration is postponed.
+const sessionDuration = 30 * 24 * time.Hour
+
+// usageUpdateInterval is how often the last use of a session or a personal token is saved.
+// Writing it on every request would make every read a write transaction, and SQLite allows a single writer.
+const usageUpdateInterval = time.Minute
+
 type UserIdLoginService struct {
 	// Dependencies
-	UserDao user.Dao
+	Db            Dao
+	Time          timeprovider.TimeProvider
+	SecondFactor  SecondFactor  // Optional
+	Throttle      LoginThrottle // Optional
+	EmailVerifier EmailVerifier // Optional
 }
 
-var errUnknownUser = errors.New("invalid user credentials")
+// errInvalidSession is returned when an auth token
+// doesn't correspond to any active session
+var errInvalidSession = errors.New("invalid or expired session")
 
-// AuthenticateOrSignup tries to authenticate the user specified with the credentials,
-// and returns the user token or an error.
-// In case a user with these credentials cannot be found, it creates a new user with that username.
-func (service UserIdLoginService) AuthenticateOrSignup(credentials userLoginCredentials) (string, bool, error) {
+// Signup creates a new user with the given credentials,
+// and returns a new session token for it.
+// If the username or the email is already in use, it returns api.ErrAlreadyTaken
+//
+// The email must be verified later, through the link sent to it.
+func (service UserIdLoginService) Signup(signup userSignup, client SessionClient) (userLoginResult, error) {
 	newUuid, err := uuid.NewV4()
 	if err != nil {
-		return "", false, err
+		return userLoginResult{}, err
+	}
+
+	passwordHash, err := hashPassword(signup.Password)
+	if err != nil {
+		return userLoginResult{}, err
 	}
 
-	// Try registering a new user with this ID
 	newUser := user.ModelUser{
 		Id:       newUuid.Bytes(),
-		Name:     credentials.Username,
+		Name:     signup.Username,
 		Surname:  "",
-		Username: credentials.Username,
+		Username: signup.Username,
+	}
+
+	email := normalizeEmail(signup.Email)
+	err = service.Db.InsertUserWithPassword(newUser, passwordHash, email, service.Time.UTCString())
+	if errors.Is(err, database.ErrDuplicated) {
+		return userLoginResult{}, api.ErrAlreadyTaken
+	} else if err != nil {
+		return userLoginResult{}, err
+	}
+
+	if service.EmailVerifier != nil {
+		// The account exists anyway: the user can ask for a new email later
+		_ = service.EmailVerifier.StartVerification(newUuid, email)
+	}
+
+	return service.createSession(newUuid, client)
+}
+
+// Authenticate checks the given credentials and returns a new session token,
+// or api.ErrWrongCredentials if they don't match any user.
+//
+// If the user enabled a second factor, the one-time password is required too,
+// otherwise api.ErrSecondFactorRequired is returned.
+//
+// After too many failures on the same username or from the same IP address,
+// logins are temporarily refused with an api.RetryAfterError,
+// without even checking the credentials.
+func (service UserIdLoginService) Authenticate(credentials userLoginCredentials, client SessionClient) (userLoginResult, error) {
+	if service.Throttle == nil {
+		return service.checkCredentials(credentials, client)
 	}
 
-	foundUserId, isNew, err := service.UserDao.InsertOrGetUserId(newUser)
+	if err := service.Throttle.Check(credentials.Username, client.RemoteIp); err != nil {
+		return userLoginResult{}, err
+	}
+
+	result, err := service.checkCredentials(credentials, client)
+	switch {
+	case errors.Is(err, api.ErrWrongCredentials):
+		if throttleErr := service.Throttle.RecordFailure(credentials.Username, client.RemoteIp); throttleErr != nil {
+			return userLoginResult{}, throttleErr
+		}
+	case err == nil:
+		if throttleErr := service.Throttle.RecordSuccess(credentials.Username); throttleErr != nil {
+			return userLoginResult{}, throttleErr
+		}
+	}
+
+	return result, err
+}
+
+// checkCredentials performs the actual authentication.
+//
+// Users without a password, like the ones created before passwords were introduced
+// or through an external provider, can't log in this way: they must reset it by email.
+func (service UserIdLoginService) checkCredentials(credentials userLoginCredentials, client SessionClient) (userLoginResult, error) {
+	found, err := service.Db.GetCredentialsByUsername(credentials.Username)
 	if err != nil {
-		return "", isNew, err
+		return userLoginResult{}, err
 	}
 
-	return foundUserId.String(), isNew, nil
+	var passwordHash string
+	if found != nil {
+		passwordHash = found.PasswordHash
+	}
+
+	// Always check the password, even if the user doesn't exist
+	if !checkPassword(passwordHash, credentials.Password) || found == nil {
+		return userLoginResult{}, api.ErrWrongCredentials
+	}
+
+	userUuid := uuid.FromBytesOrNil(found.Id)
+	if err := service.checkSecondFactor(userUuid, credentials.Otp); err != nil {
+		return userLoginResult{}, err
+	}
+
+	return service.createSession(userUuid, client)
 }
 
-// IsAuthenticated checks if the given authToken can be assigned to a User.
-// In case no user is found, it returns errUnknownUser
-func (service UserIdLoginService) IsAuthenticated(authToken string) (string, error) {
-	userId, err := uuid.FromString(authToken)
+// checkSecondFactor verifies the one-time password, if the user enabled a second factor
+func (service UserIdLoginService) checkSecondFactor(userUuid uuid.UUID, otp string) error {
+	if service.SecondFactor == nil {
+		return nil
+	}
+
+	enabled, err := service.SecondFactor.IsEnabled(userUuid)
+	if err != nil || !enabled {
+		return err
+	}
+
+	if otp == "" {
+		return api.ErrSecondFactorRequired
+	}
+
+	valid, err := service.SecondFactor.Verify(userUuid, otp)
 	if err != nil {
-		return "", err
+		return err
+	} else if !valid {
+		return api.ErrWrongCredentials
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password of the given user,
+// after checking the old one.
+// All the other sessions of the user are revoked,
+// keeping only the one which performed the change, along with all its personal tokens.
+func (service UserIdLoginService) ChangePassword(userId string, change passwordChange, currentSessionId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	sessionUuid := uuid.FromStringOrNil(currentSessionId)
+	if userUuid.IsNil() || sessionUuid.IsNil() {
+		return api.ErrWrongUUID
 	}
 
-	foundUser, err := service.UserDao.GetUserById(userId)
+	found, err := service.Db.GetCredentialsById(userUuid)
 	if err != nil {
-		return "", err
-	} else if foundUser == nil {
-		return "", errUnknownUser
+		return err
+	} else if found == nil {
+		return api.ErrNotFound
+	}
+
+	if found.PasswordHash != "" && !checkPassword(found.PasswordHash, change.OldPassword) {
+		return api.ErrWrongPassword
+	}
+
+	return service.setPassword(userUuid, change.NewPassword, sessionUuid)
+}
+
+// setPassword replaces the password of the user, revoking all its personal tokens
+// and all its sessions, except the given one
+func (service UserIdLoginService) setPassword(userUuid uuid.UUID, password string, exceptSessionUuid uuid.UUID) error {
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return service.Db.UpdatePasswordHashRevokingAccess(userUuid, passwordHash, exceptSessionUuid)
+}
+
+// createSession starts a new session for the given user,
+// returning the token the client must use from now on.
+// Logging in reactivates the account, if it was deactivated.
+func (service UserIdLoginService) createSession(userUuid uuid.UUID, client SessionClient) (userLoginResult, error) {
+	sessionUuid, err := uuid.NewV4()
+	if err != nil {
+		return userLoginResult{}, err
+	}
+
+	if err := service.Db.ReactivateUser(userUuid); err != nil {
+		return userLoginResult{}, err
+	}
+
+	token, tokenHash, err := securetoken.New()
+	if err != nil {
+		return userLoginResult{}, err
+	}
+
+	// Take the chance to clean up old sessions
+	now := service.Time.Now()
+	if err := service.Db.DeleteExpiredSessions(timeprovider.DateToUTCString(now)); err != nil {
+		return userLoginResult{}, err
+	}
+
+	err = service.Db.InsertSession(entitySession{
+		Id:             sessionUuid.Bytes(),
+		TokenHash:      tokenHash,
+		UserId:         userUuid.Bytes(),
+		CreationDate:   timeprovider.DateToUTCString(now),
+		LastUseDate:    timeprovider.DateToUTCString(now),
+		ExpirationDate: timeprovider.DateToUTCString(now.Add(sessionDuration)),
+		UserAgent:      client.UserAgent,
+		RemoteIp:       client.RemoteIp,
+	})
+	if err != nil {
+		return userLoginResult{}, err
+	}
+
+	return userLoginResult{
+		UserId: userUuid.String(),
+		Token:  token,
+	}, nil
+}
+
+// IsAuthenticated checks if the given authToken belongs to an active session,
+// or it's a personal access token, and returns who is using it.
+// The session keeps track of the client which used it most recently.
+// In case no session or token is found, it returns errInvalidSession
+func (service UserIdLoginService) IsAuthenticated(authToken string, client SessionClient) (authInfo, error) {
+	var (
+		info authInfo
+		err  error
+	)
+	if isPersonalToken(authToken) {
+		info, err = service.authenticatePersonalToken(authToken)
+	} else {
+		info, err = service.authenticateSession(authToken, client)
+	}
+	if err != nil {
+		return authInfo{}, err
+	}
+
+	role, err := service.Db.GetUserRole(uuid.FromStringOrNil(info.UserId))
+	if err != nil {
+		return authInfo{}, err
+	}
+	info.Role = route.Role(role)
+
+	// Personal tokens never carry the privileges of moderators and admins,
+	// which require a session
+	if info.TokenId != "" && !route.RoleUser.Includes(info.Role) {
+		info.Role = route.RoleUser
+	}
+
+	return info, nil
+}
+
+func (service UserIdLoginService) authenticateSession(authToken string, client SessionClient) (authInfo, error) {
+	now := service.Time.Now()
+	session, err := service.Db.GetSessionByTokenHash(securetoken.Hash(authToken), timeprovider.DateToUTCString(now))
+	if err != nil {
+		return authInfo{}, err
+	} else if session == nil {
+		return authInfo{}, errInvalidSession
+	}
+
+	// Keep the session alive, since it's been used right now,
+	// unless it's already been done recently by the same client
+	sessionUuid := uuid.FromBytesOrNil(session.Id)
+	sameClient := session.UserAgent == client.UserAgent && session.RemoteIp == client.RemoteIp
+	if !sameClient || !usedRecently(session.LastUseDate, now) {
+		err = service.Db.UpdateSessionUsage(
+			sessionUuid,
+			timeprovider.DateToUTCString(now),
+			timeprovider.DateToUTCString(now.Add(sessionDuration)),
+			client.UserAgent,
+			client.RemoteIp,
+		)
+		if err != nil {
+			return authInfo{}, err
+		}
+	}
+
+	return authInfo{
+		UserId:    uuid.FromBytesOrNil(session.UserId).String(),
+		SessionId: sessionUuid.String(),
+	}, nil
+}
+
+func (service UserIdLoginService) authenticatePersonalToken(authToken string) (authInfo, error) {
+	token, err := service.Db.GetPersonalTokenByHash(securetoken.Hash(authToken))
+	if err != nil {
+		return authInfo{}, err
+	} else if token == nil {
+		return authInfo{}, errInvalidSession
+	}
+
+	tokenUuid := uuid.FromBytesOrNil(token.Id)
+	now := service.Time.Now()
+	if !usedRecently(token.LastUseDate, now) {
+		if err := service.Db.UpdatePersonalTokenUsage(tokenUuid, timeprovider.DateToUTCString(now)); err != nil {
+			return authInfo{}, err
+		}
+	}
+
+	return authInfo{
+		UserId:  uuid.FromBytesOrNil(token.UserId).String(),
+		TokenId: tokenUuid.String(),
+		Scopes:  parseScopes(token.Scopes),
+	}, nil
+}
+
+// usedRecently tells if the given last use date, if any, is within usageUpdateInterval from now
+func usedRecently(lastUseDate string, now time.Time) bool {
+	lastUse, err := timeprovider.UTCStringToDate(lastUseDate)
+	return err == nil && now.Sub(lastUse) < usageUpdateInterval
+}
+
+// Logout revokes the given session, so that its token cannot be used anymore.
+func (service UserIdLoginService) Logout(sessionId string) error {
+	sessionUuid := uuid.FromStringOrNil(sessionId)
+	if sessionUuid.IsNil() {
+		return api.ErrWrongUUID
 	}
 
-	return uuid.FromBytesOrNil(foundUser.Id).String(), nil
+	return service.Db.DeleteSession(sessionUuid)
 }
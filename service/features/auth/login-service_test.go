@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+)
+
+func TestSessionUsageUpdateInterval(t *testing.T) {
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	service := UserIdLoginService{Db: DbDao{Db: databasetest.New(t)}, Time: clock}
+
+	userUuid := uuid.Must(uuid.NewV4())
+	newUser := user.ModelUser{Id: userUuid.Bytes(), Name: "John", Username: "john_doe"}
+	if err := service.Db.InsertUserWithPassword(newUser, "", "john@example.com", clock.UTCString()); err != nil {
+		t.Fatal(err)
+	}
+
+	client := SessionClient{UserAgent: "Firefox", RemoteIp: "192.0.2.1"}
+	login, err := service.createSession(userUuid, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkLastUse authenticates with the session, then asserts when it's been used for the last time
+	checkLastUse := func(client SessionClient, expected time.Time) {
+		t.Helper()
+
+		if _, err := service.authenticateSession(login.Token, client); err != nil {
+			t.Fatal(err)
+		}
+		session, err := service.Db.GetSessionByTokenHash(securetoken.Hash(login.Token), clock.UTCString())
+		if err != nil {
+			t.Fatal(err)
+		} else if session.LastUseDate != timeprovider.DateToUTCString(expected) {
+			t.Errorf("last use %s, expected %s", session.LastUseDate, timeprovider.DateToUTCString(expected))
+		}
+	}
+
+	created := clock.Now()
+	clock.MockTime = created.Add(usageUpdateInterval - time.Second)
+	checkLastUse(client, created)
+
+	// The same session used by another client is saved right away
+	otherClient := SessionClient{UserAgent: "Firefox", RemoteIp: "192.0.2.2"}
+	checkLastUse(otherClient, clock.Now())
+
+	clock.MockTime = clock.MockTime.Add(usageUpdateInterval)
+	checkLastUse(otherClient, clock.Now())
+}
+
+func TestPersonalTokensActAsUsers(t *testing.T) {
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	db := databasetest.New(t)
+	service := UserIdLoginService{Db: DbDao{Db: db}, Time: clock}
+
+	userUuid := uuid.Must(uuid.NewV4())
+	newUser := user.ModelUser{Id: userUuid.Bytes(), Name: "John", Username: "john_doe"}
+	if err := service.Db.InsertUserWithPassword(newUser, "", "john@example.com", clock.UTCString()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (user.DbDao{Db: db}).SetUserRole(userUuid, string(route.RoleModerator)); err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := service.createSession(userUuid, SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, tokenHash, err := generatePersonalToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = service.Db.InsertPersonalToken(entityPersonalToken{
+		Id:           uuid.Must(uuid.NewV4()).Bytes(),
+		UserId:       userUuid.Bytes(),
+		Name:         "bot",
+		TokenHash:    tokenHash,
+		Scopes:       string(route.ScopePhotosWrite),
+		CreationDate: clock.UTCString(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := service.IsAuthenticated(login.Token, SessionClient{}); err != nil || info.Role != route.RoleModerator {
+		t.Errorf("expected a moderator session, got %+v, %v", info, err)
+	}
+	if info, err := service.IsAuthenticated(token, SessionClient{}); err != nil || info.Role != route.RoleUser {
+		t.Errorf("expected a personal token acting as a user, got %+v, %v", info, err)
+	}
+}
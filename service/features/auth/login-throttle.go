@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"time"
+)
+
+// LoginThrottle is checked by the LoginService before verifying the credentials,
+// to slow down brute-force and credential stuffing attacks.
+//
+// The same storage limits the emails sent on request, like password resets,
+// so that they can't be used to flood someone's inbox.
+type LoginThrottle interface {
+	Check(username string, remoteIp string) error
+	RecordFailure(username string, remoteIp string) error
+	RecordSuccess(username string) error
+	RecordEmail(email string, remoteIp string) error
+}
+
+const (
+	// usernameFreeFailures is how many wrong logins are allowed on a username before locking it
+	usernameFreeFailures = 5
+
+	// ipFreeFailures is how many wrong logins are allowed from an IP address before locking it.
+	// It's higher, since many users can share the same IP address.
+	ipFreeFailures = 20
+
+	// baseLockout is the lockout after the first failure exceeding the free ones.
+	// It doubles at every further failure, up to maxLockout.
+	baseLockout = 15 * time.Second
+	maxLockout  = 15 * time.Minute
+
+	// failuresMemory is how long failures are remembered after the last one
+	failuresMemory = 24 * time.Hour
+
+	// emailAddressFreeSends is how many emails can be sent to an address before locking it,
+	// with the same backoff as failed logins
+	emailAddressFreeSends = 3
+
+	// emailIpFreeSends is how many emails can be requested from an IP address before locking it
+	emailIpFreeSends = 10
+)
+
+// Kinds of counters of failed logins
+const (
+	loginFailureByUsername = "username"
+	loginFailureByIp       = "ip"
+	emailSentToAddress     = "emailAddress"
+	emailSentFromIp        = "emailIp"
+)
+
+// loginCounter identifies a counter of failed logins
+type loginCounter struct {
+	kind         string
+	subject      string
+	freeFailures int64
+}
+
+type LoginThrottleImpl struct {
+	Db   Dao
+	Time timeprovider.TimeProvider
+}
+
+// Check returns an api.RetryAfterError if the username or the IP address are locked
+func (throttle LoginThrottleImpl) Check(username string, remoteIp string) error {
+	return throttle.check(loginCounters(username, remoteIp))
+}
+
+func (throttle LoginThrottleImpl) check(counters []loginCounter) error {
+	now := throttle.Time.Now()
+
+	var retryAfter time.Duration
+	for _, counter := range counters {
+		failure, err := throttle.Db.GetLoginFailure(counter.kind, counter.subject)
+		if err != nil {
+			return err
+		} else if failure == nil {
+			continue
+		}
+
+		lockedUntil, err := timeprovider.UTCStringToDate(failure.LockedUntilDate)
+		if err != nil {
+			return err
+		}
+
+		if wait := lockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return api.RetryAfterError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a wrong login, locking the username and the IP address
+// if they exceeded the free failures.
+func (throttle LoginThrottleImpl) RecordFailure(username string, remoteIp string) error {
+	return throttle.record(loginCounters(username, remoteIp))
+}
+
+// RecordEmail counts an email sent on request to the given address,
+// returning an api.RetryAfterError if the address or the IP address are locked.
+func (throttle LoginThrottleImpl) RecordEmail(email string, remoteIp string) error {
+	counters := []loginCounter{{kind: emailSentToAddress, subject: email, freeFailures: emailAddressFreeSends}}
+	if remoteIp != "" {
+		counters = append(counters, loginCounter{kind: emailSentFromIp, subject: remoteIp, freeFailures: emailIpFreeSends})
+	}
+
+	if err := throttle.check(counters); err != nil {
+		return err
+	}
+	return throttle.record(counters)
+}
+
+// record increments the counters, locking the ones which exceeded the free failures
+func (throttle LoginThrottleImpl) record(counters []loginCounter) error {
+	now := throttle.Time.Now()
+
+	// Forget old failures first
+	err := throttle.Db.DeleteStaleLoginFailures(timeprovider.DateToUTCString(now.Add(-failuresMemory)))
+	if err != nil {
+		return err
+	}
+
+	for _, counter := range counters {
+		failures, err := throttle.Db.IncrementLoginFailures(counter.kind, counter.subject, timeprovider.DateToUTCString(now))
+		if err != nil {
+			return err
+		}
+
+		lockout := lockoutDuration(failures - counter.freeFailures)
+		if lockout == 0 {
+			continue
+		}
+
+		err = throttle.Db.ExtendLoginLockout(counter.kind, counter.subject, timeprovider.DateToUTCString(now.Add(lockout)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess resets the failures of the username.
+// Failures of the IP address are kept instead,
+// otherwise an attacker could reset them logging into its own account.
+func (throttle LoginThrottleImpl) RecordSuccess(username string) error {
+	return throttle.Db.DeleteLoginFailure(loginFailureByUsername, username)
+}
+
+func loginCounters(username string, remoteIp string) []loginCounter {
+	counters := []loginCounter{{kind: loginFailureByUsername, subject: username, freeFailures: usernameFreeFailures}}
+	if remoteIp != "" {
+		counters = append(counters, loginCounter{kind: loginFailureByIp, subject: remoteIp, freeFailures: ipFreeFailures})
+	}
+	return counters
+}
+
+// lockoutDuration calculates the exponential backoff,
+// given how many failures exceeded the free ones.
+func lockoutDuration(exceedingFailures int64) time.Duration {
+	if exceedingFailures <= 0 {
+		return 0
+	}
+
+	lockout := baseLockout
+	for i := int64(1); i < exceedingFailures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+
+	if lockout > maxLockout {
+		return maxLockout
+	}
+	return lockout
+}
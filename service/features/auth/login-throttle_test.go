@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+)
+
+func newTestLoginThrottle(t *testing.T) (LoginThrottleImpl, *timeprovider.MockTimeProvider) {
+	t.Helper()
+
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	return LoginThrottleImpl{Db: DbDao{Db: databasetest.New(t)}, Time: clock}, clock
+}
+
+// recordFailures counts the given number of wrong logins
+func recordFailures(t *testing.T, throttle LoginThrottle, username string, remoteIp string, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		if err := throttle.RecordFailure(username, remoteIp); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkRetryAfter asserts how long the login is locked, where zero means not locked
+func checkRetryAfter(t *testing.T, throttle LoginThrottle, username string, remoteIp string, expected time.Duration) {
+	t.Helper()
+
+	err := throttle.Check(username, remoteIp)
+	var retryAfterErr api.RetryAfterError
+	switch {
+	case expected == 0 && err != nil:
+		t.Errorf("expected no lockout, got %v", err)
+	case expected > 0 && !errors.As(err, &retryAfterErr):
+		t.Errorf("expected a lockout of %v, got %v", expected, err)
+	case expected > 0 && retryAfterErr.RetryAfter != expected:
+		t.Errorf("expected a lockout of %v, got %v", expected, retryAfterErr.RetryAfter)
+	case expected > 0 && !errors.Is(err, api.ErrTooManyRequests):
+		t.Errorf("expected an ErrTooManyRequests, got %v", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	testCases := map[int64]time.Duration{
+		-3:  0,
+		0:   0,
+		1:   15 * time.Second,
+		2:   30 * time.Second,
+		3:   time.Minute,
+		6:   8 * time.Minute,
+		7:   maxLockout,
+		100: maxLockout,
+	}
+
+	for exceedingFailures, expected := range testCases {
+		if lockout := lockoutDuration(exceedingFailures); lockout != expected {
+			t.Errorf("lockout after %d exceeding failures is %v, expected %v", exceedingFailures, lockout, expected)
+		}
+	}
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	throttle, clock := newTestLoginThrottle(t)
+
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures)
+	checkRetryAfter(t, throttle, "john_doe", "", 0)
+
+	// Every further failure doubles the lockout
+	for _, expected := range []time.Duration{15 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute} {
+		recordFailures(t, throttle, "john_doe", "", 1)
+		checkRetryAfter(t, throttle, "john_doe", "", expected)
+
+		// The wait decreases as time goes by
+		clock.MockTime = clock.MockTime.Add(10 * time.Second)
+		checkRetryAfter(t, throttle, "john_doe", "", expected-10*time.Second)
+
+		// Until the lockout expires
+		clock.MockTime = clock.MockTime.Add(expected - 10*time.Second)
+		checkRetryAfter(t, throttle, "john_doe", "", 0)
+	}
+
+	// Other usernames are not affected
+	checkRetryAfter(t, throttle, "someone_else", "", 0)
+}
+
+func TestLoginThrottleMaxLockout(t *testing.T) {
+	throttle, _ := newTestLoginThrottle(t)
+
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures+50)
+	checkRetryAfter(t, throttle, "john_doe", "", maxLockout)
+}
+
+func TestLoginThrottleConcurrentFailures(t *testing.T) {
+	throttle, _ := newTestLoginThrottle(t)
+
+	// No failure is lost, even if they're recorded at the same time
+	var wg sync.WaitGroup
+	errs := make(chan error, usernameFreeFailures+3)
+	for i := 0; i < usernameFreeFailures+3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- throttle.RecordFailure("john_doe", "")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkRetryAfter(t, throttle, "john_doe", "", lockoutDuration(3))
+}
+
+func TestLoginThrottleForgetsOldFailures(t *testing.T) {
+	throttle, clock := newTestLoginThrottle(t)
+
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures)
+	clock.MockTime = clock.MockTime.Add(failuresMemory + time.Second)
+
+	// Counting starts again
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures)
+	checkRetryAfter(t, throttle, "john_doe", "", 0)
+}
+
+func TestLoginThrottleByIp(t *testing.T) {
+	throttle, _ := newTestLoginThrottle(t)
+
+	// Trying many usernames from the same address
+	for i := 0; i < ipFreeFailures; i++ {
+		recordFailures(t, throttle, uuid.Must(uuid.NewV4()).String()[:16], "192.0.2.1", 1)
+	}
+	checkRetryAfter(t, throttle, "john_doe", "192.0.2.1", 0)
+
+	recordFailures(t, throttle, "john_doe", "192.0.2.1", 1)
+	checkRetryAfter(t, throttle, "john_doe", "192.0.2.1", baseLockout)
+	checkRetryAfter(t, throttle, "someone_else", "192.0.2.1", baseLockout)
+	checkRetryAfter(t, throttle, "someone_else", "192.0.2.2", 0)
+
+	// Logging into another account doesn't unlock the address
+	if err := throttle.RecordSuccess("attacker"); err != nil {
+		t.Fatal(err)
+	}
+	checkRetryAfter(t, throttle, "someone_else", "192.0.2.1", baseLockout)
+}
+
+func TestLoginThrottleSuccessResetsUsername(t *testing.T) {
+	throttle, clock := newTestLoginThrottle(t)
+
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures+1)
+	clock.MockTime = clock.MockTime.Add(baseLockout)
+	if err := throttle.RecordSuccess("john_doe"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The free failures are available again
+	recordFailures(t, throttle, "john_doe", "", usernameFreeFailures)
+	checkRetryAfter(t, throttle, "john_doe", "", 0)
+}
+
+func TestAuthenticateThrottled(t *testing.T) {
+	throttle, clock := newTestLoginThrottle(t)
+	service := UserIdLoginService{Db: throttle.Db, Time: clock, Throttle: throttle}
+
+	passwordHash, err := hashPassword("right password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUser := user.ModelUser{Id: uuid.Must(uuid.NewV4()).Bytes(), Name: "John", Username: "john_doe"}
+	if err := service.Db.InsertUserWithPassword(newUser, passwordHash, "john@example.com", clock.UTCString()); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := userLoginCredentials{Username: "john_doe", Password: "wrong password"}
+	for i := 0; i <= usernameFreeFailures; i++ {
+		if _, err := service.Authenticate(wrong, SessionClient{}); !errors.Is(err, api.ErrWrongCredentials) {
+			t.Fatalf("expected ErrWrongCredentials, got %v", err)
+		}
+	}
+
+	// Even the right password is refused during the lockout
+	right := userLoginCredentials{Username: "john_doe", Password: "right password"}
+	var retryAfterErr api.RetryAfterError
+	if _, err := service.Authenticate(right, SessionClient{}); !errors.As(err, &retryAfterErr) || retryAfterErr.RetryAfter != baseLockout {
+		t.Fatalf("expected a lockout of %v, got %v", baseLockout, err)
+	}
+
+	clock.MockTime = clock.MockTime.Add(baseLockout)
+	if _, err := service.Authenticate(right, SessionClient{}); err != nil {
+		t.Fatalf("expected a successful login after the lockout, got %v", err)
+	}
+}
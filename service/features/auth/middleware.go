@@ -11,7 +11,7 @@ type Middleware struct {
 	LoginService LoginService
 }
 
-func (middleware Middleware) Intercept(handler route.SecureHandler) route.Handler {
+func (middleware Middleware) Intercept(handler route.SecureHandler, requiredScope route.Scope, requiredRole route.Role) route.Handler {
 	return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params, context route.RequestContext) {
 		const bearerPrefix = "Bearer "
 		authorization := request.Header.Get("Authorization")
@@ -22,7 +22,7 @@ func (middleware Middleware) Intercept(handler route.SecureHandler) route.Handle
 		}
 
 		authToken := strings.TrimPrefix(authorization, bearerPrefix)
-		userId, err := middleware.LoginService.IsAuthenticated(authToken)
+		info, err := middleware.LoginService.IsAuthenticated(authToken, clientFromContext(context))
 		if err != nil {
 			// Authentication is invalid
 			context.Logger.WithError(err).Debug("Error checking authentication")
@@ -30,10 +30,26 @@ func (middleware Middleware) Intercept(handler route.SecureHandler) route.Handle
 			return
 		}
 
+		if !info.allows(requiredScope) {
+			// Personal access token without the required scope
+			context.Logger.Debugf("Personal access token %s lacks scope '%s'", info.TokenId, requiredScope)
+			http.Error(writer, "Forbidden: the token is not allowed to perform this operation", 403)
+			return
+		}
+
+		if requiredRole != "" && !info.Role.Includes(requiredRole) {
+			// Ordinary user trying to perform a privileged operation
+			context.Logger.Debugf("User %s with role '%s' lacks role '%s'", info.UserId, info.Role, requiredRole)
+			http.Error(writer, "Forbidden: your role is not allowed to perform this operation", 403)
+			return
+		}
+
 		// Create secure context
 		secureContext := route.SecureRequestContext{
 			RequestContext: context,
-			UserId:         userId,
+			UserId:         info.UserId,
+			SessionId:      info.SessionId,
+			Role:           info.Role,
 		}
 
 		// Authentication is valid!
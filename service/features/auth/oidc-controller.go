@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"net/http"
+)
+
+// OidcController exposes the login through an external OpenID Connect provider.
+// It's registered only if a provider is configured.
+type OidcController struct {
+	AuthService ExternalLoginService
+}
+
+func (controller OidcController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.AnonymousRoute{
+			Method:  http.MethodPost,
+			Path:    "/session/oidc",
+			Handler: controller.startLogin,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodPost,
+			Path:    "/session/oidc/callback",
+			Handler: controller.completeLogin,
+		},
+	}
+}
+
+func (controller OidcController) startLogin(w http.ResponseWriter, _ *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	result, err := controller.AuthService.StartExternalLogin()
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, ctx.Logger)
+	} else {
+		api.SendJson(w, result, http.StatusOK, ctx.Logger)
+	}
+}
+
+func (controller OidcController) completeLogin(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx route.RequestContext) {
+	body, bodyErr := api.ParseAndValidateBody(r, &externalLoginCallback{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	result, isNew, err := controller.AuthService.CompleteExternalLogin(*body, clientFromContext(ctx))
+
+	responseStatus := http.StatusOK
+	if isNew {
+		responseStatus = http.StatusCreated
+	}
+
+	if err != nil {
+		ctx.Logger.WithError(err).Debug("External login failed")
+		api.HandleErrorsResponse(err, w, responseStatus, ctx.Logger)
+	} else {
+		api.SendJson(w, result, responseStatus, ctx.Logger)
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ExternalLoginService is a LoginService which can also
+// authenticate users through an external identity provider.
+type ExternalLoginService interface {
+	LoginService
+	StartExternalLogin() (externalLoginStart, error)
+	CompleteExternalLogin(callback externalLoginCallback, client SessionClient) (result userLoginResult, isNew bool, err error)
+}
+
+// oidcStateDuration is how long the user can take to log in on the provider
+const oidcStateDuration = 10 * time.Minute
+
+// OidcLoginService allows logging in through an OpenID Connect provider,
+// in addition to everything UserIdLoginService does.
+//
+// The first time someone logs in, a new user is created and linked
+// to the subject of the provider, which is used from then on to find it.
+type OidcLoginService struct {
+	UserIdLoginService
+
+	// Dependencies
+	Provider *oidc.Provider
+	Issuer   string
+}
+
+// StartExternalLogin generates the URL where the user must be sent to log in.
+// The PKCE code verifier and the nonce are kept server side, bound to the returned state.
+func (service OidcLoginService) StartExternalLogin() (externalLoginStart, error) {
+	state, err := oidc.RandomString()
+	if err != nil {
+		return externalLoginStart{}, err
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		return externalLoginStart{}, err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return externalLoginStart{}, err
+	}
+
+	authorizationUrl, err := service.Provider.AuthorizationUrl(state, nonce, oidc.CodeChallenge(codeVerifier))
+	if errors.Is(err, oidc.ErrProvider) {
+		return externalLoginStart{}, fmt.Errorf("%w: %v", api.ErrThirdParty, err)
+	} else if err != nil {
+		return externalLoginStart{}, err
+	}
+
+	// Take the chance to clean up abandoned logins
+	now := service.Time.Now()
+	if err := service.Db.DeleteExpiredOidcLoginStates(timeprovider.DateToUTCString(now)); err != nil {
+		return externalLoginStart{}, err
+	}
+
+	err = service.Db.InsertOidcLoginState(entityOidcLoginState{
+		State:          state,
+		CodeVerifier:   codeVerifier,
+		Nonce:          nonce,
+		ExpirationDate: timeprovider.DateToUTCString(now.Add(oidcStateDuration)),
+	})
+	if err != nil {
+		return externalLoginStart{}, err
+	}
+
+	return externalLoginStart{
+		AuthorizationUrl: authorizationUrl,
+		State:            state,
+	}, nil
+}
+
+// CompleteExternalLogin redeems the code the provider sent back,
+// and starts a new session for the linked user, creating it if needed.
+//
+// The second factor is not checked here, since it's up to the provider.
+func (service OidcLoginService) CompleteExternalLogin(callback externalLoginCallback, client SessionClient) (userLoginResult, bool, error) {
+	now := service.Time.UTCString()
+	loginState, err := service.Db.ConsumeOidcLoginState(callback.State, now)
+	if err != nil {
+		return userLoginResult{}, false, err
+	} else if loginState == nil {
+		return userLoginResult{}, false, api.ErrWrongCredentials
+	}
+
+	claims, err := service.Provider.ExchangeCode(callback.Code, loginState.CodeVerifier, loginState.Nonce)
+	switch {
+	case errors.Is(err, oidc.ErrProvider):
+		return userLoginResult{}, false, fmt.Errorf("%w: %v", api.ErrThirdParty, err)
+	case errors.Is(err, oidc.ErrInvalidToken):
+		return userLoginResult{}, false, fmt.Errorf("%w: %v", api.ErrWrongCredentials, err)
+	case err != nil:
+		return userLoginResult{}, false, err
+	}
+
+	userUuid, err := service.Db.GetExternalIdentityUserId(service.Issuer, claims.Subject)
+	if err != nil {
+		return userLoginResult{}, false, err
+	}
+
+	isNew := userUuid.IsNil()
+	if isNew {
+		userUuid, err = service.createExternalUser(claims, now)
+		if err != nil {
+			return userLoginResult{}, false, err
+		}
+	}
+
+	result, err := service.createSession(userUuid, client)
+	return result, isNew, err
+}
+
+// maxNameLength is the maximum length of name and surname accepted by the user profile
+const maxNameLength = 256
+
+// maxUsernameAttempts is how many random usernames are tried
+// if the one suggested by the provider is already taken
+const maxUsernameAttempts = 5
+
+func (service OidcLoginService) createExternalUser(claims oidc.Claims, now string) (uuid.UUID, error) {
+	newUuid, err := uuid.NewV4()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	name := claims.GivenName
+	if name == "" {
+		name = claims.Name
+	}
+
+	baseUsername := usernameFromClaims(claims)
+	if name == "" {
+		name = baseUsername
+	}
+
+	newUser := user.ModelUser{
+		Id:       newUuid.Bytes(),
+		Name:     limitLength(name, maxNameLength),
+		Surname:  limitLength(claims.FamilyName, maxNameLength),
+		Username: baseUsername,
+	}
+
+	for attempt := 0; attempt < maxUsernameAttempts; attempt++ {
+		err = service.Db.InsertUserWithExternalIdentity(newUser, service.Issuer, claims.Subject, now)
+		if !errors.Is(err, database.ErrDuplicated) {
+			return newUuid, err
+		}
+
+		// Username already taken, try with a random suffix
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return uuid.Nil, err
+		}
+		newUser.Username = fmt.Sprintf("%.11s_%04d", baseUsername, suffix.Int64())
+	}
+
+	return uuid.Nil, api.ErrAlreadyTaken
+}
+
+var usernameForbiddenChars = regexp.MustCompile("[^a-z_0-9]+")
+
+// usernameFromClaims suggests a valid username for a new user,
+// according to what the provider knows about the user.
+func usernameFromClaims(claims oidc.Claims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	username := usernameForbiddenChars.ReplaceAllString(strings.ToLower(candidate), "_")
+	if len(username) > 16 {
+		username = username[:16]
+	}
+	for len(username) < 3 {
+		username += "_"
+	}
+	return username
+}
+
+// limitLength truncates the string to the given amount of characters
+func limitLength(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength])
+	}
+	return value
+}
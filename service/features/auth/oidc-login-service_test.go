@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc/oidctest"
+)
+
+func newTestOidcLoginService(t *testing.T) (OidcLoginService, *oidctest.Provider, *timeprovider.MockTimeProvider) {
+	t.Helper()
+
+	stub, err := oidctest.NewProvider("wasaphoto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stub.Close)
+
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	service := OidcLoginService{
+		UserIdLoginService: UserIdLoginService{
+			Db:   DbDao{Db: databasetest.New(t)},
+			Time: clock,
+		},
+		Provider: oidc.NewProvider(oidc.Config{
+			Issuer:      stub.URL,
+			ClientId:    stub.ClientId,
+			RedirectUrl: "http://localhost/",
+		}, stub.Client(), clock),
+		Issuer: stub.URL,
+	}
+
+	return service, stub, clock
+}
+
+// loginOnProvider starts an external login, and logs in on the provider with the given claims
+func loginOnProvider(t *testing.T, service OidcLoginService, stub *oidctest.Provider, claims map[string]any) externalLoginCallback {
+	t.Helper()
+
+	start, err := service.StartExternalLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := stub.Authorize(start.AuthorizationUrl, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return externalLoginCallback{Code: code, State: start.State}
+}
+
+func TestCompleteExternalLogin(t *testing.T) {
+	service, stub, clock := newTestOidcLoginService(t)
+	claims := stub.Claims("subject-1", clock.Now())
+	claims["preferred_username"] = "John.Doe"
+	claims["given_name"] = "John"
+	claims["family_name"] = "Doe"
+
+	first, isNew, err := service.CompleteExternalLogin(loginOnProvider(t, service, stub, claims), SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	} else if !isNew {
+		t.Error("expected a new user on the first login")
+	}
+
+	created, err := service.Db.GetCredentialsByUsername("john_doe")
+	if err != nil {
+		t.Fatal(err)
+	} else if created == nil {
+		t.Fatal("expected a user named john_doe")
+	} else if created.PasswordHash != "" {
+		t.Error("expected a user without password")
+	}
+
+	second, isNew, err := service.CompleteExternalLogin(loginOnProvider(t, service, stub, claims), SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	} else if isNew || second.UserId != first.UserId {
+		t.Errorf("expected the same user %s on the second login, got %s", first.UserId, second.UserId)
+	}
+
+	info, err := service.IsAuthenticated(second.Token, SessionClient{})
+	if err != nil || info.UserId != first.UserId {
+		t.Errorf("expected a valid session for %s, got %+v, %v", first.UserId, info, err)
+	}
+}
+
+func TestCompleteExternalLoginConsumesState(t *testing.T) {
+	service, stub, clock := newTestOidcLoginService(t)
+	callback := loginOnProvider(t, service, stub, stub.Claims("subject-1", clock.Now()))
+
+	if _, _, err := service.CompleteExternalLogin(callback, SessionClient{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := service.CompleteExternalLogin(callback, SessionClient{})
+	if !errors.Is(err, api.ErrWrongCredentials) {
+		t.Errorf("expected ErrWrongCredentials reusing the state, got %v", err)
+	}
+}
+
+func TestCompleteExternalLoginExpiredState(t *testing.T) {
+	service, stub, clock := newTestOidcLoginService(t)
+	callback := loginOnProvider(t, service, stub, stub.Claims("subject-1", clock.Now()))
+
+	clock.MockTime = clock.MockTime.Add(oidcStateDuration + time.Second)
+	_, _, err := service.CompleteExternalLogin(callback, SessionClient{})
+	if !errors.Is(err, api.ErrWrongCredentials) {
+		t.Errorf("expected ErrWrongCredentials with an expired state, got %v", err)
+	}
+}
+
+func TestCompleteExternalLoginUnknownState(t *testing.T) {
+	service, stub, clock := newTestOidcLoginService(t)
+	callback := loginOnProvider(t, service, stub, stub.Claims("subject-1", clock.Now()))
+
+	callback.State = "unknown"
+	_, _, err := service.CompleteExternalLogin(callback, SessionClient{})
+	if !errors.Is(err, api.ErrWrongCredentials) {
+		t.Errorf("expected ErrWrongCredentials with an unknown state, got %v", err)
+	}
+}
+
+func TestCompleteExternalLoginSwappedState(t *testing.T) {
+	service, stub, clock := newTestOidcLoginService(t)
+	first := loginOnProvider(t, service, stub, stub.Claims("subject-1", clock.Now()))
+	second := loginOnProvider(t, service, stub, stub.Claims("subject-2", clock.Now()))
+
+	// The code of the first login doesn't match the PKCE verifier and the nonce of the second one
+	_, _, err := service.CompleteExternalLogin(externalLoginCallback{Code: first.Code, State: second.State}, SessionClient{})
+	if !errors.Is(err, api.ErrWrongCredentials) {
+		t.Errorf("expected ErrWrongCredentials, got %v", err)
+	}
+}
+
+func TestCompleteExternalLoginInvalidToken(t *testing.T) {
+	testCases := map[string]func(claims map[string]any){
+		"wrong nonce":    func(claims map[string]any) { claims["nonce"] = "another-nonce" },
+		"wrong audience": func(claims map[string]any) { claims["aud"] = "another-client" },
+		"expired":        func(claims map[string]any) { claims["exp"] = int64(0) },
+	}
+
+	for name, alterClaims := range testCases {
+		t.Run(name, func(t *testing.T) {
+			service, stub, clock := newTestOidcLoginService(t)
+			claims := stub.Claims("subject-1", clock.Now())
+			alterClaims(claims)
+
+			_, _, err := service.CompleteExternalLogin(loginOnProvider(t, service, stub, claims), SessionClient{})
+			if !errors.Is(err, api.ErrWrongCredentials) {
+				t.Errorf("expected ErrWrongCredentials, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStartExternalLoginProviderDown(t *testing.T) {
+	service, stub, _ := newTestOidcLoginService(t)
+	stub.Close()
+
+	_, err := service.StartExternalLogin()
+	if !errors.Is(err, api.ErrThirdParty) {
+		t.Errorf("expected ErrThirdParty, got %v", err)
+	}
+}
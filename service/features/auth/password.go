@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"sync"
+)
+
+// hashPassword computes the hash of a password, ready to be stored.
+// The resulting string also includes the random salt and the algorithm parameters.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether the given password matches the stored hash.
+//
+// If the hash is empty (e.g., the user doesn't exist),
+// it still spends the same time as a real check,
+// in order not to reveal which usernames exist using a timing attack.
+func checkPassword(passwordHash string, password string) bool {
+	if passwordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(getDummyPasswordHash(), []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+func getDummyPasswordHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	return dummyPasswordHash
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"net/http"
+)
+
+type PersonalTokenController struct {
+	Service PersonalTokenService
+}
+
+func (controller PersonalTokenController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/tokens/",
+			Handler: controller.listTokens,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/:userId/tokens/",
+			Handler: controller.createToken,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/tokens/:tokenId",
+			Handler: controller.revokeToken,
+		},
+	}
+}
+
+func (controller PersonalTokenController) listTokens(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	tokens, err := controller.Service.ListTokens(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, tokens, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller PersonalTokenController) createToken(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, body, bodyErr := api.ParseVariablesAndBody(r, params, &user.IdParams{}, &newPersonalToken{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	token, err := controller.Service.CreateToken(args.UserId, *body)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusCreated, context.Logger)
+	} else {
+		api.SendJson(w, token, http.StatusCreated, context.Logger)
+	}
+}
+
+func (controller PersonalTokenController) revokeToken(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &personalTokenParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.RevokeToken(args.UserId, args.TokenId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
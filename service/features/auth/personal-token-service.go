@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"strings"
+)
+
+type PersonalTokenService interface {
+	CreateToken(userId string, newToken newPersonalToken) (createdPersonalToken, error)
+	ListTokens(userId string) ([]personalToken, error)
+	RevokeToken(userId string, tokenId string) error
+}
+
+type PersonalTokenServiceImpl struct {
+	Db   Dao
+	Time timeprovider.TimeProvider
+}
+
+// CreateToken generates a new personal access token with the given scopes.
+// The token itself is returned only this time.
+func (service PersonalTokenServiceImpl) CreateToken(userId string, newToken newPersonalToken) (createdPersonalToken, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return createdPersonalToken{}, api.ErrWrongUUID
+	}
+
+	tokenUuid, err := uuid.NewV4()
+	if err != nil {
+		return createdPersonalToken{}, err
+	}
+
+	token, tokenHash, err := generatePersonalToken()
+	if err != nil {
+		return createdPersonalToken{}, err
+	}
+
+	entity := entityPersonalToken{
+		Id:           tokenUuid.Bytes(),
+		UserId:       userUuid.Bytes(),
+		Name:         newToken.Name,
+		TokenHash:    tokenHash,
+		Scopes:       formatScopes(newToken.Scopes),
+		CreationDate: service.Time.UTCString(),
+	}
+	if err := service.Db.InsertPersonalToken(entity); err != nil {
+		return createdPersonalToken{}, err
+	}
+
+	return createdPersonalToken{
+		personalToken: entity.toDto(),
+		Token:         token,
+	}, nil
+}
+
+func (service PersonalTokenServiceImpl) ListTokens(userId string) ([]personalToken, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return nil, api.ErrWrongUUID
+	}
+
+	entities, err := service.Db.GetUserPersonalTokens(userUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]personalToken, len(entities))
+	for i, entity := range entities {
+		tokens[i] = entity.toDto()
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes a personal access token, so that it cannot be used anymore.
+// If the token doesn't belong to the user, it returns api.ErrNotFound
+func (service PersonalTokenServiceImpl) RevokeToken(userId string, tokenId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	tokenUuid := uuid.FromStringOrNil(tokenId)
+	if userUuid.IsNil() || tokenUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	found, err := service.Db.DeleteUserPersonalToken(userUuid, tokenUuid)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// formatScopes converts a list of scopes to the format stored in the database
+func formatScopes(scopes []route.Scope) string {
+	rawScopes := make([]string, len(scopes))
+	for i, scope := range scopes {
+		rawScopes[i] = string(scope)
+	}
+	return strings.Join(rawScopes, " ")
+}
+
+// parseScopes converts the scopes stored in the database to a list
+func parseScopes(rawScopes string) []route.Scope {
+	fields := strings.Fields(rawScopes)
+	scopes := make([]route.Scope, len(fields))
+	for i, field := range fields {
+		scopes[i] = route.Scope(field)
+	}
+	return scopes
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"net/http"
+)
+
+type SessionController struct {
+	Service SessionService
+}
+
+func (controller SessionController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/sessions/",
+			Handler: controller.listSessions,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/sessions/",
+			Handler: controller.revokeOtherSessions,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/sessions/:sessionId",
+			Handler: controller.revokeSession,
+		},
+	}
+}
+
+func (controller SessionController) listSessions(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	sessions, err := controller.Service.ListSessions(args.UserId, context.SessionId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, sessions, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller SessionController) revokeOtherSessions(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.RevokeOtherSessions(args.UserId, context.SessionId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+func (controller SessionController) revokeSession(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &sessionParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.RevokeSession(args.UserId, args.SessionId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
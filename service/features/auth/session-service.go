@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+)
+
+type SessionService interface {
+	ListSessions(userId string, currentSessionId string) ([]activeSession, error)
+	RevokeSession(userId string, sessionId string) error
+	RevokeOtherSessions(userId string, currentSessionId string) error
+}
+
+type SessionServiceImpl struct {
+	Db   Dao
+	Time timeprovider.TimeProvider
+}
+
+// ListSessions returns the active sessions of the given user,
+// marking the one used to perform the current request.
+func (service SessionServiceImpl) ListSessions(userId string, currentSessionId string) ([]activeSession, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return nil, api.ErrWrongUUID
+	}
+
+	entities, err := service.Db.GetUserSessions(userUuid, service.Time.UTCString())
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]activeSession, len(entities))
+	for i, entity := range entities {
+		sessionId := uuid.FromBytesOrNil(entity.Id).String()
+		sessions[i] = activeSession{
+			Id:             sessionId,
+			CreationDate:   entity.CreationDate,
+			LastUseDate:    entity.LastUseDate,
+			ExpirationDate: entity.ExpirationDate,
+			UserAgent:      entity.UserAgent,
+			RemoteIp:       entity.RemoteIp,
+			Current:        sessionId == currentSessionId,
+		}
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs out the device using the given session.
+// If the session doesn't belong to the user, it returns api.ErrNotFound
+func (service SessionServiceImpl) RevokeSession(userId string, sessionId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	sessionUuid := uuid.FromStringOrNil(sessionId)
+	if userUuid.IsNil() || sessionUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	found, err := service.Db.DeleteUserSession(userUuid, sessionUuid)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions logs out every device of the user,
+// except the one which is performing this request.
+// Personal tokens are revoked too, since they give access to the account as well.
+func (service SessionServiceImpl) RevokeOtherSessions(userId string, currentSessionId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	sessionUuid := uuid.FromStringOrNil(currentSessionId)
+	if userUuid.IsNil() || sessionUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	return service.Db.RevokeUserAccessExcept(userUuid, sessionUuid)
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+	"strings"
+)
+
+// personalTokenPrefix marks personal access tokens,
+// so that they can be told apart from session tokens,
+// and easily recognized by secret scanners.
+const personalTokenPrefix = "wpat_"
+
+// generatePersonalToken generates a new personal access token, along with its hash.
+func generatePersonalToken() (token string, tokenHash []byte, err error) {
+	secret, _, err := securetoken.New()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token = personalTokenPrefix + secret
+	return token, securetoken.Hash(token), nil
+}
+
+func isPersonalToken(token string) bool {
+	return strings.HasPrefix(token, personalTokenPrefix)
+}
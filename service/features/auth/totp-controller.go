@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"net/http"
+)
+
+type TotpController struct {
+	Service TotpService
+}
+
+func (controller TotpController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/:userId/totp",
+			Handler: controller.enroll,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/totp",
+			Handler: controller.confirm,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/totp",
+			Handler: controller.disable,
+		},
+	}
+}
+
+func (controller TotpController) enroll(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	enrollment, err := controller.Service.Enroll(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusCreated, context.Logger)
+	} else {
+		api.SendJson(w, enrollment, http.StatusCreated, context.Logger)
+	}
+}
+
+func (controller TotpController) confirm(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, body, bodyErr := api.ParseVariablesAndBody(r, params, &user.IdParams{}, &totpCode{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	recoveryCodes, err := controller.Service.Confirm(args.UserId, body.Code)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, recoveryCodes, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller TotpController) disable(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &totpDisableParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.Disable(args.UserId, args.Code)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
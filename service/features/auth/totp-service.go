@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+	"github.com/simonesestito/wasaphoto/service/utils/totp"
+	"strings"
+)
+
+// TotpService manages the enrollment of the TOTP second factor
+type TotpService interface {
+	Enroll(userId string) (totpEnrollment, error)
+	Confirm(userId string, code string) (totpRecoveryCodes, error)
+	Disable(userId string, code string) error
+}
+
+// SecondFactor is checked by the LoginService
+// after the password of a user has been verified.
+type SecondFactor interface {
+	IsEnabled(userUuid uuid.UUID) (bool, error)
+	Verify(userUuid uuid.UUID, code string) (bool, error)
+}
+
+// totpIssuer is the name authenticator apps show next to the account
+const totpIssuer = "WASAPhoto"
+
+const (
+	recoveryCodesCount = 10
+
+	// recoveryCodeLength is the number of base32 characters of a code (80 bits)
+	recoveryCodeLength = 16
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+type TotpServiceImpl struct {
+	Db      Dao
+	UserDao user.Dao
+	Time    timeprovider.TimeProvider
+}
+
+// Enroll generates a new secret for the user, which stays pending until confirmed.
+// If TOTP is already enabled, it must be disabled first.
+func (service TotpServiceImpl) Enroll(userId string) (totpEnrollment, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return totpEnrollment{}, api.ErrWrongUUID
+	}
+
+	existing, err := service.Db.GetTotp(userUuid)
+	if err != nil {
+		return totpEnrollment{}, err
+	} else if existing != nil && existing.Confirmed > 0 {
+		return totpEnrollment{}, api.ErrAlreadyEnabled
+	}
+
+	dbUser, err := service.UserDao.GetUserById(userUuid)
+	if err != nil {
+		return totpEnrollment{}, err
+	} else if dbUser == nil {
+		return totpEnrollment{}, api.ErrNotFound
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return totpEnrollment{}, err
+	}
+
+	err = service.Db.SetPendingTotp(entityTotp{
+		UserId:       userUuid.Bytes(),
+		Secret:       secret,
+		CreationDate: service.Time.UTCString(),
+	})
+	if err != nil {
+		return totpEnrollment{}, err
+	}
+
+	return totpEnrollment{
+		Secret:          totp.EncodeSecret(secret),
+		ProvisioningUri: totp.ProvisioningUri(totpIssuer, dbUser.Username, secret),
+	}, nil
+}
+
+// Confirm enables the pending TOTP, if the code generated by the app is right.
+// It returns the recovery codes, which are shown only this time.
+func (service TotpServiceImpl) Confirm(userId string, code string) (totpRecoveryCodes, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return totpRecoveryCodes{}, api.ErrWrongUUID
+	}
+
+	pending, err := service.Db.GetTotp(userUuid)
+	if err != nil {
+		return totpRecoveryCodes{}, err
+	} else if pending == nil {
+		return totpRecoveryCodes{}, api.ErrNotFound
+	} else if pending.Confirmed > 0 {
+		return totpRecoveryCodes{}, api.ErrAlreadyEnabled
+	}
+
+	usedStep, ok := totp.Validate(pending.Secret, code, service.Time.Now(), pending.LastUsedStep)
+	if !ok {
+		return totpRecoveryCodes{}, api.ErrWrongOtp
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([][]byte, recoveryCodesCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return totpRecoveryCodes{}, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := service.Db.ConfirmTotp(userUuid, usedStep, hashes); err != nil {
+		return totpRecoveryCodes{}, err
+	}
+
+	return totpRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// Disable removes the TOTP second factor,
+// requiring a valid code or recovery code to do so.
+func (service TotpServiceImpl) Disable(userId string, code string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	enabled, err := service.IsEnabled(userUuid)
+	if err != nil {
+		return err
+	} else if !enabled {
+		return api.ErrNotFound
+	}
+
+	valid, err := service.Verify(userUuid, code)
+	if err != nil {
+		return err
+	} else if !valid {
+		return api.ErrWrongOtp
+	}
+
+	return service.Db.DeleteTotp(userUuid)
+}
+
+func (service TotpServiceImpl) IsEnabled(userUuid uuid.UUID) (bool, error) {
+	found, err := service.Db.GetTotp(userUuid)
+	if err != nil {
+		return false, err
+	}
+
+	return found != nil && found.Confirmed > 0, nil
+}
+
+// Verify checks a code generated by the authenticator app,
+// or a recovery code, which is consumed.
+// They are told apart by their length, ignoring the spaces and the dashes.
+func (service TotpServiceImpl) Verify(userUuid uuid.UUID, code string) (bool, error) {
+	found, err := service.Db.GetTotp(userUuid)
+	if err != nil || found == nil || found.Confirmed == 0 {
+		return false, err
+	}
+
+	if len(totp.NormalizeCode(code)) == totp.Digits {
+		usedStep, ok := totp.Validate(found.Secret, code, service.Time.Now(), found.LastUsedStep)
+		if !ok {
+			return false, nil
+		}
+		return service.Db.UseTotpStep(userUuid, usedStep)
+	}
+
+	return service.Db.UseTotpRecoveryCode(userUuid, hashRecoveryCode(code))
+}
+
+// newRecoveryCode generates a random code, formatted as xxxx-xxxx-xxxx-xxxx
+func newRecoveryCode() (string, error) {
+	randomBytes := make([]byte, recoveryCodeLength*5/8)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	raw := recoveryCodeEncoding.EncodeToString(randomBytes)
+	parts := make([]string, 0, recoveryCodeLength/4)
+	for i := 0; i < len(raw); i += 4 {
+		parts = append(parts, raw[i:i+4])
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// hashRecoveryCode computes the hash to store and to look up a recovery code,
+// ignoring the way the user typed it.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return securetoken.Hash(normalized)
+}
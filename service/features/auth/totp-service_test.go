@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/totp"
+)
+
+// newTestTotpService creates a user with a confirmed TOTP second factor,
+// returning its secret and recovery codes
+func newTestTotpService(t *testing.T) (TotpServiceImpl, *timeprovider.MockTimeProvider, uuid.UUID, []byte, []string) {
+	t.Helper()
+
+	dao := DbDao{Db: databasetest.New(t)}
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	service := TotpServiceImpl{
+		Db:      dao,
+		UserDao: user.DbDao{Db: dao.Db},
+		Time:    clock,
+	}
+
+	userUuid := uuid.Must(uuid.NewV4())
+	newUser := user.ModelUser{Id: userUuid.Bytes(), Name: "John", Username: "john_doe"}
+	if err := dao.InsertUserWithPassword(newUser, "", "john@example.com", clock.UTCString()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Enroll(userUuid.String()); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := dao.GetTotp(userUuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := pending.Secret
+
+	recovery, err := service.Confirm(userUuid.String(), totp.Code(secret, totp.TimeStep(clock.Now())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return service, clock, userUuid, secret, recovery.RecoveryCodes
+}
+
+func TestTotpConfirmRejectsWrongCode(t *testing.T) {
+	service, _, userUuid, _, _ := newTestTotpService(t)
+
+	if _, err := service.Enroll(userUuid.String()); !errors.Is(err, api.ErrAlreadyEnabled) {
+		t.Errorf("expected ErrAlreadyEnabled enrolling again, got %v", err)
+	}
+	if err := service.Disable(userUuid.String(), "000000"); !errors.Is(err, api.ErrWrongOtp) {
+		t.Errorf("expected ErrWrongOtp disabling with a wrong code, got %v", err)
+	}
+}
+
+func TestTotpVerifyRejectsReplay(t *testing.T) {
+	service, clock, userUuid, secret, _ := newTestTotpService(t)
+
+	// The code used to confirm the enrollment can't be used again
+	if valid, err := service.Verify(userUuid, totp.Code(secret, totp.TimeStep(clock.Now()))); err != nil || valid {
+		t.Errorf("expected the confirmation code to be rejected, got %v, %v", valid, err)
+	}
+
+	clock.MockTime = clock.MockTime.Add(totp.Period)
+	code := totp.Code(secret, totp.TimeStep(clock.Now()))
+	if valid, err := service.Verify(userUuid, code); err != nil || !valid {
+		t.Fatalf("expected the next code to be accepted, got %v, %v", valid, err)
+	}
+	if valid, err := service.Verify(userUuid, code); err != nil || valid {
+		t.Errorf("expected a replayed code to be rejected, got %v, %v", valid, err)
+	}
+}
+
+func TestTotpVerifyWindow(t *testing.T) {
+	service, clock, userUuid, secret, _ := newTestTotpService(t)
+	step := totp.TimeStep(clock.Now())
+
+	// A code generated too early is not valid anymore
+	clock.MockTime = clock.MockTime.Add(3 * totp.Period)
+	if valid, err := service.Verify(userUuid, totp.Code(secret, step+1)); err != nil || valid {
+		t.Errorf("expected an expired code to be rejected, got %v, %v", valid, err)
+	}
+
+	// A code of the previous step is still accepted, to tolerate delays
+	if valid, err := service.Verify(userUuid, totp.Code(secret, step+2)); err != nil || !valid {
+		t.Errorf("expected a code of the previous step to be accepted, got %v, %v", valid, err)
+	}
+
+	// Same for a code of the next step, to tolerate clock drift
+	if valid, err := service.Verify(userUuid, totp.Code(secret, step+4)); err != nil || !valid {
+		t.Errorf("expected a code of the next step to be accepted, got %v, %v", valid, err)
+	}
+
+	// Any earlier step has been implicitly used
+	if valid, err := service.Verify(userUuid, totp.Code(secret, step+3)); err != nil || valid {
+		t.Errorf("expected a code older than the last used one to be rejected, got %v, %v", valid, err)
+	}
+}
+
+func TestTotpVerifyFormattedCode(t *testing.T) {
+	service, clock, userUuid, secret, _ := newTestTotpService(t)
+	clock.MockTime = clock.MockTime.Add(totp.Period)
+	code := totp.Code(secret, totp.TimeStep(clock.Now()))
+
+	// Grouped as authenticator apps show it, and pasted with a trailing newline
+	if valid, err := service.Verify(userUuid, code[:3]+" "+code[3:]+"\n"); err != nil || !valid {
+		t.Errorf("expected a formatted code to be accepted, got %v, %v", valid, err)
+	}
+}
+
+func TestTotpVerifyRecoveryCode(t *testing.T) {
+	service, _, userUuid, _, recoveryCodes := newTestTotpService(t)
+
+	// Recovery codes are accepted however they're typed, but only once
+	typed := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", " "))
+	if valid, err := service.Verify(userUuid, typed); err != nil || !valid {
+		t.Fatalf("expected the recovery code to be accepted, got %v, %v", valid, err)
+	}
+	if valid, err := service.Verify(userUuid, recoveryCodes[0]); err != nil || valid {
+		t.Errorf("expected a used recovery code to be rejected, got %v, %v", valid, err)
+	}
+
+	if err := service.Disable(userUuid.String(), recoveryCodes[1]); err != nil {
+		t.Errorf("expected to disable TOTP with a recovery code, got %v", err)
+	}
+	if enabled, err := service.IsEnabled(userUuid); err != nil || enabled {
+		t.Errorf("expected TOTP to be disabled, got %v, %v", enabled, err)
+	}
+}
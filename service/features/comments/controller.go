@@ -18,16 +18,19 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodPost,
 			Path:    "/photos/:photoId/comments/",
 			Handler: controller.commentPhoto,
+			Scope:   route.ScopeCommentsWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodDelete,
 			Path:    "/photos/:photoId/comments/:commentId",
 			Handler: controller.uncommentPhoto,
+			Scope:   route.ScopeCommentsWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodGet,
 			Path:    "/photos/:photoId/comments/",
 			Handler: controller.getPhotoComments,
+			Scope:   route.ScopeRead,
 		},
 	}
 }
@@ -45,6 +48,7 @@ func (controller Controller) commentPhoto(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	createdComment.Author.AddImageHost(r, context.Logger)
 	api.SendJson(w, createdComment, http.StatusCreated, context.Logger)
 }
 
@@ -55,7 +59,7 @@ func (controller Controller) uncommentPhoto(w http.ResponseWriter, _ *http.Reque
 		return
 	}
 
-	err := controller.Service.DeleteCommentOnPhotoIfAuthor(args.CommentId, args.PhotoId, context.UserId)
+	err := controller.Service.DeleteCommentOnPhotoIfAuthor(args.CommentId, args.PhotoId, context.UserId, context.Role.Includes(route.RoleModerator))
 	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
 }
 
@@ -70,6 +74,11 @@ func (controller Controller) getPhotoComments(w http.ResponseWriter, r *http.Req
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		// Add avatar URL prefix
+		for i := range comments {
+			comments[i].Author.AddImageHost(r, context.Logger)
+		}
+
 		api.SendJson(w, api.PageResult[Comment]{
 			NextPageCursor: pageCursor,
 			PageData:       comments,
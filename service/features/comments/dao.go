@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/user"
 )
 
 type Dao interface {
@@ -35,11 +36,13 @@ func (db DbDao) GetCommentByIdAs(commentId uuid.UUID, userId uuid.UUID) (*Entity
 	query := `
 SELECT CommentWithAuthor.*,
        EXISTS(SELECT * FROM Ban WHERE bannedId = CommentWithAuthor.authorId AND bannerId = ?) AS banned,
-       EXISTS(SELECT * FROM Follow WHERE followedId = CommentWithAuthor.authorId AND followerId = ?) AS following
+       EXISTS(SELECT * FROM Follow WHERE followedId = CommentWithAuthor.authorId AND followerId = ?) AS following,
+       EXISTS(SELECT * FROM Follow WHERE followerId = CommentWithAuthor.authorId AND followedId = ?) AS followsYou
 FROM CommentWithAuthor
-WHERE CommentWithAuthor.id = ?`
+WHERE CommentWithAuthor.id = ?
+  AND (CommentWithAuthor.authorId = ? OR ` + user.ActiveCondition("CommentWithAuthor.authorId") + `)`
 
-	err := db.Db.QueryStructRow(entity, query, userId.Bytes(), userId.Bytes(), commentId.Bytes())
+	err := db.Db.QueryStructRow(entity, query, userId.Bytes(), userId.Bytes(), userId.Bytes(), commentId.Bytes(), userId.Bytes())
 
 	// Fix shadowed properties
 	entity.ModelUserWithCustom.ModelUser.Id = entity.entityComment.AuthorId
@@ -79,13 +82,18 @@ func (db DbDao) GetCommentsAfter(photoUuid uuid.UUID, userUuid uuid.UUID, afterC
 	query := `
 		SELECT CommentWithAuthor.*,
 			   EXISTS(SELECT * FROM Ban WHERE bannedId = CommentWithAuthor.authorId AND bannerId = ?) AS banned,
-			   EXISTS(SELECT * FROM Follow WHERE followedId = CommentWithAuthor.authorId AND followerId = ?) AS following
+			   EXISTS(SELECT * FROM Follow WHERE followedId = CommentWithAuthor.authorId AND followerId = ?) AS following,
+			   EXISTS(SELECT * FROM Follow WHERE followerId = CommentWithAuthor.authorId AND followedId = ?) AS followsYou
 		FROM CommentWithAuthor
 		WHERE CommentWithAuthor.photoId = ?
 		 	  -- Cursor pagination
 			  AND (publishDate, id) < (?, ?)
 			  -- Hide comments from users who banned me
 			  AND NOT EXISTS(SELECT * FROM Ban WHERE bannedId = ? AND bannerId = CommentWithAuthor.authorId)
+			  -- Hide comments from users I muted
+			  AND NOT EXISTS(SELECT * FROM Mute WHERE muterId = ? AND mutedId = CommentWithAuthor.authorId)
+			  -- Hide comments from deactivated users
+			  AND ` + user.ActiveCondition("CommentWithAuthor.authorId") + `
 		ORDER BY publishDate DESC, id DESC
 		LIMIT ?`
 
@@ -94,10 +102,12 @@ func (db DbDao) GetCommentsAfter(photoUuid uuid.UUID, userUuid uuid.UUID, afterC
 		query,
 		userUuid.Bytes(),
 		userUuid.Bytes(),
+		userUuid.Bytes(),
 		photoUuid.Bytes(),
 		beforeDate,
 		afterComment.Bytes(),
 		userUuid.Bytes(),
+		userUuid.Bytes(),
 		database.MaxPageItems,
 	)
 
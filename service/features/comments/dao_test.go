@@ -0,0 +1,52 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+)
+
+func TestGetCommentWithAuthor(t *testing.T) {
+	dao := DbDao{Db: databasetest.New(t)}
+	authorId, photoId, commentId := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+
+	err := dao.Db.Exec("INSERT INTO User (id, name, surname, username) VALUES (?, 'John', 'Doe', 'john_doe')", authorId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dao.Db.Exec("INSERT INTO Photo (id, imageUrl, authorId, publishDate) VALUES (?, '/photo.webp', ?, '2023-01-15 12:00:00')", photoId.Bytes(), authorId.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dao.CreateComment(entityComment{
+		Id:          commentId.Bytes(),
+		Text:        "Nice photo",
+		PublishDate: "2023-01-15 12:30:00",
+		AuthorId:    authorId.Bytes(),
+		PhotoId:     photoId.Bytes(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entity, err := dao.GetCommentByIdAs(commentId, authorId)
+	if err != nil {
+		t.Fatal(err)
+	} else if entity == nil {
+		t.Fatal("comment not found")
+	}
+
+	comment := entity.toDto()
+	if comment.Id != commentId.String() || comment.Text != "Nice photo" {
+		t.Errorf("unexpected comment %+v", comment)
+	}
+	if comment.Author.Id != authorId.String() || comment.Author.Username != "john_doe" || comment.Author.Name != "John" {
+		t.Errorf("unexpected author %+v", comment.Author)
+	}
+
+	page, _ := dbCommentsListToPage([]EntityCommentWithCustom{*entity})
+	if len(page) != 1 || page[0].Id != commentId.String() {
+		t.Errorf("unexpected page %+v", page)
+	}
+}
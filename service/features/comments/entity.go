@@ -16,11 +16,6 @@ type entityComment struct {
 	PhotoId     []byte `json:"photoId"`
 }
 
-type entityCommentWithAuthor struct {
-	user.ModelUserInfo
-	entityComment
-}
-
 // CommentIdWithAuthorAndPhoto is a simple view with just the IDs
 type CommentIdWithAuthorAndPhoto struct {
 	CommentId       []byte `json:"commentId"`
@@ -29,9 +24,11 @@ type CommentIdWithAuthorAndPhoto struct {
 	PhotoAuthorId   []byte `json:"photoAuthorId"`
 }
 
+// EntityCommentWithCustom is a comment along with its author.
+// The author fields are decoded just once, in ModelUserWithCustom.
 type EntityCommentWithCustom struct {
 	user.ModelUserWithCustom
-	entityCommentWithAuthor
+	entityComment
 }
 
 func (entity EntityCommentWithCustom) toDto() Comment {
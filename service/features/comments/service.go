@@ -7,20 +7,18 @@ import (
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/photo"
-	"github.com/simonesestito/wasaphoto/service/features/user"
 	"github.com/simonesestito/wasaphoto/service/timeprovider"
 	"github.com/simonesestito/wasaphoto/service/utils/cursor"
 )
 
 type Service interface {
 	CommentPhoto(photoId string, userId string, comment newComment) (Comment, error)
-	DeleteCommentOnPhotoIfAuthor(commentId string, photoId string, userId string) error
+	DeleteCommentOnPhotoIfAuthor(commentId string, photoId string, userId string, asModerator bool) error
 	GetCommentsPageAs(photoId string, userId string, pageCursor string) ([]Comment, *string, error)
 }
 
 type ServiceImpl struct {
 	Db           Dao
-	BanService   user.BanService
 	PhotoService photo.Service
 	TimeProvider timeprovider.TimeProvider
 }
@@ -32,24 +30,15 @@ func (service ServiceImpl) CommentPhoto(photoId string, userId string, comment n
 		return Comment{}, api.ErrWrongUUID
 	}
 
-	// Get info about the photo to like
-	photoAuthorId, err := service.PhotoService.GetPostAuthorById(photoId)
+	// Check if I can see the photo to comment:
+	// the author must not have banned me, and I must be in its audience
+	photoToComment, err := service.PhotoService.GetPhotoByIdAs(photoId, userId)
 	if err != nil {
 		return Comment{}, err
-	}
-	if photoAuthorId == "" {
+	} else if photoToComment == nil {
 		return Comment{}, api.ErrNotFound
 	}
 
-	// Check if photo author banned me
-	iamBanned, err := service.BanService.IsUserBanned(userId, photoAuthorId)
-	if err != nil {
-		return Comment{}, err
-	}
-	if iamBanned {
-		return Comment{}, api.ErrUserBanned
-	}
-
 	newCommentUuid, err := uuid.NewV4()
 	if err != nil {
 		return Comment{}, err
@@ -77,7 +66,9 @@ func (service ServiceImpl) CommentPhoto(photoId string, userId string, comment n
 	return newComment.toDto(), nil
 }
 
-func (service ServiceImpl) DeleteCommentOnPhotoIfAuthor(commentId string, photoId string, userId string) error {
+// DeleteCommentOnPhotoIfAuthor deletes the comment, if written by the given user.
+// Moderators can delete any comment.
+func (service ServiceImpl) DeleteCommentOnPhotoIfAuthor(commentId string, photoId string, userId string, asModerator bool) error {
 	photoUuid := uuid.FromStringOrNil(photoId)
 	commentUuid := uuid.FromStringOrNil(commentId)
 	userUuid := uuid.FromStringOrNil(userId)
@@ -97,13 +88,14 @@ func (service ServiceImpl) DeleteCommentOnPhotoIfAuthor(commentId string, photoI
 	if !bytes.Equal(photoUuid.Bytes(), commentInfoIds.PhotoId) {
 		// Wrong Photo ID provided
 		return api.ErrNotFound
-	} else if !bytes.Equal(userUuid.Bytes(), commentInfoIds.CommentAuthorId) {
+	} else if !asModerator && !bytes.Equal(userUuid.Bytes(), commentInfoIds.CommentAuthorId) {
 		// Wrong user is trying to delete this comment
 		return api.ErrOthersData
 	}
 
 	// Delete comment
-	_, err = service.Db.DeleteByIdPhotoAndAuthor(commentUuid, photoUuid, userUuid)
+	authorUuid := uuid.FromBytesOrNil(commentInfoIds.CommentAuthorId)
+	_, err = service.Db.DeleteByIdPhotoAndAuthor(commentUuid, photoUuid, authorUuid)
 	return err
 }
 
@@ -119,7 +111,7 @@ func (service ServiceImpl) GetCommentsPageAs(photoId string, userId string, page
 		return nil, nil, api.ErrWrongCursor
 	}
 
-	// Check if photo exists and if the author banned me
+	// Check if photo exists, if the author banned me and if I'm in its audience
 	foundPhoto, err := service.PhotoService.GetPhotoByIdAs(photoId, userId)
 	if errors.Is(err, api.ErrUserBanned) {
 		return nil, nil, api.ErrUserBanned
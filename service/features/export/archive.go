@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// buildArchive writes the zip archive with all the data of the user.
+//
+// It's written to a temporary file first,
+// so that a partial archive can never be downloaded.
+func (service ServiceImpl) buildArchive(exportUuid uuid.UUID, userUuid uuid.UUID) error {
+	content, err := service.collectManifest(exportUuid, userUuid)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(service.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	archivePath := service.pathForArchive(exportUuid)
+	tempPath := archivePath + ".tmp"
+	if err := service.writeArchive(tempPath, content); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	return os.Rename(tempPath, archivePath)
+}
+
+func (service ServiceImpl) writeArchive(path string, content manifest) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	archive := zip.NewWriter(file)
+	exportDate, _ := timeprovider.UTCStringToDate(content.ExportDate)
+
+	// Photos first, so that the manifest can tell the missing ones
+	for i, photo := range content.Photos {
+		photoData, err := service.PhotoService.ReadPhotoFile(photo.Id)
+		if errors.Is(err, fs.ErrNotExist) {
+			service.Logger.WithField("photoId", photo.Id).Warn("photo file missing from data export")
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		fileName := "photos/" + photo.Id + ".webp"
+		writer, err := createArchiveFile(archive, fileName, exportDate)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(photoData); err != nil {
+			return err
+		}
+		content.Photos[i].File = fileName
+	}
+
+	writer, err := createArchiveFile(archive, "manifest.json", exportDate)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(content); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// collectManifest reads all the data of the user from the database
+func (service ServiceImpl) collectManifest(exportUuid uuid.UUID, userUuid uuid.UUID) (manifest, error) {
+	profile, err := service.Db.GetProfile(userUuid)
+	if err != nil {
+		return manifest{}, err
+	} else if profile == nil {
+		return manifest{}, errors.New("user of the export not found")
+	}
+
+	photos, err := service.Db.GetPhotos(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	comments, err := service.Db.GetComments(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	likes, err := service.Db.GetLikes(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	followers, err := service.Db.GetFollowers(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	followings, err := service.Db.GetFollowings(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	bans, err := service.Db.GetBans(userUuid)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	content := manifest{
+		ExportId:   exportUuid.String(),
+		ExportDate: service.Time.UTCString(),
+		Profile: manifestProfile{
+			Id:        uuid.FromBytesOrNil(profile.Id).String(),
+			Name:      profile.Name,
+			Surname:   profile.Surname,
+			Username:  profile.Username,
+			Bio:       profile.Bio,
+			Website:   profile.Website,
+			Pronouns:  profile.Pronouns,
+			Email:     profile.Email,
+			Private:   profile.Private > 0,
+			AvatarUrl: profile.AvatarUrl,
+		},
+		Photos:     make([]manifestPhoto, len(photos)),
+		Comments:   make([]manifestComment, len(comments)),
+		Likes:      make([]manifestLike, len(likes)),
+		Followers:  relatedUsersToManifest(followers),
+		Followings: relatedUsersToManifest(followings),
+		Bans:       make([]manifestBan, len(bans)),
+	}
+
+	for i, photo := range photos {
+		content.Photos[i] = manifestPhoto{
+			Id:          uuid.FromBytesOrNil(photo.Id).String(),
+			PublishDate: photo.PublishDate,
+			Audience:    photo.Visibility,
+		}
+	}
+
+	for i, comment := range comments {
+		content.Comments[i] = manifestComment{
+			Id:          uuid.FromBytesOrNil(comment.Id).String(),
+			PhotoId:     uuid.FromBytesOrNil(comment.PhotoId).String(),
+			Text:        comment.Text,
+			PublishDate: comment.PublishDate,
+		}
+	}
+
+	for i, like := range likes {
+		content.Likes[i] = manifestLike{PhotoId: uuid.FromBytesOrNil(like.PhotoId).String()}
+	}
+
+	for i, ban := range bans {
+		content.Bans[i] = manifestBan{
+			Id:       uuid.FromBytesOrNil(ban.Id).String(),
+			Username: ban.Username,
+			BanDate:  ban.BanDate,
+		}
+	}
+
+	return content, nil
+}
+
+// createArchiveFile adds a compressed file to the archive,
+// dated as the export itself
+func createArchiveFile(archive *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	return archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+}
+
+func relatedUsersToManifest(users []entityRelatedUser) []manifestUser {
+	result := make([]manifestUser, len(users))
+	for i, user := range users {
+		result[i] = manifestUser{
+			Id:       uuid.FromBytesOrNil(user.Id).String(),
+			Username: user.Username,
+		}
+	}
+	return result
+}
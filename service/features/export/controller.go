@@ -0,0 +1,137 @@
+package export
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/utils"
+	"net/http"
+	"time"
+)
+
+// archiveFileName is the name the archive is downloaded with
+const archiveFileName = "wasaphoto-data.zip"
+
+// archiveWriteTimeout replaces the WriteTimeout of the server while the archive is downloaded,
+// since it's much bigger than the other responses
+const archiveWriteTimeout = time.Hour
+
+type Controller struct {
+	Service Service
+}
+
+func (controller Controller) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/:userId/export",
+			Handler: controller.requestExport,
+		},
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/export",
+			Handler: controller.getExport,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPost,
+			Path:    "/users/:userId/export/links",
+			Handler: controller.createDownloadLink,
+		},
+		route.AnonymousRoute{
+			Method:  http.MethodGet,
+			Path:    "/data-exports/:token",
+			Handler: controller.downloadArchive,
+		},
+	}
+}
+
+func (controller Controller) requestExport(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	export, err := controller.Service.RequestExport(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusAccepted, context.Logger)
+	} else {
+		api.SendJson(w, export, http.StatusAccepted, context.Logger)
+	}
+}
+
+func (controller Controller) getExport(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	export, err := controller.Service.GetLatestExport(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, export, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller Controller) createDownloadLink(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &user.IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	link, err := controller.Service.CreateDownloadLink(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusCreated, context.Logger)
+	} else {
+		link.Url = utils.GetUrlPrefix(r, context.Logger) + link.Url
+		api.SendJson(w, link, http.StatusCreated, context.Logger)
+	}
+}
+
+func (controller Controller) downloadArchive(w http.ResponseWriter, r *http.Request, params httprouter.Params, ctx route.RequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &tokenParams{}, ctx.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	archive, err := controller.Service.OpenArchive(args.Token)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, ctx.Logger)
+		return
+	}
+	defer func() { _ = archive.Close() }()
+
+	info, err := archive.Stat()
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, ctx.Logger)
+		return
+	}
+
+	if err := api.ExtendWriteDeadline(r, archiveWriteTimeout); err != nil {
+		ctx.Logger.WithError(err).Warning("can't extend the write deadline of the archive download")
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+archiveFileName+"\"")
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, archiveFileName, info.ModTime(), archive)
+}
@@ -0,0 +1,207 @@
+package export
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+type Dao interface {
+	InsertExport(export entityDataExport) error
+	GetLatestExport(userUuid uuid.UUID) (*entityDataExport, error)
+	GetPendingExports() ([]entityDataExport, error)
+	SetExportCompleted(exportUuid uuid.UUID, status string, completionDate string, expirationDate string) error
+	SetExportLink(exportUuid uuid.UUID, tokenHash []byte, expirationDate string) error
+	GetExportByLinkTokenHash(tokenHash []byte, now string) (*entityDataExport, error)
+	GetExpiredExports(now string) ([]entityDataExport, error)
+	GetExportsOf(userUuid uuid.UUID) ([]entityDataExport, error)
+	DeleteExport(exportUuid uuid.UUID) error
+
+	GetProfile(userUuid uuid.UUID) (*entityProfile, error)
+	GetPhotos(userUuid uuid.UUID) ([]entityPhoto, error)
+	GetComments(userUuid uuid.UUID) ([]entityComment, error)
+	GetLikes(userUuid uuid.UUID) ([]entityLike, error)
+	GetFollowers(userUuid uuid.UUID) ([]entityRelatedUser, error)
+	GetFollowings(userUuid uuid.UUID) ([]entityRelatedUser, error)
+	GetBans(userUuid uuid.UUID) ([]entityBan, error)
+
+	UpdateProfile(userUuid uuid.UUID, profile importedProfile) error
+	FindUserByUsername(username string) (uuid.UUID, error)
+	GetCommentAuthor(commentUuid uuid.UUID) (uuid.UUID, error)
+	RestoreComment(commentUuid uuid.UUID, photoUuid uuid.UUID, authorUuid uuid.UUID, text string, publishDate string) error
+	HasLiked(userUuid uuid.UUID, photoUuid uuid.UUID) (bool, error)
+	RestoreLike(userUuid uuid.UUID, photoUuid uuid.UUID) error
+	RestoreFollow(followerUuid uuid.UUID, followedUuid uuid.UUID) error
+	GetImportedId(userUuid uuid.UUID, originalUuid uuid.UUID) (uuid.UUID, error)
+	InsertImportedId(userUuid uuid.UUID, originalUuid uuid.UUID, newUuid uuid.UUID) error
+}
+
+type DbDao struct {
+	Db database.AppDatabase
+}
+
+// exportColumns selects a DataExport row as an entityDataExport
+const exportColumns = `
+	id, userId, status, requestDate,
+	COALESCE(completionDate, '') AS completionDate,
+	COALESCE(expirationDate, '') AS expirationDate`
+
+func (db DbDao) InsertExport(export entityDataExport) error {
+	return db.Db.Exec("INSERT INTO DataExport (id, userId, status, requestDate) VALUES (?, ?, ?, ?)",
+		export.Id,
+		export.UserId,
+		export.Status,
+		export.RequestDate,
+	)
+}
+
+func (db DbDao) GetLatestExport(userUuid uuid.UUID) (*entityDataExport, error) {
+	export := &entityDataExport{}
+	err := db.Db.QueryStructRow(export, "SELECT "+exportColumns+" FROM DataExport WHERE userId = ? ORDER BY requestDate DESC LIMIT 1", userUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return export, nil
+	}
+}
+
+func (db DbDao) GetPendingExports() ([]entityDataExport, error) {
+	return queryAll[entityDataExport](db.Db, "SELECT "+exportColumns+" FROM DataExport WHERE status = ? ORDER BY requestDate", statusPending)
+}
+
+func (db DbDao) SetExportCompleted(exportUuid uuid.UUID, status string, completionDate string, expirationDate string) error {
+	return db.Db.Exec("UPDATE DataExport SET status = ?, completionDate = ?, expirationDate = ? WHERE id = ?",
+		status,
+		completionDate,
+		expirationDate,
+		exportUuid.Bytes(),
+	)
+}
+
+// SetExportLink replaces the download link of the export,
+// so that only the last one created is valid.
+func (db DbDao) SetExportLink(exportUuid uuid.UUID, tokenHash []byte, expirationDate string) error {
+	return db.Db.Exec("UPDATE DataExport SET linkTokenHash = ?, linkExpirationDate = ? WHERE id = ?",
+		tokenHash,
+		expirationDate,
+		exportUuid.Bytes(),
+	)
+}
+
+func (db DbDao) GetExportByLinkTokenHash(tokenHash []byte, now string) (*entityDataExport, error) {
+	export := &entityDataExport{}
+	query := "SELECT " + exportColumns + " FROM DataExport WHERE linkTokenHash = ? AND linkExpirationDate > ? AND status = ?"
+	err := db.Db.QueryStructRow(export, query, tokenHash, now, statusReady)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return export, nil
+	}
+}
+
+func (db DbDao) GetExpiredExports(now string) ([]entityDataExport, error) {
+	return queryAll[entityDataExport](db.Db, "SELECT "+exportColumns+" FROM DataExport WHERE expirationDate <= ?", now)
+}
+
+func (db DbDao) GetExportsOf(userUuid uuid.UUID) ([]entityDataExport, error) {
+	return queryAll[entityDataExport](db.Db, "SELECT "+exportColumns+" FROM DataExport WHERE userId = ?", userUuid.Bytes())
+}
+
+func (db DbDao) DeleteExport(exportUuid uuid.UUID) error {
+	return db.Db.Exec("DELETE FROM DataExport WHERE id = ?", exportUuid.Bytes())
+}
+
+func (db DbDao) GetProfile(userUuid uuid.UUID) (*entityProfile, error) {
+	query := `
+		SELECT id, name, surname, username, bio, website, pronouns,
+		       COALESCE(email, '') AS email,
+		       private,
+		       COALESCE(avatarUrl, '') AS avatarUrl
+		FROM User
+		WHERE id = ?`
+
+	profile := &entityProfile{}
+	err := db.Db.QueryStructRow(profile, query, userUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return profile, nil
+	}
+}
+
+func (db DbDao) GetPhotos(userUuid uuid.UUID) ([]entityPhoto, error) {
+	return queryAll[entityPhoto](db.Db, "SELECT id, publishDate, visibility FROM Photo WHERE authorId = ? ORDER BY publishDate", userUuid.Bytes())
+}
+
+func (db DbDao) GetComments(userUuid uuid.UUID) ([]entityComment, error) {
+	return queryAll[entityComment](db.Db, "SELECT id, photoId, `text`, publishDate FROM Comment WHERE authorId = ? ORDER BY publishDate", userUuid.Bytes())
+}
+
+func (db DbDao) GetLikes(userUuid uuid.UUID) ([]entityLike, error) {
+	return queryAll[entityLike](db.Db, "SELECT photoId FROM Likes WHERE userId = ?", userUuid.Bytes())
+}
+
+func (db DbDao) GetFollowers(userUuid uuid.UUID) ([]entityRelatedUser, error) {
+	query := `
+		SELECT User.id, User.username
+		FROM Follow
+		JOIN User ON User.id = Follow.followerId
+		WHERE Follow.followedId = ?
+		ORDER BY User.username`
+	return queryAll[entityRelatedUser](db.Db, query, userUuid.Bytes())
+}
+
+func (db DbDao) GetFollowings(userUuid uuid.UUID) ([]entityRelatedUser, error) {
+	query := `
+		SELECT User.id, User.username
+		FROM Follow
+		JOIN User ON User.id = Follow.followedId
+		WHERE Follow.followerId = ?
+		ORDER BY User.username`
+	return queryAll[entityRelatedUser](db.Db, query, userUuid.Bytes())
+}
+
+func (db DbDao) GetBans(userUuid uuid.UUID) ([]entityBan, error) {
+	query := `
+		SELECT User.id, User.username, COALESCE(Ban.banDate, '') AS banDate
+		FROM Ban
+		JOIN User ON User.id = Ban.bannedId
+		WHERE Ban.bannerId = ?
+		ORDER BY User.username`
+	return queryAll[entityBan](db.Db, query, userUuid.Bytes())
+}
+
+// queryAll collects all the rows returned by the query, without pagination
+func queryAll[T any](db database.AppDatabase, query string, args ...any) ([]T, error) {
+	var zero T
+	rows, err := db.QueryStructRows(zero, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results []T
+		entity  any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		result, ok := entity.(T)
+		if !ok {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+		results = append(results, result)
+	}
+	if !errors.Is(err, database.ErrNoResult) {
+		return nil, err
+	}
+
+	return results, nil
+}
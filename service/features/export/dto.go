@@ -0,0 +1,151 @@
+package export
+
+import "github.com/simonesestito/wasaphoto/service/features/user"
+
+// Statuses of an export, as stored in the DataExport table
+const (
+	statusPending = "pending"
+	statusReady   = "ready"
+	statusFailed  = "failed"
+)
+
+type dataExport struct {
+	Id             string `json:"id"`
+	Status         string `json:"status"`
+	RequestDate    string `json:"requestDate"`
+	CompletionDate string `json:"completionDate,omitempty"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
+}
+
+type downloadLink struct {
+	Url            string `json:"url"`
+	ExpirationDate string `json:"expirationDate"`
+}
+
+type tokenParams struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// manifest is the manifest.json file at the root of the archive
+type manifest struct {
+	ExportId   string            `json:"exportId"`
+	ExportDate string            `json:"exportDate"`
+	Profile    manifestProfile   `json:"profile"`
+	Photos     []manifestPhoto   `json:"photos"`
+	Comments   []manifestComment `json:"comments"`
+	Likes      []manifestLike    `json:"likes"`
+	Followers  []manifestUser    `json:"followers"`
+	Followings []manifestUser    `json:"followings"`
+	Bans       []manifestBan     `json:"bans"`
+}
+
+type manifestProfile struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Surname   string `json:"surname"`
+	Username  string `json:"username"`
+	Bio       string `json:"bio"`
+	Website   string `json:"website"`
+	Pronouns  string `json:"pronouns"`
+	Email     string `json:"email,omitempty"`
+	Private   bool   `json:"private"`
+	AvatarUrl string `json:"avatarUrl,omitempty"`
+}
+
+type manifestPhoto struct {
+	Id          string `json:"id"`
+	PublishDate string `json:"publishDate"`
+	Audience    string `json:"audience"`
+
+	// File is the path of the photo inside the archive,
+	// empty if it was missing from the storage.
+	File string `json:"file,omitempty"`
+}
+
+type manifestComment struct {
+	Id          string `json:"id"`
+	PhotoId     string `json:"photoId"`
+	Text        string `json:"text"`
+	PublishDate string `json:"publishDate"`
+}
+
+type manifestLike struct {
+	PhotoId string `json:"photoId"`
+}
+
+type manifestUser struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type manifestBan struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	BanDate  string `json:"banDate,omitempty"`
+}
+
+type importParams struct {
+	user.IdParams
+	DryRun bool `json:"dryRun"`
+}
+
+// importReport tells what an import did, or would do in a dry run
+type importReport struct {
+	DryRun bool `json:"dryRun"`
+
+	// Warnings about the profile, which is imported as a whole
+	Warnings []string `json:"warnings"`
+
+	Photos     importSection `json:"photos"`
+	Comments   importSection `json:"comments"`
+	Likes      importSection `json:"likes"`
+	Followings importSection `json:"followings"`
+	Followers  importSection `json:"followers"`
+	Bans       importSection `json:"bans"`
+}
+
+type importSection struct {
+	Imported int `json:"imported"`
+
+	// Remapped lists the items imported with a new ID,
+	// since the original one was already taken
+	Remapped []remappedId `json:"remapped"`
+
+	Skipped []skippedItem `json:"skipped"`
+}
+
+type remappedId struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type skippedItem struct {
+	Id     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// Reasons why an item of the archive is not imported
+const (
+	skipAlreadyPresent = "alreadyPresent"
+	skipInvalid        = "invalid"
+	skipMissingFile    = "missingFile"
+	skipPhotoNotFound  = "photoNotFound"
+	skipUserNotFound   = "userNotFound"
+	skipBanned         = "banned"
+	skipOperatorOnly   = "operatorOnly"
+)
+
+// importedProfile validates the profile in the archive,
+// with the same rules of a profile update
+type importedProfile struct {
+	Name     string `json:"name" validate:"required,min=2,max=256,singleline"`
+	Surname  string `json:"surname" validate:"max=256,singleline"`
+	Username string `json:"username" validate:"required,username"`
+	Bio      string `json:"bio" validate:"max=500"`
+	Website  string `json:"website" validate:"omitempty,max=256,weburl"`
+	Pronouns string `json:"pronouns" validate:"max=32,singleline"`
+}
+
+type importedComment struct {
+	Text string `json:"text" validate:"required,min=1,max=256"`
+}
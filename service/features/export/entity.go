@@ -0,0 +1,67 @@
+package export
+
+import "github.com/gofrs/uuid"
+
+// entityDataExport is the entity for the DataExport database table,
+// without the download link columns, which are never read back.
+type entityDataExport struct {
+	Id             []byte `json:"id"`
+	UserId         []byte `json:"userId"`
+	Status         string `json:"status"`
+	RequestDate    string `json:"requestDate"`
+	CompletionDate string `json:"completionDate"`
+	ExpirationDate string `json:"expirationDate"`
+}
+
+func (entity entityDataExport) toDto() dataExport {
+	return dataExport{
+		Id:             uuid.FromBytesOrNil(entity.Id).String(),
+		Status:         entity.Status,
+		RequestDate:    entity.RequestDate,
+		CompletionDate: entity.CompletionDate,
+		ExpirationDate: entity.ExpirationDate,
+	}
+}
+
+// Entities below are the data of the user which end up in the archive
+
+type entityProfile struct {
+	Id        []byte `json:"id"`
+	Name      string `json:"name"`
+	Surname   string `json:"surname"`
+	Username  string `json:"username"`
+	Bio       string `json:"bio"`
+	Website   string `json:"website"`
+	Pronouns  string `json:"pronouns"`
+	Email     string `json:"email"`
+	Private   int64  `json:"private"`
+	AvatarUrl string `json:"avatarUrl"`
+}
+
+type entityPhoto struct {
+	Id          []byte `json:"id"`
+	PublishDate string `json:"publishDate"`
+	Visibility  string `json:"visibility"`
+}
+
+type entityComment struct {
+	Id          []byte `json:"id"`
+	PhotoId     []byte `json:"photoId"`
+	Text        string `json:"text"`
+	PublishDate string `json:"publishDate"`
+}
+
+type entityLike struct {
+	PhotoId []byte `json:"photoId"`
+}
+
+type entityRelatedUser struct {
+	Id       []byte `json:"id"`
+	Username string `json:"username"`
+}
+
+type entityBan struct {
+	Id       []byte `json:"id"`
+	Username string `json:"username"`
+	BanDate  string `json:"banDate"`
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// maxArchiveSize is the maximum size of an uploaded archive,
+// much larger than the default limit, since it contains every photo
+const maxArchiveSize = 1024 * 1024 * 1024
+
+// archiveReadTimeout replaces the ReadTimeout of the server while the archive is uploaded
+const archiveReadTimeout = time.Hour
+
+type ImportController struct {
+	Service ImportService
+}
+
+func (controller ImportController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:      http.MethodPost,
+			Path:        "/users/:userId/import",
+			Handler:     controller.importArchive,
+			MaxBodySize: maxArchiveSize,
+		},
+	}
+}
+
+func (controller ImportController) importArchive(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &importParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	// Admins act as operators, moving accounts between instances
+	asOperator := context.Role.Includes(route.RoleAdmin)
+	if args.UserId != context.UserId && !asOperator {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := api.ExtendReadDeadline(r, archiveReadTimeout); err != nil {
+		context.Logger.WithError(err).Warning("can't extend the read deadline of the archive upload")
+	}
+
+	// Save archive from body to a temporary file, instead of keeping it in memory
+	archiveFile, err := os.CreateTemp("", "wasaphoto-import-*.zip")
+	if err != nil {
+		context.Logger.WithError(err).Errorln("error creating temporary archive file")
+		http.Error(w, "unexpected error receiving archive", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = archiveFile.Close()
+		_ = os.Remove(archiveFile.Name())
+	}()
+
+	archiveSize, err := io.Copy(archiveFile, r.Body)
+	_ = r.Body.Close()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "archive too large", http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		context.Logger.WithError(err).Errorln("error receiving archive")
+		http.Error(w, "unexpected error receiving archive", http.StatusInternalServerError)
+		return
+	}
+
+	if archiveSize == 0 {
+		http.Error(w, "missing archive body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := controller.Service.ImportArchive(args.UserId, archiveFile, archiveSize, args.DryRun, asOperator)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, report, http.StatusOK, context.Logger)
+	}
+}
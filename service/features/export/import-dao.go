@@ -0,0 +1,110 @@
+package export
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+func (db DbDao) UpdateProfile(userUuid uuid.UUID, profile importedProfile) error {
+	return db.Db.Exec("UPDATE User SET name = ?, surname = ?, bio = ?, website = ?, pronouns = ? WHERE id = ?",
+		profile.Name,
+		profile.Surname,
+		profile.Bio,
+		profile.Website,
+		profile.Pronouns,
+		userUuid.Bytes(),
+	)
+}
+
+// FindUserByUsername returns the ID of the user, or uuid.Nil if there's none.
+// Users are matched by username, since the same ID may belong
+// to someone else on another instance.
+func (db DbDao) FindUserByUsername(username string) (uuid.UUID, error) {
+	result := struct {
+		Id []byte `json:"id"`
+	}{}
+
+	err := db.Db.QueryStructRow(&result, "SELECT id FROM User WHERE username = ?", username)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.Id), nil
+	}
+}
+
+// GetCommentAuthor returns the author of the comment, or uuid.Nil if it doesn't exist
+func (db DbDao) GetCommentAuthor(commentUuid uuid.UUID) (uuid.UUID, error) {
+	result := struct {
+		AuthorId []byte `json:"authorId"`
+	}{}
+
+	err := db.Db.QueryStructRow(&result, "SELECT authorId FROM Comment WHERE id = ?", commentUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.AuthorId), nil
+	}
+}
+
+// RestoreComment inserts a comment keeping its original publish date
+func (db DbDao) RestoreComment(commentUuid uuid.UUID, photoUuid uuid.UUID, authorUuid uuid.UUID, text string, publishDate string) error {
+	return db.Db.Exec("INSERT INTO Comment (id, `text`, publishDate, authorId, photoId) VALUES (?, ?, ?, ?, ?)",
+		commentUuid.Bytes(),
+		text,
+		publishDate,
+		authorUuid.Bytes(),
+		photoUuid.Bytes(),
+	)
+}
+
+func (db DbDao) HasLiked(userUuid uuid.UUID, photoUuid uuid.UUID) (bool, error) {
+	result := struct {
+		Liked int64 `json:"liked"`
+	}{}
+
+	err := db.Db.QueryStructRow(&result, "SELECT EXISTS(SELECT * FROM Likes WHERE userId = ? AND photoId = ?) AS liked", userUuid.Bytes(), photoUuid.Bytes())
+	return result.Liked > 0, err
+}
+
+func (db DbDao) RestoreLike(userUuid uuid.UUID, photoUuid uuid.UUID) error {
+	return db.Db.Exec("INSERT INTO Likes (userId, photoId) VALUES (?, ?)", userUuid.Bytes(), photoUuid.Bytes())
+}
+
+// RestoreFollow inserts a follow directly,
+// without asking the followed user to approve it
+func (db DbDao) RestoreFollow(followerUuid uuid.UUID, followedUuid uuid.UUID) error {
+	return db.Db.Exec("INSERT INTO Follow (followerId, followedId) VALUES (?, ?)", followerUuid.Bytes(), followedUuid.Bytes())
+}
+
+// GetImportedId returns the ID given to an item imported with a new ID,
+// or uuid.Nil if it has not been remapped
+func (db DbDao) GetImportedId(userUuid uuid.UUID, originalUuid uuid.UUID) (uuid.UUID, error) {
+	result := struct {
+		NewId []byte `json:"newId"`
+	}{}
+
+	err := db.Db.QueryStructRow(&result, "SELECT newId FROM ImportedId WHERE userId = ? AND originalId = ?", userUuid.Bytes(), originalUuid.Bytes())
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.NewId), nil
+	}
+}
+
+func (db DbDao) InsertImportedId(userUuid uuid.UUID, originalUuid uuid.UUID, newUuid uuid.UUID) error {
+	return db.Db.Exec("INSERT OR REPLACE INTO ImportedId (userId, originalId, newId) VALUES (?, ?, ?)",
+		userUuid.Bytes(),
+		originalUuid.Bytes(),
+		newUuid.Bytes(),
+	)
+}
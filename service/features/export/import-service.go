@@ -0,0 +1,571 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/features/follow"
+	"github.com/simonesestito/wasaphoto/service/features/photo"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/imaging"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/fs"
+)
+
+// ImportService restores an account from an archive created by Service,
+// even if it comes from another instance.
+type ImportService interface {
+	ImportArchive(userId string, archive io.ReaderAt, archiveSize int64, dryRun bool, asOperator bool) (importReport, error)
+}
+
+// maxArchiveFileSize is the maximum size of a single file in the archive,
+// once decompressed, so that a crafted archive cannot exhaust the memory.
+const maxArchiveFileSize = 20 * 1024 * 1024
+
+var errArchiveFileTooLarge = errors.New("archive file too large")
+
+type ImportServiceImpl struct {
+	Db                  Dao
+	PhotoService        photo.Service
+	UserService         user.Service
+	FollowService       follow.Service
+	BanService          user.BanService
+	RelationshipService user.RelationshipService
+	Logger              logrus.FieldLogger
+}
+
+// ImportArchive imports the archive into the account of the given user,
+// which is usually a new one, returning what has been imported.
+// In a dry run, nothing is written, but the report is returned anyway.
+//
+// Photos and comments keep their IDs, unless they're already taken by someone else.
+// Other users are found by username, since their IDs differ between instances.
+// Followers are imported only by an operator, since following is up to them.
+//
+// Importing the same archive again skips what has already been imported.
+func (service ImportServiceImpl) ImportArchive(userId string, archiveFile io.ReaderAt, archiveSize int64, dryRun bool, asOperator bool) (importReport, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return importReport{}, api.ErrWrongUUID
+	}
+
+	archive, err := zip.NewReader(archiveFile, archiveSize)
+	if err != nil {
+		return importReport{}, api.ErrMedia
+	}
+
+	manifestData, err := readArchiveFile(archive, "manifest.json")
+	if err != nil {
+		return importReport{}, api.ErrMedia
+	}
+
+	var content manifest
+	if err := json.Unmarshal(manifestData, &content); err != nil {
+		return importReport{}, api.ErrMedia
+	}
+
+	currentProfile, err := service.Db.GetProfile(userUuid)
+	if err != nil {
+		return importReport{}, err
+	} else if currentProfile == nil {
+		return importReport{}, api.ErrNotFound
+	}
+
+	imp := &importer{
+		ImportServiceImpl: service,
+		userUuid:          userUuid,
+		currentProfile:    *currentProfile,
+		dryRun:            dryRun,
+		asOperator:        asOperator,
+		archive:           archive,
+		report:            newImportReport(dryRun),
+		photoIds:          make(map[string]uuid.UUID),
+	}
+
+	// Photos must be there before comments and likes, follows before bans
+	steps := []func(manifest) error{
+		imp.importProfile,
+		imp.importPhotos,
+		imp.importFollowings,
+		imp.importFollowers,
+		imp.importComments,
+		imp.importLikes,
+		imp.importBans,
+	}
+	for _, step := range steps {
+		if err := step(content); err != nil {
+			return importReport{}, err
+		}
+	}
+
+	return imp.report, nil
+}
+
+// importer holds the state of a single import
+type importer struct {
+	ImportServiceImpl
+	userUuid       uuid.UUID
+	currentProfile entityProfile
+	dryRun         bool
+	asOperator     bool
+	archive        *zip.Reader
+	report         importReport
+
+	// photoIds maps the ID of each photo in the archive to its ID in this instance
+	photoIds map[string]uuid.UUID
+}
+
+func (imp *importer) importProfile(content manifest) error {
+	profile := importedProfile{
+		Name:     content.Profile.Name,
+		Surname:  content.Profile.Surname,
+		Username: content.Profile.Username,
+		Bio:      content.Profile.Bio,
+		Website:  content.Profile.Website,
+		Pronouns: content.Profile.Pronouns,
+	}
+	if err := api.ValidateParsedStruct(&profile, imp.Logger); err != nil {
+		imp.warn("The profile is not valid, so it has not been imported: " + err.Message)
+		return nil
+	}
+
+	if !imp.dryRun {
+		if err := imp.Db.UpdateProfile(imp.userUuid, profile); err != nil {
+			return err
+		}
+	}
+
+	if profile.Username != imp.currentProfile.Username {
+		if err := imp.importUsername(profile.Username); err != nil {
+			return err
+		}
+	}
+
+	if content.Profile.Private != (imp.currentProfile.Private > 0) && !imp.dryRun {
+		if err := imp.FollowService.SetPrivate(imp.userUuid.String(), content.Profile.Private); err != nil {
+			return err
+		}
+	}
+
+	if content.Profile.Email != "" && content.Profile.Email != imp.currentProfile.Email {
+		imp.warn("The email address has not been imported, since it must be verified again")
+	}
+
+	return nil
+}
+
+func (imp *importer) importUsername(username string) error {
+	owner, err := imp.Db.FindUserByUsername(username)
+	if err != nil {
+		return err
+	} else if !owner.IsNil() {
+		imp.warn("The username @" + username + " is already taken, so it has not been imported")
+		return nil
+	}
+
+	if imp.dryRun {
+		err = imp.UserService.CheckUsernameChange(imp.userUuid.String(), username)
+	} else {
+		_, err = imp.UserService.UpdateUsername(imp.userUuid.String(), username)
+	}
+
+	switch {
+	case errors.Is(err, api.ErrAlreadyTaken):
+		// Reserved, or taken in the meantime
+		imp.warn("The username @" + username + " is already taken, so it has not been imported")
+		return nil
+	case errors.Is(err, api.ErrTooManyRequests):
+		imp.warn("The username has been changed too recently, so @" + username + " has not been imported")
+		return nil
+	default:
+		return err
+	}
+}
+
+func (imp *importer) importPhotos(content manifest) error {
+	section := &imp.report.Photos
+	for _, archived := range content.Photos {
+		photoUuid := uuid.FromStringOrNil(archived.Id)
+		_, dateErr := timeprovider.UTCStringToDate(archived.PublishDate)
+		if photoUuid.IsNil() || dateErr != nil || !isValidAudience(archived.Audience) {
+			section.skip(archived.Id, skipInvalid)
+			continue
+		}
+
+		if archived.File == "" {
+			section.skip(archived.Id, skipMissingFile)
+			continue
+		}
+
+		imageData, err := readArchiveFile(imp.archive, archived.File)
+		if errors.Is(err, fs.ErrNotExist) {
+			section.skip(archived.Id, skipMissingFile)
+			continue
+		} else if err != nil || !imaging.IsWebp(imageData) {
+			section.skip(archived.Id, skipInvalid)
+			continue
+		}
+
+		// Keep the original ID, unless it's someone else's
+		photoUuid, alreadyPresent, err := imp.chooseId(photoUuid, func(id uuid.UUID) (uuid.UUID, error) {
+			authorId, err := imp.PhotoService.GetPostAuthorById(id.String())
+			if errors.Is(err, api.ErrNotFound) {
+				return uuid.Nil, nil
+			}
+			return uuid.FromStringOrNil(authorId), err
+		})
+		if err != nil {
+			return err
+		} else if alreadyPresent {
+			imp.photoIds[archived.Id] = photoUuid
+			section.skip(archived.Id, skipAlreadyPresent)
+			continue
+		}
+
+		if !imp.dryRun {
+			err := imp.PhotoService.RestorePost(photoUuid.String(), imp.userUuid.String(), imageData, archived.Audience, archived.PublishDate, imp.Logger)
+			if errors.Is(err, api.ErrMedia) {
+				section.skip(archived.Id, skipInvalid)
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if err := imp.rememberId(archived.Id, photoUuid); err != nil {
+				return err
+			}
+		}
+
+		imp.photoIds[archived.Id] = photoUuid
+		section.imported(archived.Id, photoUuid)
+	}
+
+	return nil
+}
+
+func (imp *importer) importFollowings(content manifest) error {
+	section := &imp.report.Followings
+	for _, archived := range content.Followings {
+		relationship, found, err := imp.findRelatedUser(section, archived.Id, archived.Username)
+		if err != nil {
+			return err
+		} else if !found {
+			continue
+		}
+
+		switch {
+		case relationship.Following || relationship.Requested:
+			section.skip(archived.Id, skipAlreadyPresent)
+			continue
+		case relationship.BannedBy:
+			section.skip(archived.Id, skipBanned)
+			continue
+		}
+
+		if !imp.dryRun {
+			// Private accounts will receive a follow request
+			_, err := imp.FollowService.FollowUser(imp.userUuid.String(), relationship.OtherId)
+			if errors.Is(err, api.ErrUserBanned) {
+				section.skip(archived.Id, skipBanned)
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+
+		section.imported(archived.Id, uuid.FromStringOrNil(relationship.OtherId))
+	}
+
+	return nil
+}
+
+func (imp *importer) importFollowers(content manifest) error {
+	section := &imp.report.Followers
+	for _, archived := range content.Followers {
+		if !imp.asOperator {
+			section.skip(archived.Id, skipOperatorOnly)
+			continue
+		}
+
+		relationship, found, err := imp.findRelatedUser(section, archived.Id, archived.Username)
+		if err != nil {
+			return err
+		} else if !found {
+			continue
+		}
+
+		switch {
+		case relationship.FollowedBy:
+			section.skip(archived.Id, skipAlreadyPresent)
+			continue
+		case relationship.Banned || relationship.BannedBy:
+			section.skip(archived.Id, skipBanned)
+			continue
+		}
+
+		if !imp.dryRun {
+			err := imp.Db.RestoreFollow(uuid.FromStringOrNil(relationship.OtherId), imp.userUuid)
+			if err != nil {
+				return err
+			}
+		}
+
+		section.imported(archived.Id, uuid.FromStringOrNil(relationship.OtherId))
+	}
+
+	return nil
+}
+
+func (imp *importer) importComments(content manifest) error {
+	section := &imp.report.Comments
+	for _, archived := range content.Comments {
+		commentUuid := uuid.FromStringOrNil(archived.Id)
+		_, dateErr := timeprovider.UTCStringToDate(archived.PublishDate)
+		text := importedComment{Text: archived.Text}
+		if commentUuid.IsNil() || dateErr != nil || api.ValidateParsedStruct(&text, imp.Logger) != nil {
+			section.skip(archived.Id, skipInvalid)
+			continue
+		}
+
+		// Keep the original ID, unless it's someone else's
+		commentUuid, alreadyPresent, err := imp.chooseId(commentUuid, imp.Db.GetCommentAuthor)
+		if err != nil {
+			return err
+		} else if alreadyPresent {
+			section.skip(archived.Id, skipAlreadyPresent)
+			continue
+		}
+
+		photoUuid, err := imp.findPhoto(archived.PhotoId)
+		if err != nil {
+			return err
+		} else if photoUuid.IsNil() {
+			section.skip(archived.Id, skipPhotoNotFound)
+			continue
+		}
+
+		if !imp.dryRun {
+			err := imp.Db.RestoreComment(commentUuid, photoUuid, imp.userUuid, text.Text, archived.PublishDate)
+			if err != nil {
+				return err
+			}
+
+			if err := imp.rememberId(archived.Id, commentUuid); err != nil {
+				return err
+			}
+		}
+
+		section.imported(archived.Id, commentUuid)
+	}
+
+	return nil
+}
+
+func (imp *importer) importLikes(content manifest) error {
+	section := &imp.report.Likes
+	for _, archived := range content.Likes {
+		photoUuid, err := imp.findPhoto(archived.PhotoId)
+		if err != nil {
+			return err
+		} else if photoUuid.IsNil() {
+			section.skip(archived.PhotoId, skipPhotoNotFound)
+			continue
+		}
+
+		liked, err := imp.Db.HasLiked(imp.userUuid, photoUuid)
+		if err != nil {
+			return err
+		} else if liked {
+			section.skip(archived.PhotoId, skipAlreadyPresent)
+			continue
+		}
+
+		if !imp.dryRun {
+			if err := imp.Db.RestoreLike(imp.userUuid, photoUuid); err != nil {
+				return err
+			}
+		}
+
+		section.imported(archived.PhotoId, photoUuid)
+	}
+
+	return nil
+}
+
+func (imp *importer) importBans(content manifest) error {
+	section := &imp.report.Bans
+	for _, archived := range content.Bans {
+		relationship, found, err := imp.findRelatedUser(section, archived.Id, archived.Username)
+		if err != nil {
+			return err
+		} else if !found {
+			continue
+		}
+
+		if relationship.Banned {
+			section.skip(archived.Id, skipAlreadyPresent)
+			continue
+		}
+
+		if !imp.dryRun {
+			if err := imp.BanService.BanUser(relationship.OtherId, imp.userUuid.String()); err != nil {
+				return err
+			}
+		}
+
+		section.imported(archived.Id, uuid.FromStringOrNil(relationship.OtherId))
+	}
+
+	return nil
+}
+
+// chooseId decides the ID to import an item with, given a function returning the author of an existing item.
+// The original ID is kept, unless someone else is using it: in that case, a new one is chosen,
+// or the one chosen by a previous import of the same item is used again.
+// It also tells if the item has already been imported by the user.
+func (imp *importer) chooseId(originalUuid uuid.UUID, getAuthor func(uuid.UUID) (uuid.UUID, error)) (uuid.UUID, bool, error) {
+	authorUuid, err := getAuthor(originalUuid)
+	if err != nil {
+		return uuid.Nil, false, err
+	} else if authorUuid.IsNil() {
+		return originalUuid, false, nil
+	} else if authorUuid == imp.userUuid {
+		return originalUuid, true, nil
+	}
+
+	// Imported before with a new ID?
+	importedUuid, err := imp.Db.GetImportedId(imp.userUuid, originalUuid)
+	if err != nil {
+		return uuid.Nil, false, err
+	} else if !importedUuid.IsNil() {
+		if authorUuid, err := getAuthor(importedUuid); err != nil {
+			return uuid.Nil, false, err
+		} else if authorUuid == imp.userUuid {
+			return importedUuid, true, nil
+		}
+	}
+
+	newUuid, err := uuid.NewV4()
+	return newUuid, false, err
+}
+
+// rememberId records the new ID given to an imported item, if any
+func (imp *importer) rememberId(archivedId string, newUuid uuid.UUID) error {
+	if newUuid.String() == archivedId {
+		return nil
+	}
+	return imp.Db.InsertImportedId(imp.userUuid, uuid.FromStringOrNil(archivedId), newUuid)
+}
+
+// findRelatedUser finds the user in this instance by username,
+// returning the relationship with them.
+// If they cannot be found, it adds the reason to the section.
+func (imp *importer) findRelatedUser(section *importSection, archivedId string, username string) (user.Relationship, bool, error) {
+	otherUuid, err := imp.Db.FindUserByUsername(username)
+	if err != nil {
+		return user.Relationship{}, false, err
+	} else if otherUuid.IsNil() {
+		section.skip(archivedId, skipUserNotFound)
+		return user.Relationship{}, false, nil
+	} else if otherUuid == imp.userUuid {
+		section.skip(archivedId, skipInvalid)
+		return user.Relationship{}, false, nil
+	}
+
+	relationship, err := imp.RelationshipService.GetRelationship(imp.userUuid.String(), otherUuid.String())
+	if errors.Is(err, api.ErrNotFound) {
+		// Deleted in the meantime
+		section.skip(archivedId, skipUserNotFound)
+		return user.Relationship{}, false, nil
+	}
+	return relationship, err == nil, err
+}
+
+// findPhoto returns the ID in this instance of a photo in the archive.
+// Photos by other users must be on this instance already, visible to the user.
+// It returns uuid.Nil if it cannot be found.
+func (imp *importer) findPhoto(archivedId string) (uuid.UUID, error) {
+	if photoUuid, ok := imp.photoIds[archivedId]; ok {
+		return photoUuid, nil
+	}
+
+	found, err := imp.PhotoService.GetPhotoByIdAs(archivedId, imp.userUuid.String())
+	switch {
+	case errors.Is(err, api.ErrWrongUUID), errors.Is(err, api.ErrUserBanned), errors.Is(err, api.ErrPrivateAccount):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	case found == nil:
+		return uuid.Nil, nil
+	default:
+		return uuid.FromStringOrNil(found.Id), nil
+	}
+}
+
+func (imp *importer) warn(warning string) {
+	imp.report.Warnings = append(imp.report.Warnings, warning)
+}
+
+func newImportReport(dryRun bool) importReport {
+	return importReport{
+		DryRun:     dryRun,
+		Warnings:   []string{},
+		Photos:     newImportSection(),
+		Comments:   newImportSection(),
+		Likes:      newImportSection(),
+		Followings: newImportSection(),
+		Followers:  newImportSection(),
+		Bans:       newImportSection(),
+	}
+}
+
+func newImportSection() importSection {
+	return importSection{
+		Remapped: []remappedId{},
+		Skipped:  []skippedItem{},
+	}
+}
+
+// imported counts an imported item, remembering if its ID changed
+func (section *importSection) imported(archivedId string, newUuid uuid.UUID) {
+	section.Imported++
+	if newUuid.String() != archivedId {
+		section.Remapped = append(section.Remapped, remappedId{From: archivedId, To: newUuid.String()})
+	}
+}
+
+func (section *importSection) skip(archivedId string, reason string) {
+	section.Skipped = append(section.Skipped, skippedItem{Id: archivedId, Reason: reason})
+}
+
+func isValidAudience(audience string) bool {
+	switch audience {
+	case photo.AudienceEveryone, photo.AudienceFollowers, photo.AudienceCloseFriends:
+		return true
+	default:
+		return false
+	}
+}
+
+// readArchiveFile reads a file from the archive, up to maxArchiveFileSize.
+// If it doesn't exist, it returns an error satisfying errors.Is(err, fs.ErrNotExist).
+func readArchiveFile(archive *zip.Reader, name string) ([]byte, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxArchiveFileSize+1))
+	if err != nil {
+		return nil, err
+	} else if len(data) > maxArchiveFileSize {
+		return nil, errArchiveFileTooLarge
+	}
+
+	return data, nil
+}
@@ -0,0 +1,224 @@
+package export
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/features/photo"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/securetoken"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type Service interface {
+	RequestExport(userId string) (dataExport, error)
+	GetLatestExport(userId string) (dataExport, error)
+	CreateDownloadLink(userId string) (downloadLink, error)
+	OpenArchive(token string) (*os.File, error)
+	ProcessPendingExports() error
+	DeleteExpiredExports() error
+	DeleteAllExportsOf(userId string) error
+}
+
+// How long the archives and their download links are kept
+const (
+	archiveDuration      = 7 * 24 * time.Hour
+	downloadLinkDuration = 15 * time.Minute
+)
+
+type ServiceImpl struct {
+	Db           Dao
+	PhotoService photo.Service
+	Time         timeprovider.TimeProvider
+	Logger       logrus.FieldLogger
+
+	// Dir is where the archives are saved.
+	// It must not be publicly served, since they contain private data.
+	Dir string
+}
+
+// RequestExport schedules a new export of the data of the user,
+// which is built in background.
+// If there's one still pending, the existing one is returned.
+func (service ServiceImpl) RequestExport(userId string) (dataExport, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return dataExport{}, api.ErrWrongUUID
+	}
+
+	latest, err := service.Db.GetLatestExport(userUuid)
+	if err != nil {
+		return dataExport{}, err
+	} else if latest != nil && latest.Status == statusPending {
+		return latest.toDto(), nil
+	}
+
+	exportUuid, err := uuid.NewV4()
+	if err != nil {
+		return dataExport{}, err
+	}
+
+	export := entityDataExport{
+		Id:          exportUuid.Bytes(),
+		UserId:      userUuid.Bytes(),
+		Status:      statusPending,
+		RequestDate: service.Time.UTCString(),
+	}
+	if err := service.Db.InsertExport(export); err != nil {
+		return dataExport{}, err
+	}
+
+	return export.toDto(), nil
+}
+
+// GetLatestExport returns the last export requested by the user,
+// or api.ErrNotFound if there's none.
+func (service ServiceImpl) GetLatestExport(userId string) (dataExport, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return dataExport{}, api.ErrWrongUUID
+	}
+
+	latest, err := service.Db.GetLatestExport(userUuid)
+	if err != nil {
+		return dataExport{}, err
+	} else if latest == nil {
+		return dataExport{}, api.ErrNotFound
+	}
+
+	return latest.toDto(), nil
+}
+
+// CreateDownloadLink creates a short-lived link to download the last export,
+// replacing the previous one.
+// If the last export is not ready, it returns api.ErrNotFound
+func (service ServiceImpl) CreateDownloadLink(userId string) (downloadLink, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return downloadLink{}, api.ErrWrongUUID
+	}
+
+	latest, err := service.Db.GetLatestExport(userUuid)
+	if err != nil {
+		return downloadLink{}, err
+	} else if latest == nil || latest.Status != statusReady {
+		return downloadLink{}, api.ErrNotFound
+	}
+
+	token, tokenHash, err := securetoken.New()
+	if err != nil {
+		return downloadLink{}, err
+	}
+
+	expirationDate := timeprovider.DateToUTCString(service.Time.Now().Add(downloadLinkDuration))
+	if err := service.Db.SetExportLink(uuid.FromBytesOrNil(latest.Id), tokenHash, expirationDate); err != nil {
+		return downloadLink{}, err
+	}
+
+	return downloadLink{
+		Url:            "/data-exports/" + token,
+		ExpirationDate: expirationDate,
+	}, nil
+}
+
+// OpenArchive opens the archive the download link points to.
+// If the link is invalid or expired, it returns api.ErrNotFound
+func (service ServiceImpl) OpenArchive(token string) (*os.File, error) {
+	export, err := service.Db.GetExportByLinkTokenHash(securetoken.Hash(token), service.Time.UTCString())
+	if err != nil {
+		return nil, err
+	} else if export == nil {
+		return nil, api.ErrNotFound
+	}
+
+	file, err := os.Open(service.pathForArchive(uuid.FromBytesOrNil(export.Id)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, api.ErrNotFound
+	}
+	return file, err
+}
+
+// ProcessPendingExports builds the archives of the pending exports, from the oldest one.
+//
+// If an archive cannot be built, its export is marked as failed,
+// so that the user can request a new one.
+// It goes on with the other exports anyway, returning the first error encountered.
+func (service ServiceImpl) ProcessPendingExports() error {
+	exports, err := service.Db.GetPendingExports()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, export := range exports {
+		exportUuid := uuid.FromBytesOrNil(export.Id)
+		status := statusReady
+		expirationDate := timeprovider.DateToUTCString(service.Time.Now().Add(archiveDuration))
+
+		if err := service.buildArchive(exportUuid, uuid.FromBytesOrNil(export.UserId)); err != nil {
+			service.Logger.WithError(err).WithField("exportId", exportUuid.String()).Error("unable to build data export")
+			status = statusFailed
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		if err := service.Db.SetExportCompleted(exportUuid, status, service.Time.UTCString(), expirationDate); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// DeleteExpiredExports deletes the archives which are not available anymore.
+func (service ServiceImpl) DeleteExpiredExports() error {
+	exports, err := service.Db.GetExpiredExports(service.Time.UTCString())
+	if err != nil {
+		return err
+	}
+
+	return service.deleteExports(exports)
+}
+
+// DeleteAllExportsOf deletes the archives of the user.
+// It's used before deleting the user, since the files are not in the database.
+func (service ServiceImpl) DeleteAllExportsOf(userId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	exports, err := service.Db.GetExportsOf(userUuid)
+	if err != nil {
+		return err
+	}
+
+	return service.deleteExports(exports)
+}
+
+// deleteExports deletes the archive files first,
+// so that a failure leaves the row there and the deletion is retried later.
+func (service ServiceImpl) deleteExports(exports []entityDataExport) error {
+	for _, export := range exports {
+		exportUuid := uuid.FromBytesOrNil(export.Id)
+		err := os.Remove(service.pathForArchive(exportUuid))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if err := service.Db.DeleteExport(exportUuid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service ServiceImpl) pathForArchive(exportUuid uuid.UUID) string {
+	return filepath.Join(service.Dir, exportUuid.String()+".zip")
+}
@@ -18,21 +18,55 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodPut,
 			Path:    "/users/:userId/followings/:followedId",
 			Handler: controller.followUser,
+			Scope:   route.ScopeFollowsWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodDelete,
 			Path:    "/users/:userId/followings/:followedId",
 			Handler: controller.unfollowUser,
+			Scope:   route.ScopeFollowsWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodGet,
 			Path:    "/users/:userId/followers/",
 			Handler: controller.listFollowers,
+			Scope:   route.ScopeRead,
 		},
 		route.SecureRoute{
 			Method:  http.MethodGet,
 			Path:    "/users/:userId/followings/",
 			Handler: controller.listFollowings,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/followRequests/",
+			Handler: controller.listFollowRequests,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/followRequests/:requesterId",
+			Handler: controller.approveFollowRequest,
+			Scope:   route.ScopeFollowsWrite,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/followRequests/:requesterId",
+			Handler: controller.denyFollowRequest,
+			Scope:   route.ScopeFollowsWrite,
+		},
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/suggestions",
+			Handler: controller.listSuggestions,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/privacy",
+			Handler: controller.setPrivacy,
+			Scope:   route.ScopeProfileWrite,
 		},
 	}
 }
@@ -49,7 +83,16 @@ func (controller Controller) followUser(w http.ResponseWriter, _ *http.Request,
 		return
 	}
 
-	err := controller.Service.FollowUser(context.UserId, args.FollowedId)
+	requested, err := controller.Service.FollowUser(context.UserId, args.FollowedId)
+	if err == nil && requested {
+		// The account is private, the owner must approve the request
+		api.SendJson(w, followRequest{
+			RequesterId: context.UserId,
+			TargetId:    args.FollowedId,
+		}, http.StatusAccepted, context.Logger)
+		return
+	}
+
 	result := userFollow{
 		FollowingId: args.FollowedId,
 		FollowerId:  context.UserId,
@@ -84,12 +127,18 @@ func (controller Controller) listFollowers(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		// Add avatar URL prefix
+		for i := range followers {
+			followers[i].AddImageHost(r, context.Logger)
+		}
+
 		api.SendJson(w, api.PageResult[user.User]{
 			NextPageCursor: cursor,
 			PageData:       followers,
 		}, http.StatusOK, context.Logger)
 	}
 }
+
 func (controller Controller) listFollowings(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
 	args, bodyErr := api.ParseAllRequestVariables(r, params, &user.IdUserCursor{}, context.Logger)
 	if bodyErr != nil {
@@ -101,9 +150,129 @@ func (controller Controller) listFollowings(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		// Add avatar URL prefix
+		for i := range followers {
+			followers[i].AddImageHost(r, context.Logger)
+		}
+
 		api.SendJson(w, api.PageResult[user.User]{
 			NextPageCursor: cursor,
 			PageData:       followers,
 		}, http.StatusOK, context.Logger)
 	}
 }
+
+func (controller Controller) listFollowRequests(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &user.IdUserCursor{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	requesters, cursor, err := controller.Service.ListFollowRequests(args.UserId, args.PageCursorOrEmpty)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		// Add avatar URL prefix
+		for i := range requesters {
+			requesters[i].AddImageHost(r, context.Logger)
+		}
+
+		api.SendJson(w, api.PageResult[user.User]{
+			NextPageCursor: cursor,
+			PageData:       requesters,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller Controller) approveFollowRequest(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &followRequestParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.ApproveFollowRequest(args.UserId, args.RequesterId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, userFollow{
+			FollowingId: args.UserId,
+			FollowerId:  args.RequesterId,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller Controller) denyFollowRequest(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &followRequestParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.DenyFollowRequest(args.UserId, args.RequesterId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
+
+func (controller Controller) setPrivacy(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, body, bodyErr := api.ParseVariablesAndBody(r, params, &user.IdParams{}, &userPrivacy{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.SetPrivate(args.UserId, *body.Private)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, body, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller Controller) listSuggestions(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &user.IdUserCursor{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	suggestions, cursor, err := controller.Service.ListSuggestions(args.UserId, args.PageCursorOrEmpty)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		// Add avatar URL prefix
+		for i := range suggestions {
+			suggestions[i].AddImageHost(r, context.Logger)
+		}
+
+		api.SendJson(w, api.PageResult[user.User]{
+			NextPageCursor: cursor,
+			PageData:       suggestions,
+		}, http.StatusOK, context.Logger)
+	}
+}
@@ -1,6 +1,7 @@
 package follow
 
 import (
+	"database/sql"
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/user"
@@ -11,8 +12,18 @@ type Dao interface {
 	UnfollowUser(followerUuid uuid.UUID, followingUuid uuid.UUID) (bool, error)
 	GetFollowersPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID, afterFollowerId uuid.UUID, afterUsername string) ([]user.ModelUserWithCustom, error)
 	GetFollowingsPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID, afterFollowerId uuid.UUID, afterUsername string) ([]user.ModelUserWithCustom, error)
+	InsertFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID, requestDate string) error
+	DeleteFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID) (bool, error)
+	ApproveFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID) (bool, error)
+	GetFollowRequestsPage(targetUuid uuid.UUID, afterRequesterId uuid.UUID, afterUsername string) ([]user.ModelUserWithCustom, error)
+	SetPrivate(userUuid uuid.UUID, private bool) (bool, error)
+	GetSuggestionsPage(userUuid uuid.UUID, afterScore float64, afterId uuid.UUID) ([]user.ModelUserWithScore, error)
 }
 
+// Max number of followings, followers and likes of the user
+// considered for the suggestions, so that their cost is bounded.
+const suggestionsSeedLimit = 500
+
 type DbDao struct {
 	Db database.AppDatabase
 }
@@ -33,12 +44,14 @@ func (db DbDao) GetFollowersPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID, a
 	query := `
 		SELECT UserInfo.*,
 		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
 		FROM UserInfo
 		LEFT JOIN Follow on UserInfo.id = Follow.followerId
 		WHERE Follow.followedId = ?
 		 	  -- Cursor pagination
 			  AND (username, id) > (?, ?)
+			  AND ` + user.ActiveCondition("UserInfo.id") + `
 		 	  AND NOT EXISTS(SELECT * FROM Ban WHERE Ban.bannerId = UserInfo.id AND Ban.bannedId = ?)
 		ORDER BY username, id
 		LIMIT ?`
@@ -48,6 +61,7 @@ func (db DbDao) GetFollowersPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID, a
 		query,
 		searchAsUuid.Bytes(),
 		searchAsUuid.Bytes(),
+		searchAsUuid.Bytes(),
 		userUuid.Bytes(),
 		afterUsername,
 		afterFollowerId.Bytes(),
@@ -66,12 +80,14 @@ func (db DbDao) GetFollowingsPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID,
 	query := `
 		SELECT UserInfo.*,
 		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
 		FROM UserInfo
 		LEFT JOIN Follow on UserInfo.id = Follow.followedId
 		WHERE Follow.followerId = ?
 		 	  -- Cursor pagination
 			  AND (username, id) > (?, ?)
+			  AND ` + user.ActiveCondition("UserInfo.id") + `
 			  AND NOT EXISTS(SELECT * FROM Ban WHERE Ban.bannerId = UserInfo.id AND Ban.bannedId = ?)
 		ORDER BY username, id
 		LIMIT ?`
@@ -81,6 +97,7 @@ func (db DbDao) GetFollowingsPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID,
 		query,
 		searchAsUuid.Bytes(),
 		searchAsUuid.Bytes(),
+		searchAsUuid.Bytes(),
 		userUuid.Bytes(),
 		afterUsername,
 		afterFollowerId.Bytes(),
@@ -94,3 +111,204 @@ func (db DbDao) GetFollowingsPageAs(userUuid uuid.UUID, searchAsUuid uuid.UUID,
 
 	return user.ParseUserEntities(rows)
 }
+
+// InsertFollowRequest records a request to follow a private account.
+// Nothing happens if the request already exists.
+func (db DbDao) InsertFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID, requestDate string) error {
+	return db.Db.Exec("INSERT INTO FollowRequest (requesterId, targetId, requestDate) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		requesterUuid.Bytes(), targetUuid.Bytes(), requestDate)
+}
+
+func (db DbDao) DeleteFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID) (bool, error) {
+	rows, err := db.Db.ExecRows("DELETE FROM FollowRequest WHERE requesterId = ? AND targetId = ?",
+		requesterUuid.Bytes(), targetUuid.Bytes())
+	return rows > 0, err
+}
+
+// ApproveFollowRequest turns a pending request into an actual follow.
+// It returns false if there was no such request.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) ApproveFollowRequest(requesterUuid uuid.UUID, targetUuid uuid.UUID) (bool, error) {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec("DELETE FROM FollowRequest WHERE requesterId = ? AND targetId = ?",
+		requesterUuid.Bytes(), targetUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+	if found, err := hasAffectedRows(result); err != nil || !found {
+		return false, err
+	}
+
+	_, err = tx.Exec("INSERT INTO Follow (followerId, followedId) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		requesterUuid.Bytes(), targetUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (db DbDao) GetFollowRequestsPage(targetUuid uuid.UUID, afterRequesterId uuid.UUID, afterUsername string) ([]user.ModelUserWithCustom, error) {
+	query := `
+		SELECT UserInfo.*,
+		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
+		FROM UserInfo
+		JOIN FollowRequest ON UserInfo.id = FollowRequest.requesterId
+		WHERE FollowRequest.targetId = ?
+		 	  -- Cursor pagination
+			  AND (username, id) > (?, ?)
+			  AND ` + user.ActiveCondition("UserInfo.id") + `
+		ORDER BY username, id
+		LIMIT ?`
+
+	rows, err := db.Db.QueryStructRows(
+		user.ModelUserWithCustom{},
+		query,
+		targetUuid.Bytes(),
+		targetUuid.Bytes(),
+		targetUuid.Bytes(),
+		targetUuid.Bytes(),
+		afterUsername,
+		afterRequesterId.Bytes(),
+		database.MaxPageItems,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ParseUserEntities(rows)
+}
+
+// SetPrivate changes the privacy of the account.
+// When it becomes public, all the pending requests are approved.
+//
+// Since multiple tables are involved, a transaction is used.
+func (db DbDao) SetPrivate(userUuid uuid.UUID, private bool) (bool, error) {
+	tx, err := db.Db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec("UPDATE User SET private = ? WHERE id = ?", private, userUuid.Bytes())
+	if err != nil {
+		return false, err
+	}
+	if found, err := hasAffectedRows(result); err != nil || !found {
+		return false, err
+	}
+
+	if !private {
+		_, err = tx.Exec(`INSERT INTO Follow (followerId, followedId)
+			SELECT requesterId, targetId FROM FollowRequest WHERE targetId = ?
+			ON CONFLICT DO NOTHING`, userUuid.Bytes())
+		if err != nil {
+			return false, err
+		}
+
+		_, err = tx.Exec("DELETE FROM FollowRequest WHERE targetId = ?", userUuid.Bytes())
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// GetSuggestionsPage finds the users the given one may want to follow.
+//
+// Each candidate gets a score, summing up:
+//   - the followings of the user who follow the candidate (friends of friends);
+//   - the followers of the user who follow the candidate too (mutual followers),
+//     plus a bonus if the candidate follows the user;
+//   - the photos liked by both the user and the candidate.
+//
+// Users already followed, or in a ban relationship in either direction, are excluded.
+func (db DbDao) GetSuggestionsPage(userUuid uuid.UUID, afterScore float64, afterId uuid.UUID) ([]user.ModelUserWithScore, error) {
+	query := `
+		WITH MyFollowings AS (
+			SELECT followedId AS id FROM Follow WHERE followerId = ? LIMIT ?
+		), MyFollowers AS (
+			SELECT followerId AS id FROM Follow WHERE followedId = ? LIMIT ?
+		), MyLikes AS (
+			SELECT photoId FROM Likes WHERE userId = ? LIMIT ?
+		), Candidates AS (
+			SELECT Follow.followedId AS id, 3 AS weight
+			FROM MyFollowings
+			JOIN Follow ON Follow.followerId = MyFollowings.id
+			UNION ALL
+			SELECT Follow.followedId AS id, 2 AS weight
+			FROM MyFollowers
+			JOIN Follow ON Follow.followerId = MyFollowers.id
+			UNION ALL
+			SELECT id, 4 AS weight
+			FROM MyFollowers
+			UNION ALL
+			SELECT Likes.userId AS id, 1 AS weight
+			FROM MyLikes
+			JOIN Likes ON Likes.photoId = MyLikes.photoId
+		), Scored AS (
+			SELECT id, SUM(weight) AS score
+			FROM Candidates
+			WHERE id != ?
+			GROUP BY id
+		)
+		SELECT UserInfo.*,
+		       FALSE AS banned,
+		       FALSE AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = Scored.id AND followedId = ?) AS followsYou,
+		       Scored.score
+		FROM Scored
+		JOIN UserInfo ON UserInfo.id = Scored.id
+		WHERE NOT EXISTS(SELECT * FROM Follow WHERE followerId = ? AND followedId = Scored.id)
+		  AND NOT EXISTS(SELECT * FROM Ban WHERE bannerId = ? AND bannedId = Scored.id)
+		  AND NOT EXISTS(SELECT * FROM Ban WHERE bannerId = Scored.id AND bannedId = ?)
+		  AND ` + user.ActiveCondition("Scored.id") + `
+		  -- Cursor pagination
+		  AND (Scored.score < ? OR (Scored.score = ? AND Scored.id > ?))
+		ORDER BY Scored.score DESC, Scored.id
+		LIMIT ?`
+
+	rows, err := db.Db.QueryStructRows(
+		user.ModelUserWithScore{},
+		query,
+		userUuid.Bytes(),
+		suggestionsSeedLimit,
+		userUuid.Bytes(),
+		suggestionsSeedLimit,
+		userUuid.Bytes(),
+		suggestionsSeedLimit,
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		afterScore,
+		afterScore,
+		afterId.Bytes(),
+		database.MaxPageItems,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ParseScoredUserEntities(rows)
+}
+
+func hasAffectedRows(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
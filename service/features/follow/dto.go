@@ -13,3 +13,17 @@ type userFollow struct {
 	FollowingId string `json:"followingId"`
 	FollowerId  string `json:"followerId"`
 }
+
+type followRequestParams struct {
+	user.IdParams
+	RequesterId string `json:"requesterId" validate:"required,uuid"`
+}
+
+type followRequest struct {
+	RequesterId string `json:"requesterId"`
+	TargetId    string `json:"targetId"`
+}
+
+type userPrivacy struct {
+	Private *bool `json:"private" validate:"required"`
+}
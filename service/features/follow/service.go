@@ -6,27 +6,35 @@ import (
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
 	"github.com/simonesestito/wasaphoto/service/utils/cursor"
 )
 
 type Service interface {
-	FollowUser(followerId string, followingId string) error
+	FollowUser(followerId string, followingId string) (requested bool, err error)
 	UnfollowUser(followerId string, followingId string) error
 	ListFollowersAs(userId string, searchAs string, pageCursor string) ([]user.User, *string, error)
 	ListFollowingsAs(userId string, searchAs string, pageCursor string) ([]user.User, *string, error)
+	ListFollowRequests(userId string, pageCursor string) ([]user.User, *string, error)
+	ApproveFollowRequest(userId string, requesterId string) error
+	DenyFollowRequest(userId string, requesterId string) error
+	SetPrivate(userId string, private bool) error
+	ListSuggestions(userId string, pageCursor string) ([]user.User, *string, error)
 }
 
 type ServiceImpl struct {
 	Db          Dao
 	BanService  user.BanService
 	UserService user.Service
+	Time        timeprovider.TimeProvider
 }
 
-func NewServiceImpl(db Dao, banService user.BanService, userService user.Service) ServiceImpl {
+func NewServiceImpl(db Dao, banService user.BanService, userService user.Service, time timeprovider.TimeProvider) ServiceImpl {
 	service := ServiceImpl{
 		Db:          db,
 		BanService:  banService,
 		UserService: userService,
+		Time:        time,
 	}
 
 	// Perform actions when a user is banned
@@ -35,37 +43,55 @@ func NewServiceImpl(db Dao, banService user.BanService, userService user.Service
 	return service
 }
 
-func (service ServiceImpl) FollowUser(followerId string, followingId string) error {
+// FollowUser makes the follower follow the other user.
+// If the followed account is private, a follow request is sent instead,
+// which must be approved by its owner, and requested is true.
+func (service ServiceImpl) FollowUser(followerId string, followingId string) (bool, error) {
 	followerUuid := uuid.FromStringOrNil(followerId)
 	followingUuid := uuid.FromStringOrNil(followingId)
 	if followerUuid == uuid.Nil || followingUuid == uuid.Nil {
-		return api.ErrWrongUUID
+		return false, api.ErrWrongUUID
 	}
 
 	if followerId == followingId {
-		return api.ErrSelfOperation
+		return false, api.ErrSelfOperation
 	}
 
 	banned, err := service.BanService.IsUserBanned(followerId, followingId)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if banned {
-		return api.ErrUserBanned
+		return false, api.ErrUserBanned
+	}
+
+	followedUser, err := service.UserService.GetUserAs(followingId, followerId)
+	if err != nil {
+		return false, err
+	} else if followedUser == nil {
+		return false, api.ErrNotFound
+	}
+
+	if followedUser.IsHiddenFrom(followerId) {
+		err = service.Db.InsertFollowRequest(followerUuid, followingUuid, service.Time.UTCString())
+		if errors.Is(err, database.ErrForeignKey) {
+			return false, api.ErrNotFound
+		}
+		return true, err
 	}
 
 	newInsert, err := service.Db.FollowUser(followerUuid, followingUuid)
 	if errors.Is(err, database.ErrForeignKey) {
-		return api.ErrNotFound
+		return false, api.ErrNotFound
 	} else if err != nil {
-		return err
+		return false, err
 	}
 
 	if !newInsert {
-		return api.ErrDuplicated
+		return false, api.ErrDuplicated
 	}
 
-	return nil
+	return false, nil
 }
 
 func (service ServiceImpl) UnfollowUser(followerId string, followingId string) error {
@@ -75,7 +101,12 @@ func (service ServiceImpl) UnfollowUser(followerId string, followingId string) e
 		return api.ErrWrongUUID
 	}
 
-	_, err := service.Db.UnfollowUser(followerUuid, followingUuid)
+	if _, err := service.Db.UnfollowUser(followerUuid, followingUuid); err != nil {
+		return err
+	}
+
+	// Withdraw the follow request too, if still pending
+	_, err := service.Db.DeleteFollowRequest(followerUuid, followingUuid)
 	return err
 }
 
@@ -92,6 +123,8 @@ func (service ServiceImpl) ListFollowersAs(userId string, searchAs string, pageC
 		return nil, nil, err
 	} else if foundRequestedUser == nil {
 		return nil, nil, api.ErrNotFound
+	} else if foundRequestedUser.IsHiddenFrom(searchAs) {
+		return nil, nil, api.ErrPrivateAccount
 	}
 
 	// Parse cursor
@@ -124,6 +157,8 @@ func (service ServiceImpl) ListFollowingsAs(userId string, searchAs string, page
 		return nil, nil, err
 	} else if foundRequestedUser == nil {
 		return nil, nil, api.ErrNotFound
+	} else if foundRequestedUser.IsHiddenFrom(searchAs) {
+		return nil, nil, api.ErrPrivateAccount
 	}
 
 	// Parse cursor
@@ -142,3 +177,98 @@ func (service ServiceImpl) ListFollowingsAs(userId string, searchAs string, page
 	users, nextCursor := user.DbUsersListToPage(dbFollowings)
 	return users, nextCursor, nil
 }
+
+// ListFollowRequests lists the users waiting for the approval to follow the given one
+func (service ServiceImpl) ListFollowRequests(userId string, pageCursor string) ([]user.User, *string, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return nil, nil, api.ErrWrongUUID
+	}
+
+	// Parse cursor
+	afterRequesterId, afterUsername, err := cursor.ParseStringIdCursor(pageCursor)
+	if err != nil {
+		return nil, nil, api.ErrWrongCursor
+	}
+
+	dbRequesters, err := service.Db.GetFollowRequestsPage(userUuid, afterRequesterId, afterUsername)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to DTO
+	users, nextCursor := user.DbUsersListToPage(dbRequesters)
+	return users, nextCursor, nil
+}
+
+// ApproveFollowRequest lets the requester follow the user.
+// If there's no pending request, it returns api.ErrNotFound
+func (service ServiceImpl) ApproveFollowRequest(userId string, requesterId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	requesterUuid := uuid.FromStringOrNil(requesterId)
+	if userUuid.IsNil() || requesterUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	approved, err := service.Db.ApproveFollowRequest(requesterUuid, userUuid)
+	if err != nil {
+		return err
+	} else if !approved {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// DenyFollowRequest discards the pending request, if any
+func (service ServiceImpl) DenyFollowRequest(userId string, requesterId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	requesterUuid := uuid.FromStringOrNil(requesterId)
+	if userUuid.IsNil() || requesterUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	_, err := service.Db.DeleteFollowRequest(requesterUuid, userUuid)
+	return err
+}
+
+// SetPrivate makes the account private or public.
+// When it becomes public, all the pending requests are approved.
+func (service ServiceImpl) SetPrivate(userId string, private bool) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	found, err := service.Db.SetPrivate(userUuid, private)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListSuggestions lists the users the given one may know, with the most relevant ones first
+func (service ServiceImpl) ListSuggestions(userId string, pageCursor string) ([]user.User, *string, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return nil, nil, api.ErrWrongUUID
+	}
+
+	// Parse cursor
+	afterId, afterScore, err := cursor.ParseScoreIdCursor(pageCursor)
+	if err != nil {
+		return nil, nil, api.ErrWrongCursor
+	}
+
+	dbSuggestions, err := service.Db.GetSuggestionsPage(userUuid, afterScore, afterId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to DTO
+	suggestions, nextCursor := user.ScoredUsersListToPage(dbSuggestions)
+	return suggestions, nextCursor, nil
+}
@@ -17,11 +17,13 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodPut,
 			Path:    "/photos/:photoId/likes/:userId",
 			Handler: controller.likePhoto,
+			Scope:   route.ScopeLikesWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodDelete,
 			Path:    "/photos/:photoId/likes/:userId",
 			Handler: controller.unlikePhoto,
+			Scope:   route.ScopeLikesWrite,
 		},
 	}
 }
@@ -6,7 +6,6 @@ import (
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/photo"
-	"github.com/simonesestito/wasaphoto/service/features/user"
 )
 
 type Service interface {
@@ -16,7 +15,6 @@ type Service interface {
 
 type ServiceImpl struct {
 	Db           Dao
-	BanService   user.BanService
 	PhotoService photo.Service
 }
 
@@ -27,19 +25,13 @@ func (service ServiceImpl) LikePhoto(photoId string, userId string) error {
 		return api.ErrWrongUUID
 	}
 
-	// Get info about the photo to like
-	photoAuthorId, err := service.PhotoService.GetPostAuthorById(photoId)
+	// Check if I can see the photo to like:
+	// the author must not have banned me, and I must be in its audience
+	photoToLike, err := service.PhotoService.GetPhotoByIdAs(photoId, userId)
 	if err != nil {
 		return err
-	}
-
-	// Check if photo author banned me
-	iamBanned, err := service.BanService.IsUserBanned(userId, photoAuthorId)
-	if err != nil {
-		return err
-	}
-	if iamBanned {
-		return api.ErrUserBanned
+	} else if photoToLike == nil {
+		return api.ErrNotFound
 	}
 
 	// Like photo
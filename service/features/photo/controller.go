@@ -19,21 +19,30 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodPost,
 			Path:    "/photos/",
 			Handler: controller.uploadPhoto,
+			Scope:   route.ScopePhotosWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodDelete,
 			Path:    "/photos/:photoId",
 			Handler: controller.deletePhoto,
+			Scope:   route.ScopePhotosWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodGet,
 			Path:    "/users/:userId/photos/",
 			Handler: controller.listUserPhotos,
+			Scope:   route.ScopeRead,
 		},
 	}
 }
 
-func (controller Controller) uploadPhoto(w http.ResponseWriter, r *http.Request, _ httprouter.Params, context route.SecureRequestContext) {
+func (controller Controller) uploadPhoto(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &uploadParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
 	// Read photo file from body
 	photoData, err := io.ReadAll(r.Body)
 	_ = r.Body.Close()
@@ -48,7 +57,7 @@ func (controller Controller) uploadPhoto(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	photo, err := controller.Service.CreatePost(context.UserId, photoData, context.Logger)
+	photo, err := controller.Service.CreatePost(context.UserId, photoData, args.Audience, context.Logger)
 
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusCreated, context.Logger)
@@ -65,7 +74,7 @@ func (controller Controller) deletePhoto(w http.ResponseWriter, _ *http.Request,
 		return
 	}
 
-	err := controller.Service.DeletePostAs(args.PhotoId, context.UserId)
+	err := controller.Service.DeletePostAs(args.PhotoId, context.UserId, context.Role.Includes(route.RoleModerator))
 	if errors.Is(err, api.ErrNotFound) {
 		// Ignore, since it's intended to be idempotent
 		err = nil
@@ -5,15 +5,19 @@ import (
 	"errors"
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/user"
 	"github.com/simonesestito/wasaphoto/service/timeprovider"
 )
 
 type Dao interface {
 	GetPhotoByIdAs(photoId uuid.UUID, userId uuid.UUID) (*EntityPhotoAuthorInfo, error)
-	NewPhotoPerUser(photoId uuid.UUID, userId uuid.UUID, imageUrl string) error
+	NewPhotoPerUser(photoId uuid.UUID, userId uuid.UUID, imageUrl string, visibility string) error
+	RestorePhoto(photoId uuid.UUID, userId uuid.UUID, imageUrl string, visibility string, publishDate string) error
+	InsertPhotoImage(image EntityPhotoImage) error
 	DeletePhoto(imageUuid uuid.UUID) error
 	GetPhotoById(imageUuid uuid.UUID) (*EntityPhotoInfo, error)
 	ListUsersPhotoAfter(authorUuid uuid.UUID, searchAsUuid uuid.UUID, afterPhotoId uuid.UUID, beforeDate string) ([]EntityPhotoAuthorInfo, error)
+	ListAllUserPhotoIds(authorUuid uuid.UUID) ([]uuid.UUID, error)
 }
 
 type DbDao struct {
@@ -27,11 +31,12 @@ func (db DbDao) GetPhotoByIdAs(photoId uuid.UUID, userId uuid.UUID) (*EntityPhot
 SELECT P.*,
 EXISTS(SELECT * FROM Ban B WHERE B.bannedId = P.authorId AND B.bannerId = ?) AS banned,
 EXISTS(SELECT * FROM Follow F WHERE F.followedId = P.authorId AND F.followerId = ?) AS following,
+EXISTS(SELECT * FROM Follow F WHERE F.followerId = P.authorId AND F.followedId = ?) AS followsYou,
 EXISTS(SELECT * FROM Likes L WHERE L.photoId = P.authorId AND L.userId = ?) AS liked
 FROM PhotoAuthorInfo P
-WHERE P.id = ?
+WHERE P.id = ? AND ` + VisibleToCondition("P") + `
 `
-	err := db.Db.QueryStructRow(&photo, query, userId.Bytes(), userId.Bytes(), userId.Bytes(), photoId.Bytes())
+	err := db.Db.QueryStructRow(&photo, query, userId.Bytes(), userId.Bytes(), userId.Bytes(), userId.Bytes(), photoId.Bytes(), userId.Bytes(), userId.Bytes(), userId.Bytes())
 
 	// Fix shadowed properties
 	photo.ModelUser.Id = photo.entityPhoto.AuthorId
@@ -43,9 +48,19 @@ WHERE P.id = ?
 	return &photo, err
 }
 
-func (db DbDao) NewPhotoPerUser(photoId uuid.UUID, userId uuid.UUID, imageUrl string) error {
+func (db DbDao) NewPhotoPerUser(photoId uuid.UUID, userId uuid.UUID, imageUrl string, visibility string) error {
 	currentTime := db.Time.UTCString()
-	return db.Db.Exec("INSERT INTO Photo (id, imageUrl, authorId, publishDate) VALUES (?, ?, ?, ?)", photoId.Bytes(), imageUrl, userId.Bytes(), currentTime)
+	return db.Db.Exec("INSERT INTO Photo (id, imageUrl, authorId, publishDate, visibility) VALUES (?, ?, ?, ?, ?)", photoId.Bytes(), imageUrl, userId.Bytes(), currentTime, visibility)
+}
+
+// RestorePhoto inserts a photo keeping its original publish date
+func (db DbDao) RestorePhoto(photoId uuid.UUID, userId uuid.UUID, imageUrl string, visibility string, publishDate string) error {
+	return db.Db.Exec("INSERT INTO Photo (id, imageUrl, authorId, publishDate, visibility) VALUES (?, ?, ?, ?, ?)", photoId.Bytes(), imageUrl, userId.Bytes(), publishDate, visibility)
+}
+
+// InsertPhotoImage adds a rendition to an existing photo
+func (db DbDao) InsertPhotoImage(image EntityPhotoImage) error {
+	return db.Db.Exec("INSERT INTO PhotoImage (photoId, size, url, width, height) VALUES (?, ?, ?, ?, ?)", image.PhotoId, image.Size, image.Url, image.Width, image.Height)
 }
 
 func (db DbDao) DeletePhoto(imageUuid uuid.UUID) error {
@@ -72,9 +87,11 @@ func (db DbDao) ListUsersPhotoAfter(authorUuid uuid.UUID, searchAsUuid uuid.UUID
 		SELECT PhotoAuthorInfo.*,
 		       EXISTS(SELECT * FROM Likes WHERE Likes.photoId = PhotoAuthorInfo.id AND Likes.userId = ?) AS liked,
 		       EXISTS(SELECT * FROM Ban WHERE bannedId = PhotoAuthorInfo.authorId AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = PhotoAuthorInfo.authorId AND followerId = ?) AS following
+		       EXISTS(SELECT * FROM Follow WHERE followedId = PhotoAuthorInfo.authorId AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = PhotoAuthorInfo.authorId AND followedId = ?) AS followsYou
 		FROM PhotoAuthorInfo
 		WHERE PhotoAuthorInfo.authorId = ?
+			  AND ` + VisibleToCondition("PhotoAuthorInfo") + `
 		 	  -- Cursor pagination
 			  AND (publishDate, id) < (?, ?)
 		ORDER BY publishDate DESC, id DESC
@@ -86,7 +103,11 @@ func (db DbDao) ListUsersPhotoAfter(authorUuid uuid.UUID, searchAsUuid uuid.UUID
 		searchAsUuid.Bytes(),
 		searchAsUuid.Bytes(),
 		searchAsUuid.Bytes(),
+		searchAsUuid.Bytes(),
 		authorUuid.Bytes(),
+		searchAsUuid.Bytes(),
+		searchAsUuid.Bytes(),
+		searchAsUuid.Bytes(),
 		beforeDate,
 		afterPhotoId.Bytes(),
 		database.MaxPageItems,
@@ -99,6 +120,20 @@ func (db DbDao) ListUsersPhotoAfter(authorUuid uuid.UUID, searchAsUuid uuid.UUID
 	return ParsePhotoEntity(rows)
 }
 
+// VisibleToCondition is the SQL condition which tells if a photo in the given table
+// can be seen by a user, according to the audience it was published to.
+// Photos of a deactivated user can only be seen by the author.
+// It requires the ID of that user to be bound 3 times.
+func VisibleToCondition(table string) string {
+	return `(` + table + `.authorId = ?
+		OR (` + user.ActiveCondition(table+".authorId") + `
+			AND (` + table + `.visibility = '` + AudienceEveryone + `'
+				OR (` + table + `.visibility = '` + AudienceFollowers + `'
+					AND EXISTS(SELECT * FROM Follow WHERE followerId = ? AND followedId = ` + table + `.authorId))
+				OR (` + table + `.visibility = '` + AudienceCloseFriends + `'
+					AND EXISTS(SELECT * FROM CloseFriend WHERE ownerId = ` + table + `.authorId AND friendId = ?)))))`
+}
+
 func ParsePhotoEntity(rows database.StructRows) ([]EntityPhotoAuthorInfo, error) {
 	var (
 		photos []EntityPhotoAuthorInfo
@@ -120,3 +155,32 @@ func ParsePhotoEntity(rows database.StructRows) ([]EntityPhotoAuthorInfo, error)
 
 	return photos, nil
 }
+
+// ListAllUserPhotoIds lists the IDs of every photo posted by the user, without pagination
+func (db DbDao) ListAllUserPhotoIds(authorUuid uuid.UUID) ([]uuid.UUID, error) {
+	type photoId struct {
+		Id []byte `json:"id"`
+	}
+
+	rows, err := db.Db.QueryStructRows(photoId{}, "SELECT id FROM Photo WHERE authorId = ?", authorUuid.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		photoIds []uuid.UUID
+		entity   any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		row, ok := entity.(photoId)
+		if !ok {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+		photoIds = append(photoIds, uuid.FromBytesOrNil(row.Id))
+	}
+	if !errors.Is(err, database.ErrNoResult) {
+		return nil, err
+	}
+
+	return photoIds, nil
+}
@@ -11,20 +11,58 @@ import (
 )
 
 type Photo struct {
-	Id            string    `json:"id"`
-	Author        user.User `json:"author"`
-	PublishDate   time.Time `json:"publishDate"`
-	LikesCount    uint      `json:"likesCount"`
-	CommentsCount uint      `json:"commentsCount"`
-	Liked         bool      `json:"liked"`
-	ImageUrl      string    `json:"imageUrl"`
+	Id            string       `json:"id"`
+	Author        user.User    `json:"author"`
+	PublishDate   time.Time    `json:"publishDate"`
+	LikesCount    uint         `json:"likesCount"`
+	CommentsCount uint         `json:"commentsCount"`
+	Liked         bool         `json:"liked"`
+	ImageUrl      string       `json:"imageUrl"`
+	Images        []PhotoImage `json:"images"`
+	Audience      string       `json:"audience"`
 }
 
+// PhotoImage is a rendition of the photo, resized to a smaller width.
+// The full one has the same URL as Photo.ImageUrl.
+// Width and Height are unknown (zero) for photos uploaded before the renditions were introduced.
+type PhotoImage struct {
+	Size   string `json:"size"`
+	Url    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Sizes of the renditions generated for every photo
+const (
+	ImageSizeThumbnail = "thumbnail"
+	ImageSizeMedium    = "medium"
+	ImageSizeFull      = "full"
+)
+
+// Audiences a photo can be published to.
+// The author can always see their own photos.
+const (
+	AudienceEveryone     = "everyone"
+	AudienceFollowers    = "followers"
+	AudienceCloseFriends = "closeFriends"
+)
+
 func (photo *Photo) AddImageHost(r *http.Request, logger logrus.FieldLogger) {
 	// Check if the actual URL is relative to this host, or it's already an absolute URL
 	if strings.HasPrefix(photo.ImageUrl, "/") {
 		photo.ImageUrl = utils.GetUrlPrefix(r, logger) + photo.ImageUrl
 	}
+	for i := range photo.Images {
+		if strings.HasPrefix(photo.Images[i].Url, "/") {
+			photo.Images[i].Url = utils.GetUrlPrefix(r, logger) + photo.Images[i].Url
+		}
+	}
+
+	photo.Author.AddImageHost(r, logger)
+}
+
+type uploadParams struct {
+	Audience string `json:"audience" validate:"omitempty,oneof=everyone followers closeFriends"`
 }
 
 type IdParam struct {
@@ -1,6 +1,7 @@
 package photo
 
 import (
+	"encoding/json"
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
 	"github.com/simonesestito/wasaphoto/service/features/user"
@@ -14,12 +15,25 @@ type entityPhoto struct {
 	ImageUrl    string `json:"imageUrl"`
 	AuthorId    []byte `json:"authorId"`
 	PublishDate string `json:"publishDate"`
+	Visibility  string `json:"visibility"`
 }
 
 type EntityPhotoInfo struct {
 	entityPhoto
 	LikesCount    uint `json:"likesCount"`
 	CommentsCount uint `json:"commentsCount"`
+
+	// Images is the JSON array of the renditions, already in the DTO format
+	Images string `json:"images"`
+}
+
+// EntityPhotoImage is a stored rendition of a photo
+type EntityPhotoImage struct {
+	PhotoId []byte `json:"photoId"`
+	Size    string `json:"size"`
+	Url     string `json:"url"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
 }
 
 type entityPhotoInfoWithCustom struct {
@@ -36,6 +50,14 @@ func (photo EntityPhotoAuthorInfo) toDto() Photo {
 	publishDate, _ := time.Parse(timeprovider.UTCFormat, photo.PublishDate)
 	photo.ModelUserWithCustom.Id = photo.AuthorId
 
+	images := make([]PhotoImage, 0)
+	_ = json.Unmarshal([]byte(photo.Images), &images)
+	if len(images) == 0 {
+		// Photos uploaded before the renditions were introduced only have the full image,
+		// whose size has never been stored
+		images = append(images, PhotoImage{Size: ImageSizeFull, Url: photo.ImageUrl})
+	}
+
 	return Photo{
 		Id:            uuid.FromBytesOrNil(photo.entityPhoto.Id).String(),
 		Author:        photo.ModelUserWithCustom.ToDto(),
@@ -44,6 +66,8 @@ func (photo EntityPhotoAuthorInfo) toDto() Photo {
 		CommentsCount: photo.CommentsCount,
 		Liked:         photo.Liked > 0,
 		ImageUrl:      photo.ImageUrl,
+		Images:        images,
+		Audience:      photo.Visibility,
 	}
 }
 
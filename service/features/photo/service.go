@@ -6,6 +6,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/imaging"
 	"github.com/simonesestito/wasaphoto/service/storage"
 	"github.com/simonesestito/wasaphoto/service/timeprovider"
 	"github.com/simonesestito/wasaphoto/service/utils/cursor"
@@ -13,31 +14,45 @@ import (
 )
 
 type Service interface {
-	CreatePost(userId string, imageData []byte, logger logrus.FieldLogger) (Photo, error)
-	DeletePostAs(imageId string, userId string) error
+	CreatePost(userId string, imageData []byte, audience string, logger logrus.FieldLogger) (Photo, error)
+	DeletePostAs(imageId string, userId string, asModerator bool) error
 	GetPostAuthorById(imageId string) (string, error)
 	GetUsersPhotosPage(id string, searchAs string, cursor string) ([]Photo, *string, error)
 	GetPhotoByIdAs(photoId string, searchAs string) (*Photo, error)
+	DeleteAllFilesOf(userId string) error
+	ReadPhotoFile(photoId string) ([]byte, error)
+	RestorePost(photoId string, userId string, imageData []byte, audience string, publishDate string, logger logrus.FieldLogger) error
+}
+
+// imageSizes lists the renditions generated for every photo, from the smallest one.
+// The full one is as wide as the image processor allows.
+var imageSizes = []struct {
+	name  string
+	width int
+}{
+	{ImageSizeThumbnail, 160},
+	{ImageSizeMedium, 320},
+	{ImageSizeFull, 0},
 }
 
 type ServiceImpl struct {
 	Db             Dao
 	Storage        storage.Storage
-	ImageProcessor imageProcessor
+	ImageProcessor imaging.Processor
 	UserService    user.Service
 	BanService     user.BanService
 }
 
-func (service ServiceImpl) CreatePost(userId string, imageData []byte, logger logrus.FieldLogger) (Photo, error) {
+// CreatePost publishes a new photo, visible only to the given audience.
+// If no audience is given, everyone can see it.
+func (service ServiceImpl) CreatePost(userId string, imageData []byte, audience string, logger logrus.FieldLogger) (Photo, error) {
 	userUuid := uuid.FromStringOrNil(userId)
 	if userUuid == uuid.Nil {
 		return Photo{}, api.ErrWrongUUID
 	}
 
-	// Process image
-	imageData, err := service.ImageProcessor.compressPhotoToWebp(imageData, logger)
-	if err != nil {
-		return Photo{}, err
+	if audience == "" {
+		audience = AudienceEveryone
 	}
 
 	// Generate new UUID
@@ -46,29 +61,33 @@ func (service ServiceImpl) CreatePost(userId string, imageData []byte, logger lo
 		return Photo{}, err
 	}
 
-	// Save processed photo
-	photoPath := service.pathForPhotoFile(photoUuid)
-	savedFilePath, err := service.Storage.SaveFile(photoPath, imageData)
-	if err != nil {
-		return Photo{}, err
-	}
-
-	// Handle errors in inserting the image in the DB, preparing a rollback
+	// Handle errors in saving the files or inserting the image in the DB, preparing a rollback
 	isCommitted := false
 	defer func() {
 		if !isCommitted {
 			// Rollback!
-			_ = service.Storage.DeleteFile(photoPath)
+			_ = service.deletePhotoFiles(photoUuid)
 			_ = service.Db.DeletePhoto(photoUuid)
 		}
 	}()
 
-	// Create new photo struct
-	err = service.Db.NewPhotoPerUser(photoUuid, userUuid, savedFilePath)
+	savedImages, err := service.saveRenditions(photoUuid, imageData, logger)
 	if err != nil {
 		return Photo{}, err
 	}
 
+	// Create new photo struct, along with its renditions
+	fullImage := savedImages[len(savedImages)-1]
+	err = service.Db.NewPhotoPerUser(photoUuid, userUuid, fullImage.Url, audience)
+	if err != nil {
+		return Photo{}, err
+	}
+	for _, image := range savedImages {
+		if err := service.Db.InsertPhotoImage(image); err != nil {
+			return Photo{}, err
+		}
+	}
+
 	// Get just created photo
 	photo, err := service.Db.GetPhotoByIdAs(photoUuid, userUuid)
 	if err != nil {
@@ -82,19 +101,121 @@ func (service ServiceImpl) CreatePost(userId string, imageData []byte, logger lo
 	return photo.toDto(), nil
 }
 
-func (ServiceImpl) pathForPhotoFile(photoUuid uuid.UUID) string {
-	return "photos/" + photoUuid.String() + ".webp"
+// RestorePost publishes a photo from another instance, keeping its ID and publish date.
+// The image is processed again, like a new one, to generate every rendition.
+// If the ID is already taken, it returns api.ErrDuplicated
+func (service ServiceImpl) RestorePost(photoId string, userId string, imageData []byte, audience string, publishDate string, logger logrus.FieldLogger) error {
+	photoUuid := uuid.FromStringOrNil(photoId)
+	userUuid := uuid.FromStringOrNil(userId)
+	if photoUuid.IsNil() || userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	// Check it before saving anything, since the rollback would delete the files of the existing photo
+	if existing, err := service.Db.GetPhotoById(photoUuid); err != nil {
+		return err
+	} else if existing != nil {
+		return api.ErrDuplicated
+	}
+
+	// Handle errors in saving the files or inserting the image in the DB, preparing a rollback
+	isCommitted := false
+	defer func() {
+		if !isCommitted {
+			// Rollback!
+			_ = service.deletePhotoFiles(photoUuid)
+			_ = service.Db.DeletePhoto(photoUuid)
+		}
+	}()
+
+	savedImages, err := service.saveRenditions(photoUuid, imageData, logger)
+	if err != nil {
+		return err
+	}
+
+	fullImage := savedImages[len(savedImages)-1]
+	err = service.Db.RestorePhoto(photoUuid, userUuid, fullImage.Url, audience, publishDate)
+	if err != nil {
+		return err
+	}
+	for _, image := range savedImages {
+		if err := service.Db.InsertPhotoImage(image); err != nil {
+			return err
+		}
+	}
+
+	// Commit!
+	isCommitted = true
+	return nil
+}
+
+// saveRenditions processes the image, once for each rendition, and saves them in the storage.
+// A smaller one is useless if the image is not wider than it, so it's skipped.
+// The full one is always the last.
+func (service ServiceImpl) saveRenditions(photoUuid uuid.UUID, imageData []byte, logger logrus.FieldLogger) ([]EntityPhotoImage, error) {
+	widths := make([]int, len(imageSizes))
+	for i, size := range imageSizes {
+		widths[i] = size.width
+	}
+	images, err := service.ImageProcessor.CompressToWebp(imageData, widths, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var savedImages []EntityPhotoImage
+	for i, image := range images {
+		if i < len(images)-1 && image.Width >= images[i+1].Width {
+			continue
+		}
+
+		size := imageSizes[i].name
+		savedFilePath, err := service.Storage.SaveFile(service.pathForPhotoFile(photoUuid, size), image.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		savedImages = append(savedImages, EntityPhotoImage{
+			PhotoId: photoUuid.Bytes(),
+			Size:    size,
+			Url:     savedFilePath,
+			Width:   image.Width,
+			Height:  image.Height,
+		})
+	}
+
+	return savedImages, nil
+}
+
+// pathForPhotoFile returns where a rendition of the photo is stored.
+// The full one keeps the path used before renditions were introduced.
+func (ServiceImpl) pathForPhotoFile(photoUuid uuid.UUID, size string) string {
+	if size == ImageSizeFull {
+		return "photos/" + photoUuid.String() + ".webp"
+	}
+	return "photos/" + photoUuid.String() + "_" + size + ".webp"
 }
 
-func (service ServiceImpl) DeletePostAs(imageId string, userId string) error {
+// deletePhotoFiles deletes every rendition of the photo from the storage
+func (service ServiceImpl) deletePhotoFiles(photoUuid uuid.UUID) error {
+	for _, size := range imageSizes {
+		if err := service.Storage.DeleteFile(service.pathForPhotoFile(photoUuid, size.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePostAs deletes the photo, if posted by the given user.
+// Moderators can delete any photo.
+func (service ServiceImpl) DeletePostAs(imageId string, userId string, asModerator bool) error {
 	imageUuid := uuid.FromStringOrNil(imageId)
 	userUuid := uuid.FromStringOrNil(userId)
 	if imageUuid.IsNil() || userUuid.IsNil() {
 		return api.ErrWrongUUID
 	}
 
-	// Get photo
-	imageToDelete, err := service.Db.GetPhotoByIdAs(imageUuid, userUuid)
+	// Get photo, even if the user is not in its audience
+	imageToDelete, err := service.Db.GetPhotoById(imageUuid)
 	if err != nil {
 		return err
 	} else if imageToDelete == nil {
@@ -102,7 +223,7 @@ func (service ServiceImpl) DeletePostAs(imageId string, userId string) error {
 	}
 
 	// Check authorization
-	if !bytes.Equal(imageToDelete.AuthorId, userUuid.Bytes()) {
+	if !asModerator && !bytes.Equal(imageToDelete.AuthorId, userUuid.Bytes()) {
 		return api.ErrOthersData
 	}
 
@@ -112,8 +233,8 @@ func (service ServiceImpl) DeletePostAs(imageId string, userId string) error {
 		return err
 	}
 
-	// Delete photo file from storage
-	return service.Storage.DeleteFile(service.pathForPhotoFile(imageUuid))
+	// Delete photo files from storage
+	return service.deletePhotoFiles(imageUuid)
 }
 
 func (service ServiceImpl) GetPostAuthorById(imageId string) (string, error) {
@@ -156,6 +277,8 @@ func (service ServiceImpl) GetUsersPhotosPage(id string, searchAs string, pageCu
 		return nil, nil, err
 	case searchedUser == nil:
 		return nil, nil, api.ErrNotFound
+	case searchedUser.IsHiddenFrom(searchAs):
+		return nil, nil, api.ErrPrivateAccount
 	}
 
 	// Get photos
@@ -175,7 +298,7 @@ func (service ServiceImpl) GetPhotoByIdAs(photoId string, searchAs string) (*Pho
 		return nil, api.ErrWrongUUID
 	}
 
-	// Get the required photo
+	// Get the required photo, if the user is in its audience
 	dbPhoto, err := service.Db.GetPhotoByIdAs(photoUuid, searchAsUuid)
 	if err != nil {
 		return nil, err
@@ -193,6 +316,45 @@ func (service ServiceImpl) GetPhotoByIdAs(photoId string, searchAs string) (*Pho
 		return nil, api.ErrUserBanned
 	}
 
+	// Check author privacy
+	if photo.Author.IsHiddenFrom(searchAs) {
+		return nil, api.ErrPrivateAccount
+	}
+
 	// Success! Return photo
 	return &photo, nil
 }
+
+// DeleteAllFilesOf deletes the files of all the photos posted by the user from the storage,
+// leaving the database untouched.
+// It's used before deleting the user, which in turn deletes the photos from the database.
+func (service ServiceImpl) DeleteAllFilesOf(userId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	photoIds, err := service.Db.ListAllUserPhotoIds(userUuid)
+	if err != nil {
+		return err
+	}
+
+	for _, photoUuid := range photoIds {
+		if err := service.deletePhotoFiles(photoUuid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadPhotoFile returns the content of the stored photo file,
+// without checking who can see it.
+func (service ServiceImpl) ReadPhotoFile(photoId string) ([]byte, error) {
+	photoUuid := uuid.FromStringOrNil(photoId)
+	if photoUuid.IsNil() {
+		return nil, api.ErrWrongUUID
+	}
+
+	return service.Storage.ReadFile(service.pathForPhotoFile(photoUuid, ImageSizeFull))
+}
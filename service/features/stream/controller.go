@@ -18,6 +18,7 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodGet,
 			Path:    "/users/:userId/stream",
 			Handler: controller.getMyStream,
+			Scope:   route.ScopeRead,
 		},
 	}
 }
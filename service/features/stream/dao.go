@@ -19,10 +19,13 @@ func (db DbDao) GetMyFollowingsPhotosSortedByDate(userId uuid.UUID, afterId uuid
 		SELECT PhotoAuthorInfo.*,
 		       EXISTS(SELECT * FROM Likes WHERE Likes.photoId = PhotoAuthorInfo.id AND Likes.userId = ?) AS liked,
 		       EXISTS(SELECT * FROM Ban WHERE bannedId = PhotoAuthorInfo.authorId AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = PhotoAuthorInfo.authorId AND followerId = ?) AS following
+		       EXISTS(SELECT * FROM Follow WHERE followedId = PhotoAuthorInfo.authorId AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = PhotoAuthorInfo.authorId AND followedId = ?) AS followsYou
 		FROM PhotoAuthorInfo
 		LEFT JOIN Follow ON Follow.followedId = PhotoAuthorInfo.authorId
 		WHERE Follow.followerId = ?
+			  AND NOT EXISTS(SELECT * FROM Mute WHERE muterId = ? AND mutedId = PhotoAuthorInfo.authorId)
+			  AND ` + photo.VisibleToCondition("PhotoAuthorInfo") + `
 		 	  -- Cursor pagination
 			  AND (publishDate, id) < (?, ?)
 		ORDER BY publishDate DESC, id DESC
@@ -35,6 +38,11 @@ func (db DbDao) GetMyFollowingsPhotosSortedByDate(userId uuid.UUID, afterId uuid
 		userId.Bytes(),
 		userId.Bytes(),
 		userId.Bytes(),
+		userId.Bytes(),
+		userId.Bytes(),
+		userId.Bytes(),
+		userId.Bytes(),
+		userId.Bytes(),
 		beforeDate,
 		afterId.Bytes(),
 		database.MaxPageItems,
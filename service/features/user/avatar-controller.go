@@ -0,0 +1,81 @@
+package user
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"io"
+	"net/http"
+)
+
+type AvatarController struct {
+	Service AvatarService
+}
+
+func (controller AvatarController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/avatar",
+			Handler: controller.setAvatar,
+			Scope:   route.ScopeProfileWrite,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/avatar",
+			Handler: controller.deleteAvatar,
+			Scope:   route.ScopeProfileWrite,
+		},
+	}
+}
+
+func (controller AvatarController) setAvatar(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Read image file from body
+	imageData, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		context.Logger.WithError(err).Errorln("error receiving avatar")
+		http.Error(w, "unexpected error receiving avatar", http.StatusInternalServerError)
+		return
+	}
+
+	if len(imageData) == 0 {
+		http.Error(w, "missing avatar body", http.StatusBadRequest)
+		return
+	}
+
+	updatedUser, err := controller.Service.SetAvatar(args.UserId, imageData, context.Logger)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		updatedUser.AddImageHost(r, context.Logger)
+		api.SendJson(w, updatedUser, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller AvatarController) deleteAvatar(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.DeleteAvatar(args.UserId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
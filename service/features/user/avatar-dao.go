@@ -0,0 +1,14 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+func (dao DbDao) SetUserAvatar(userUuid uuid.UUID, avatarUrl string) (bool, error) {
+	rows, err := dao.Db.ExecRows("UPDATE User SET avatarUrl = ? WHERE id = ?", avatarUrl, userUuid.Bytes())
+	return rows > 0, err
+}
+
+func (dao DbDao) DeleteUserAvatar(userUuid uuid.UUID) error {
+	return dao.Db.Exec("UPDATE User SET avatarUrl = NULL WHERE id = ?", userUuid.Bytes())
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/imaging"
+	"github.com/simonesestito/wasaphoto/service/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type AvatarService interface {
+	SetAvatar(userId string, imageData []byte, logger logrus.FieldLogger) (User, error)
+	DeleteAvatar(userId string) error
+}
+
+type AvatarServiceImpl struct {
+	Db             Dao
+	Storage        storage.Storage
+	ImageProcessor imaging.Processor
+}
+
+// SetAvatar replaces the profile picture of the user.
+// The image is processed the same way as photos are.
+func (service AvatarServiceImpl) SetAvatar(userId string, imageData []byte, logger logrus.FieldLogger) (User, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return User{}, api.ErrWrongUUID
+	}
+
+	// Process image, at the largest allowed size
+	images, err := service.ImageProcessor.CompressToWebp(imageData, []int{0}, logger)
+	if err != nil {
+		return User{}, err
+	}
+	imageData = images[0].Data
+
+	// Only one avatar per user can be in the storage
+	avatarPath := service.pathForAvatarFile(userUuid)
+	if err := service.Storage.DeleteFile(avatarPath); err != nil {
+		return User{}, err
+	}
+
+	savedFilePath, err := service.Storage.SaveFile(avatarPath, imageData)
+	if err != nil {
+		// The previous avatar is gone anyway
+		_ = service.Db.DeleteUserAvatar(userUuid)
+		return User{}, err
+	}
+
+	found, err := service.Db.SetUserAvatar(userUuid, savedFilePath)
+	if err != nil {
+		return User{}, err
+	} else if !found {
+		_ = service.Storage.DeleteFile(avatarPath)
+		return User{}, api.ErrNotFound
+	}
+
+	updatedUser, err := service.Db.GetUserByIdAs(userUuid, userUuid)
+	if err != nil {
+		return User{}, err
+	}
+	return updatedUser.ToDto(), nil
+}
+
+// DeleteAvatar removes the profile picture of the user, if any.
+func (service AvatarServiceImpl) DeleteAvatar(userId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid.IsNil() {
+		return api.ErrWrongUUID
+	}
+
+	if err := service.Storage.DeleteFile(service.pathForAvatarFile(userUuid)); err != nil {
+		return err
+	}
+
+	return service.Db.DeleteUserAvatar(userUuid)
+}
+
+func (AvatarServiceImpl) pathForAvatarFile(userUuid uuid.UUID) string {
+	return "avatars/" + userUuid.String() + ".webp"
+}
@@ -13,19 +13,55 @@ type BanController struct {
 
 func (controller BanController) ListRoutes() []route.Route {
 	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/bannedPeople/",
+			Handler: controller.listBannedUsers,
+			Scope:   route.ScopeRead,
+		},
 		route.SecureRoute{
 			Method:  http.MethodPut,
 			Path:    "/users/:userId/bannedPeople/:bannedId",
 			Handler: controller.banUser,
+			Scope:   route.ScopeBansWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodDelete,
 			Path:    "/users/:userId/bannedPeople/:bannedId",
 			Handler: controller.unbanUser,
+			Scope:   route.ScopeBansWrite,
 		},
 	}
 }
 
+func (controller BanController) listBannedUsers(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &IdUserCursor{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	users, cursor, err := controller.Service.ListBannedUsers(args.UserId, args.PageCursorOrEmpty)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		// Add avatar URL prefix
+		for i := range users {
+			users[i].AddImageHost(r, context.Logger)
+		}
+
+		api.SendJson(w, api.PageResult[BannedUser]{
+			NextPageCursor: cursor,
+			PageData:       users,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
 func (controller BanController) banUser(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
 	args, bodyErr := api.ParseRequestVariables(params, &banParams{}, context.Logger)
 	if bodyErr != nil {
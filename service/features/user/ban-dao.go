@@ -1,7 +1,10 @@
 package user
 
 import (
+	"database/sql"
+	"errors"
 	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
 )
 
 func (dao DbDao) IsUserBannedBy(bannedId uuid.UUID, bannerId uuid.UUID) (bool, error) {
@@ -18,8 +21,8 @@ func (dao DbDao) IsUserBannedBy(bannedId uuid.UUID, bannerId uuid.UUID) (bool, e
 	return result.Banned > 0, nil
 }
 
-func (dao DbDao) BanUser(bannedId uuid.UUID, bannerId uuid.UUID) (bool, error) {
-	rows, err := dao.Db.ExecRows("INSERT INTO Ban (bannedId, bannerId) VALUES (?, ?)", bannedId.Bytes(), bannerId.Bytes())
+func (dao DbDao) BanUser(bannedId uuid.UUID, bannerId uuid.UUID, banDate string) (bool, error) {
+	rows, err := dao.Db.ExecRows("INSERT INTO Ban (bannedId, bannerId, banDate) VALUES (?, ?, ?)", bannedId.Bytes(), bannerId.Bytes(), banDate)
 	return rows > 0, err
 }
 
@@ -27,3 +30,52 @@ func (dao DbDao) UnbanUser(bannedUuid uuid.UUID, bannerUuid uuid.UUID) (bool, er
 	rows, err := dao.Db.ExecRows("DELETE FROM Ban WHERE bannedId = ? AND bannerId = ?", bannedUuid.Bytes(), bannerUuid.Bytes())
 	return rows > 0, err
 }
+
+func (dao DbDao) GetBannedUsersPage(bannerUuid uuid.UUID, afterBannedId uuid.UUID, afterUsername string) ([]ModelBannedUser, error) {
+	query := `
+		SELECT UserInfo.*,
+		       TRUE AS banned,
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou,
+		       COALESCE(Ban.banDate, '') AS banDate
+		FROM UserInfo
+		JOIN Ban ON UserInfo.id = Ban.bannedId
+		WHERE Ban.bannerId = ?
+		 	  -- Cursor pagination
+			  AND (username, id) > (?, ?)
+		ORDER BY username, id
+		LIMIT ?`
+
+	rows, err := dao.Db.QueryStructRows(
+		ModelBannedUser{},
+		query,
+		bannerUuid.Bytes(),
+		bannerUuid.Bytes(),
+		bannerUuid.Bytes(),
+		afterUsername,
+		afterBannedId.Bytes(),
+		database.MaxPageItems,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		users  []ModelBannedUser
+		entity any
+	)
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		bannedUser, ok := entity.(ModelBannedUser)
+		if ok {
+			users = append(users, bannedUser)
+		} else {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return users, nil
+}
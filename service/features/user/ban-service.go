@@ -5,12 +5,15 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/cursor"
 )
 
 type BanService interface {
 	BanUser(bannedId string, bannerId string) error
 	UnbanUser(bannedId string, bannerId string) error
 	IsUserBanned(bannedId string, bannerId string) (bool, error)
+	ListBannedUsers(bannerId string, pageCursor string) ([]BannedUser, *string, error)
 	AddBanListener(tag string, listener banListener)
 }
 
@@ -18,6 +21,7 @@ type banListener func(bannedId string, bannerId string) error
 
 type BanServiceImpl struct {
 	Db        Dao
+	Time      timeprovider.TimeProvider
 	listeners map[string]banListener
 }
 
@@ -32,7 +36,7 @@ func (service *BanServiceImpl) BanUser(bannedId string, bannerId string) error {
 		return api.ErrSelfOperation
 	}
 
-	newBan, err := service.Db.BanUser(bannedUuid, bannerUuid)
+	newBan, err := service.Db.BanUser(bannedUuid, bannerUuid, service.Time.UTCString())
 	if errors.Is(err, database.ErrForeignKey) {
 		return api.ErrNotFound
 	} else if err != nil {
@@ -76,6 +80,28 @@ func (service *BanServiceImpl) IsUserBanned(bannedId string, bannerId string) (b
 	return service.Db.IsUserBannedBy(bannedUuid, bannerUuid)
 }
 
+func (service *BanServiceImpl) ListBannedUsers(bannerId string, pageCursor string) ([]BannedUser, *string, error) {
+	bannerUuid := uuid.FromStringOrNil(bannerId)
+	if bannerUuid == uuid.Nil {
+		return nil, nil, api.ErrWrongUUID
+	}
+
+	// Parse cursor
+	afterBannedId, afterUsername, err := cursor.ParseStringIdCursor(pageCursor)
+	if err != nil {
+		return nil, nil, api.ErrWrongCursor
+	}
+
+	dbUsers, err := service.Db.GetBannedUsersPage(bannerUuid, afterBannedId, afterUsername)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to DTO
+	users, nextCursor := BannedUsersListToPage(dbUsers)
+	return users, nextCursor, nil
+}
+
 func (service *BanServiceImpl) AddBanListener(tag string, listener banListener) {
 	if service.listeners == nil {
 		service.listeners = make(map[string]banListener)
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"net/http"
+)
+
+type CloseFriendController struct {
+	Service CloseFriendService
+}
+
+func (controller CloseFriendController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/closeFriends/",
+			Handler: controller.listCloseFriends,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/closeFriends/:friendId",
+			Handler: controller.addCloseFriend,
+			Scope:   route.ScopeFollowsWrite,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/closeFriends/:friendId",
+			Handler: controller.removeCloseFriend,
+			Scope:   route.ScopeFollowsWrite,
+		},
+	}
+}
+
+func (controller CloseFriendController) listCloseFriends(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &IdUserCursor{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	users, cursor, err := controller.Service.ListCloseFriends(args.UserId, args.PageCursorOrEmpty)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		// Add avatar URL prefix
+		for i := range users {
+			users[i].AddImageHost(r, context.Logger)
+		}
+
+		api.SendJson(w, api.PageResult[User]{
+			NextPageCursor: cursor,
+			PageData:       users,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller CloseFriendController) addCloseFriend(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &closeFriendParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.AddCloseFriend(args.FriendId, args.UserId)
+	result := closeFriendResult{
+		FriendId: args.FriendId,
+		OwnerId:  context.UserId,
+	}
+	api.HandlePutResult(result, err, w, context.Logger)
+}
+
+func (controller CloseFriendController) removeCloseFriend(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &closeFriendParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.RemoveCloseFriend(args.FriendId, args.UserId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
@@ -0,0 +1,50 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+func (dao DbDao) AddCloseFriend(friendId uuid.UUID, ownerId uuid.UUID) (bool, error) {
+	rows, err := dao.Db.ExecRows("INSERT INTO CloseFriend (friendId, ownerId) VALUES (?, ?)", friendId.Bytes(), ownerId.Bytes())
+	return rows > 0, err
+}
+
+func (dao DbDao) RemoveCloseFriend(friendUuid uuid.UUID, ownerUuid uuid.UUID) (bool, error) {
+	rows, err := dao.Db.ExecRows("DELETE FROM CloseFriend WHERE friendId = ? AND ownerId = ?", friendUuid.Bytes(), ownerUuid.Bytes())
+	return rows > 0, err
+}
+
+func (dao DbDao) GetCloseFriendsPage(ownerUuid uuid.UUID, afterFriendId uuid.UUID, afterUsername string) ([]ModelUserWithCustom, error) {
+	query := `
+		SELECT UserInfo.*,
+		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
+		FROM UserInfo
+		JOIN CloseFriend ON UserInfo.id = CloseFriend.friendId
+		WHERE CloseFriend.ownerId = ?
+		 	  -- Cursor pagination
+			  AND (username, id) > (?, ?)
+			  AND ` + ActiveCondition("UserInfo.id") + `
+		ORDER BY username, id
+		LIMIT ?`
+
+	rows, err := dao.Db.QueryStructRows(
+		ModelUserWithCustom{},
+		query,
+		ownerUuid.Bytes(),
+		ownerUuid.Bytes(),
+		ownerUuid.Bytes(),
+		ownerUuid.Bytes(),
+		afterUsername,
+		afterFriendId.Bytes(),
+		database.MaxPageItems,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseUserEntities(rows)
+}
@@ -0,0 +1,79 @@
+package user
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/utils/cursor"
+)
+
+// CloseFriendService handles the close friends list of a user,
+// who can see the photos published only to close friends.
+type CloseFriendService interface {
+	AddCloseFriend(friendId string, ownerId string) error
+	RemoveCloseFriend(friendId string, ownerId string) error
+	ListCloseFriends(ownerId string, pageCursor string) ([]User, *string, error)
+}
+
+type CloseFriendServiceImpl struct {
+	Db Dao
+}
+
+func (service CloseFriendServiceImpl) AddCloseFriend(friendId string, ownerId string) error {
+	friendUuid := uuid.FromStringOrNil(friendId)
+	ownerUuid := uuid.FromStringOrNil(ownerId)
+	if friendUuid == uuid.Nil || ownerUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	if friendUuid == ownerUuid {
+		return api.ErrSelfOperation
+	}
+
+	newFriend, err := service.Db.AddCloseFriend(friendUuid, ownerUuid)
+	if errors.Is(err, database.ErrForeignKey) {
+		return api.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if !newFriend {
+		return api.ErrDuplicated
+	}
+
+	return nil
+}
+
+func (service CloseFriendServiceImpl) RemoveCloseFriend(friendId string, ownerId string) error {
+	friendUuid := uuid.FromStringOrNil(friendId)
+	ownerUuid := uuid.FromStringOrNil(ownerId)
+	if friendUuid == uuid.Nil || ownerUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	_, err := service.Db.RemoveCloseFriend(friendUuid, ownerUuid)
+	return err
+}
+
+func (service CloseFriendServiceImpl) ListCloseFriends(ownerId string, pageCursor string) ([]User, *string, error) {
+	ownerUuid := uuid.FromStringOrNil(ownerId)
+	if ownerUuid == uuid.Nil {
+		return nil, nil, api.ErrWrongUUID
+	}
+
+	// Parse cursor
+	afterFriendId, afterUsername, err := cursor.ParseStringIdCursor(pageCursor)
+	if err != nil {
+		return nil, nil, api.ErrWrongCursor
+	}
+
+	dbUsers, err := service.Db.GetCloseFriendsPage(ownerUuid, afterFriendId, afterUsername)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to DTO
+	users, nextCursor := DbUsersListToPage(dbUsers)
+	return users, nextCursor, nil
+}
@@ -5,19 +5,38 @@ import (
 	"errors"
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"strings"
+	"unicode/utf8"
 )
 
 type Dao interface {
 	GetUserById(id uuid.UUID) (*ModelUser, error)
-	InsertOrGetUserId(user ModelUser) (id uuid.UUID, isNew bool, err error)
 	IsUserBannedBy(bannedId uuid.UUID, bannerId uuid.UUID) (bool, error)
 	GetUserByIdAs(id uuid.UUID, searchAsId uuid.UUID) (*ModelUserWithCustom, error)
-	BanUser(bannedId uuid.UUID, bannerId uuid.UUID) (bool, error)
+	BanUser(bannedId uuid.UUID, bannerId uuid.UUID, banDate string) (bool, error)
 	UnbanUser(bannedUuid uuid.UUID, bannerUuid uuid.UUID) (bool, error)
+	GetBannedUsersPage(bannerUuid uuid.UUID, afterBannedId uuid.UUID, afterUsername string) ([]ModelBannedUser, error)
 	EditUser(userUuid uuid.UUID, user ModelUser) error
-	EditUsername(userUuid uuid.UUID, username string) error
+	EditUserWithUsername(userUuid uuid.UUID, user ModelUser, change UsernameChangeLimits) (string, error)
+	ChangeUsername(userUuid uuid.UUID, username string, change UsernameChangeLimits) (string, error)
 	GetUserByUsernameAs(username string, searchAsId uuid.UUID) (*ModelUserWithCustom, error)
-	ListUsersByUsernameAs(username string, searchAsId uuid.UUID, afterUsername string, afterId uuid.UUID) ([]ModelUserWithCustom, error)
+	SearchUsersAs(text string, searchAsId uuid.UUID, afterScore float64, afterId uuid.UUID) ([]ModelUserWithScore, error)
+	GetUserRole(userUuid uuid.UUID) (string, error)
+	SetUserRole(userUuid uuid.UUID, role string) (bool, error)
+	SetUserRoleIfUnassigned(userUuid uuid.UUID, role string) (bool, error)
+	SetUserAvatar(userUuid uuid.UUID, avatarUrl string) (bool, error)
+	DeleteUserAvatar(userUuid uuid.UUID) error
+	IsUserMutedBy(mutedId uuid.UUID, muterId uuid.UUID) (bool, error)
+	MuteUser(mutedId uuid.UUID, muterId uuid.UUID) (bool, error)
+	UnmuteUser(mutedUuid uuid.UUID, muterUuid uuid.UUID) (bool, error)
+	GetMutedUsersPage(muterUuid uuid.UUID, afterMutedId uuid.UUID, afterUsername string) ([]ModelUserWithCustom, error)
+	GetRelationship(userUuid uuid.UUID, otherUuid uuid.UUID) (*ModelRelationship, error)
+	AddCloseFriend(friendId uuid.UUID, ownerId uuid.UUID) (bool, error)
+	RemoveCloseFriend(friendUuid uuid.UUID, ownerUuid uuid.UUID) (bool, error)
+	GetCloseFriendsPage(ownerUuid uuid.UUID, afterFriendId uuid.UUID, afterUsername string) ([]ModelUserWithCustom, error)
+	GetLastUsernameChangeDate(userUuid uuid.UUID) (string, error)
+	IsUsernameReservedToOthers(username string, userUuid uuid.UUID, now string) (bool, error)
+	GetUserIdByReservedUsername(username string, now string) (uuid.UUID, error)
 }
 
 type DbDao struct {
@@ -26,7 +45,7 @@ type DbDao struct {
 
 func (dao DbDao) GetUserById(id uuid.UUID) (*ModelUser, error) {
 	user := &ModelUser{}
-	err := dao.Db.QueryStructRow(user, "SELECT * FROM User WHERE id = ?", id.Bytes())
+	err := dao.Db.QueryStructRow(user, "SELECT id, name, surname, username, bio, website, pronouns FROM User WHERE id = ?", id.Bytes())
 	switch {
 	case errors.Is(err, database.ErrNoResult):
 		return nil, nil
@@ -37,60 +56,16 @@ func (dao DbDao) GetUserById(id uuid.UUID) (*ModelUser, error) {
 	}
 }
 
-// InsertOrGetUserId tries to insert the new given user,
-// or returns the ID of the existing one in case of conflicts (e.g.: on username).
-//
-// Since concurrency issues may happen, a transaction is used.
-// Boolean return value indicates whether the user is new
-func (dao DbDao) InsertOrGetUserId(user ModelUser) (uuid.UUID, bool, error) {
-	tx, err := dao.Db.BeginTx()
-	if err != nil {
-		return uuid.Nil, false, err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	// Get user if exists
-	existingUserId := make([]byte, 16)
-	result, err := tx.Query("SELECT id FROM User WHERE username = ?", user.Username)
-	if err != nil && result != nil {
-		defer result.Close()
-	}
-
-	if !errors.Is(err, sql.ErrNoRows) && err != nil {
-		// Unexpected error
-		return uuid.Nil, false, err
-	}
-
-	if errors.Is(err, sql.ErrNoRows) || !result.Next() {
-		// Username not found, create it!
-		_, err = tx.Exec("INSERT INTO User (id, name, surname, username) VALUES (?, ?, ?, ?)", user.Id, user.Name, user.Surname, user.Username)
-		if err != nil {
-			return uuid.Nil, true, err
-		}
-
-		return uuid.FromBytesOrNil(user.Id), true, tx.Commit()
-	} else {
-		// User found!
-		if err := result.Scan(&existingUserId); err != nil {
-			return uuid.Nil, false, err
-		}
-
-		userUuid, err := uuid.FromBytes(existingUserId)
-		return userUuid, false, err
-	}
-}
-
 // GetUserByIdAs also adds personal fields such as "banned" which are
 // relative to the actual user looking for this data
 func (dao DbDao) GetUserByIdAs(id uuid.UUID, searchAsId uuid.UUID) (*ModelUserWithCustom, error) {
 	user := &ModelUserWithCustom{}
 	query := "SELECT UserInfo.*, " +
 		"EXISTS(SELECT * FROM Ban WHERE bannedId = ? AND bannerId = ?) AS banned, " +
-		"EXISTS(SELECT * FROM Follow WHERE followedId = ? AND followerId = ?) AS following " +
-		"FROM UserInfo WHERE id = ?"
-	err := dao.Db.QueryStructRow(user, query, id.Bytes(), searchAsId.Bytes(), id.Bytes(), searchAsId.Bytes(), id.Bytes())
+		"EXISTS(SELECT * FROM Follow WHERE followedId = ? AND followerId = ?) AS following, " +
+		"EXISTS(SELECT * FROM Follow WHERE followerId = ? AND followedId = ?) AS followsYou " +
+		"FROM UserInfo WHERE id = ? AND (id = ? OR " + ActiveCondition("UserInfo.id") + ")"
+	err := dao.Db.QueryStructRow(user, query, id.Bytes(), searchAsId.Bytes(), id.Bytes(), searchAsId.Bytes(), id.Bytes(), searchAsId.Bytes(), id.Bytes(), searchAsId.Bytes())
 	switch {
 	case errors.Is(err, database.ErrNoResult):
 		return nil, nil
@@ -102,22 +77,19 @@ func (dao DbDao) GetUserByIdAs(id uuid.UUID, searchAsId uuid.UUID) (*ModelUserWi
 }
 
 func (dao DbDao) EditUser(userUuid uuid.UUID, user ModelUser) error {
-	query := "UPDATE User SET name = ?, surname = ?, username = ? WHERE id = ?"
-	return dao.Db.Exec(query, user.Name, user.Surname, user.Username, userUuid.Bytes())
-}
-
-func (dao DbDao) EditUsername(userUuid uuid.UUID, username string) error {
-	query := "UPDATE User SET username = ? WHERE id = ?"
-	return dao.Db.Exec(query, username, userUuid.Bytes())
+	query := "UPDATE User SET name = ?, surname = ?, username = ?, bio = ?, website = ?, pronouns = ? WHERE id = ?"
+	return dao.Db.Exec(query, user.Name, user.Surname, user.Username, user.Bio, user.Website, user.Pronouns, userUuid.Bytes())
 }
 
 func (dao DbDao) GetUserByUsernameAs(username string, searchAsId uuid.UUID) (*ModelUserWithCustom, error) {
 	query := `
 		SELECT UserInfo.*,
 		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
 		FROM UserInfo
-		WHERE UserInfo.username = ?`
+		WHERE UserInfo.username = ?
+		      AND (UserInfo.id = ? OR ` + ActiveCondition("UserInfo.id") + `)`
 
 	row := &ModelUserWithCustom{}
 	err := dao.Db.QueryStructRow(
@@ -125,7 +97,9 @@ func (dao DbDao) GetUserByUsernameAs(username string, searchAsId uuid.UUID) (*Mo
 		query,
 		searchAsId.Bytes(),
 		searchAsId.Bytes(),
+		searchAsId.Bytes(),
 		username,
+		searchAsId.Bytes(),
 	)
 
 	if errors.Is(err, database.ErrNoResult) {
@@ -137,36 +111,122 @@ func (dao DbDao) GetUserByUsernameAs(username string, searchAsId uuid.UUID) (*Mo
 	}
 }
 
-func (dao DbDao) ListUsersByUsernameAs(username string, searchAsId uuid.UUID, afterUsername string, afterId uuid.UUID) ([]ModelUserWithCustom, error) {
+// SearchUsersAs performs a full-text search on username, name and surname,
+// sorting the results by relevance for the user performing the search.
+//
+// The relevance of a match is boosted for an exact or prefix username match,
+// if the searching user already follows the found one, and by the followers count.
+func (dao DbDao) SearchUsersAs(text string, searchAsId uuid.UUID, afterScore float64, afterId uuid.UUID) ([]ModelUserWithScore, error) {
+	text = strings.TrimSpace(text)
+
+	// Find the matching rows, with their text relevance.
+	// The trigram tokenizer can't match less than 3 characters,
+	// so a (slower) full scan is needed for very short texts,
+	// or if the full-text index is not available at all.
+	matchQuery := `
+		SELECT id, -bm25(UserSearch, 0.0, 10.0, 5.0, 5.0) AS relevance
+		FROM UserSearch
+		WHERE UserSearch MATCH ?`
+	matchArgs := []any{ftsMatchExpression(text)}
+	if matchArgs[0] == "" || !dao.Db.HasFullTextSearch() {
+		matchQuery = `
+		SELECT id, 0.0 AS relevance
+		FROM User
+		WHERE instr(lower(username), lower(?)) > 0
+		   OR instr(lower(name), lower(?)) > 0
+		   OR instr(lower(surname), lower(?)) > 0`
+		matchArgs = []any{text, text, text}
+	}
+
 	query := `
-		SELECT UserInfo.*,
-		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
-		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following
-		FROM UserInfo
-		WHERE username LIKE ?
-		 	  -- Cursor pagination
-			  AND (username, id) > (?, ?)
-			  AND NOT EXISTS(SELECT * FROM Ban WHERE Ban.bannerId = UserInfo.id AND Ban.bannedId = ?)
-		ORDER BY username, id
+		WITH Matching AS (` + matchQuery + `
+		), Ranked AS (
+			SELECT UserInfo.*,
+			       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
+			       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+			       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou,
+			       Matching.relevance
+			           -- Exact and prefix username match
+			           + 10.0 * (lower(UserInfo.username) = lower(?))
+			           + 2.0 * (instr(lower(UserInfo.username), lower(?)) = 1)
+			           -- Already followed
+			           + 3.0 * EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?)
+			           -- Popularity, with diminishing returns
+			           + 2.0 * UserInfo.followersCount / (UserInfo.followersCount + 10.0) AS score
+			FROM Matching
+			JOIN UserInfo ON UserInfo.id = Matching.id
+			WHERE NOT EXISTS(SELECT * FROM Ban WHERE Ban.bannerId = UserInfo.id AND Ban.bannedId = ?)
+			  AND ` + ActiveCondition("UserInfo.id") + `
+		)
+		SELECT *
+		FROM Ranked
+		-- Cursor pagination
+		WHERE score < ? OR (score = ? AND id > ?)
+		ORDER BY score DESC, id
 		LIMIT ?`
 
-	rows, err := dao.Db.QueryStructRows(
-		ModelUserWithCustom{},
-		query,
+	args := append(matchArgs,
 		searchAsId.Bytes(),
 		searchAsId.Bytes(),
-		"%"+username+"%",
-		afterUsername,
-		afterId.Bytes(),
 		searchAsId.Bytes(),
+		text,
+		text,
+		searchAsId.Bytes(),
+		searchAsId.Bytes(),
+		afterScore,
+		afterScore,
+		afterId.Bytes(),
 		database.MaxPageItems,
 	)
 
+	rows, err := dao.Db.QueryStructRows(ModelUserWithScore{}, query, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return ParseUserEntities(rows)
+	return ParseScoredUserEntities(rows)
+}
+
+// ActiveCondition is the SQL condition which tells if the user with the ID in the given column
+// hasn't deactivated their account, so that it can be shown to the others.
+func ActiveCondition(idColumn string) string {
+	return `NOT EXISTS(SELECT * FROM User WHERE User.id = ` + idColumn + ` AND User.deactivated)`
+}
+
+// ftsMatchExpression builds the FTS5 query to find all the words in the text,
+// each one quoted so that it can't be interpreted as a query operator.
+// Words shorter than 3 characters are ignored, since the trigram tokenizer can't match them:
+// if no word is left, it returns an empty string.
+func ftsMatchExpression(text string) string {
+	var phrases []string
+	for _, word := range strings.Fields(text) {
+		if utf8.RuneCountInString(word) >= 3 {
+			phrases = append(phrases, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`)
+		}
+	}
+	return strings.Join(phrases, " ")
+}
+
+func ParseScoredUserEntities(rows database.StructRows) ([]ModelUserWithScore, error) {
+	var (
+		users  []ModelUserWithScore
+		entity any
+		err    error
+	)
+
+	for entity, err = rows.Next(); err == nil; entity, err = rows.Next() {
+		newUser, ok := entity.(ModelUserWithScore)
+		if ok {
+			users = append(users, newUser)
+		} else {
+			return nil, errors.New("invalid cast from db map to application entity")
+		}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return users, nil
 }
 
 func ParseUserEntities(rows database.StructRows) ([]ModelUserWithCustom, error) {
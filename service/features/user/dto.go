@@ -1,9 +1,18 @@
 package user
 
-import "github.com/simonesestito/wasaphoto/service/api"
+import (
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/utils"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
 
 type searchParams struct {
-	usernameGetParams
+	// Username is the text to search in usernames, names and surnames,
+	// or the whole username if ExactMatch is set.
+	Username string `json:"username" validate:"required,max=256,singleline"`
 	api.PaginationInfo
 	ExactMatch bool `json:"exactMatch"`
 }
@@ -17,22 +26,58 @@ type banParams struct {
 	BannedId string `json:"bannedId" validate:"required,uuid"`
 }
 
+type muteParams struct {
+	IdParams
+	MutedId string `json:"mutedId" validate:"required,uuid"`
+}
+
+type closeFriendParams struct {
+	IdParams
+	FriendId string `json:"friendId" validate:"required,uuid"`
+}
+
+type relationshipParams struct {
+	IdParams
+	OtherId string `json:"otherId" validate:"required,uuid"`
+}
+
 type newUser struct {
 	Name     string `json:"name" validate:"required,min=2,max=256,singleline"`
 	Surname  string `json:"surname" validate:"max=256,singleline"`
 	Username string `json:"username" validate:"required,username"`
+	Bio      string `json:"bio" validate:"max=500"`
+	Website  string `json:"website" validate:"omitempty,max=256,weburl"`
+	Pronouns string `json:"pronouns" validate:"max=32,singleline"`
 }
 
 type User struct {
-	Id              string `json:"id"`
-	FollowersCount  uint   `json:"followersCount"`
-	FollowingsCount uint   `json:"followingsCount"`
-	PostsCount      uint   `json:"postsCount"`
-	Banned          bool   `json:"banned"`
-	Following       bool   `json:"following"`
+	Id              string  `json:"id"`
+	FollowersCount  uint    `json:"followersCount"`
+	FollowingsCount uint    `json:"followingsCount"`
+	PostsCount      uint    `json:"postsCount"`
+	Banned          bool    `json:"banned"`
+	Following       bool    `json:"following"`
+	FollowsYou      bool    `json:"followsYou"`
+	AvatarUrl       *string `json:"avatarUrl"`
+	Private         bool    `json:"private"`
 	newUser
 }
 
+// IsHiddenFrom tells if the photos, the followers and the followings of this user
+// cannot be seen by the given user, since the account is private and they are not following it.
+// The user must have been fetched as the given one, so that Following refers to them.
+func (user User) IsHiddenFrom(userId string) bool {
+	return user.Private && !user.Following && user.Id != userId
+}
+
+func (user *User) AddImageHost(r *http.Request, logger logrus.FieldLogger) {
+	// Check if the actual URL is relative to this host, or it's already an absolute URL
+	if user.AvatarUrl != nil && strings.HasPrefix(*user.AvatarUrl, "/") {
+		avatarUrl := utils.GetUrlPrefix(r, logger) + *user.AvatarUrl
+		user.AvatarUrl = &avatarUrl
+	}
+}
+
 type IdParams struct {
 	UserId string `json:"userId" validate:"required,uuid"`
 }
@@ -42,7 +87,44 @@ type banResult struct {
 	BannerId string `json:"bannerId"`
 }
 
+// BannedUser is a user banned by the one performing the request.
+// BanDate is nil for the bans made before their date was recorded.
+type BannedUser struct {
+	User
+	BanDate *time.Time `json:"banDate"`
+}
+
+type muteResult struct {
+	MutedId string `json:"mutedId"`
+	MuterId string `json:"muterId"`
+}
+
+type closeFriendResult struct {
+	FriendId string `json:"friendId"`
+	OwnerId  string `json:"ownerId"`
+}
+
+// Relationship describes how the user UserId is related to the user OtherId.
+// Requested is a pending follow request from UserId to OtherId, RequestedBy the other way around.
+// CloseFriend tells if OtherId is in the close friends list of UserId.
+type Relationship struct {
+	UserId      string `json:"userId"`
+	OtherId     string `json:"otherId"`
+	Following   bool   `json:"following"`
+	FollowedBy  bool   `json:"followedBy"`
+	Banned      bool   `json:"banned"`
+	BannedBy    bool   `json:"bannedBy"`
+	Muted       bool   `json:"muted"`
+	Requested   bool   `json:"requested"`
+	RequestedBy bool   `json:"requestedBy"`
+	CloseFriend bool   `json:"closeFriend"`
+}
+
 type IdUserCursor struct {
 	api.PaginationInfo
 	IdParams
 }
+
+type userRole struct {
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
 	"github.com/simonesestito/wasaphoto/service/utils/cursor"
 )
 
@@ -12,25 +13,87 @@ type ModelUser struct {
 	Name     string `json:"name"`
 	Surname  string `json:"surname"`
 	Username string `json:"username"`
+	Bio      string `json:"bio"`
+	Website  string `json:"website"`
+	Pronouns string `json:"pronouns"`
 }
 
 // ModelUserInfo represents the actual database entity UserInfo (Data Layer in our architecture)
 type ModelUserInfo struct {
 	ModelUser
-	FollowersCount  uint `json:"followersCount"`
-	FollowingsCount uint `json:"followingsCount"`
-	PostsCount      uint `json:"photosCount"`
+	AvatarUrl       string `json:"avatarUrl"`
+	Private         int64  `json:"private"`
+	FollowersCount  uint   `json:"followersCount"`
+	FollowingsCount uint   `json:"followingsCount"`
+	PostsCount      uint   `json:"photosCount"`
 }
 
 // ModelUserWithCustom is ModelUserInfo with all fields which
 // depend on the actual user performing the query.
 type ModelUserWithCustom struct {
 	ModelUserInfo
-	Banned    int64 `json:"banned"`
-	Following int64 `json:"following"`
+	Banned     int64 `json:"banned"`
+	Following  int64 `json:"following"`
+	FollowsYou int64 `json:"followsYou"`
+}
+
+// ModelUserWithScore is a user found by a search or a suggestion,
+// with the score used to sort the results.
+type ModelUserWithScore struct {
+	ModelUserWithCustom
+	Score float64 `json:"score"`
+}
+
+// ModelBannedUser is a user banned by the one performing the query, with the date of the ban
+type ModelBannedUser struct {
+	ModelUserWithCustom
+	BanDate string `json:"banDate"`
+}
+
+// UsernameChangeLimits are the dates the DAO needs to check and record a username change
+type UsernameChangeLimits struct {
+	Now string
+
+	// LastChangeBefore is the latest date the previous change can be done at
+	LastChangeBefore string
+
+	// ReservedUntil is when the released username stops being reserved
+	ReservedUntil string
+}
+
+// ModelRelationship holds every relationship between two users
+type ModelRelationship struct {
+	Following   int64 `json:"following"`
+	FollowedBy  int64 `json:"followedBy"`
+	Banned      int64 `json:"banned"`
+	BannedBy    int64 `json:"bannedBy"`
+	Muted       int64 `json:"muted"`
+	Requested   int64 `json:"requested"`
+	RequestedBy int64 `json:"requestedBy"`
+	CloseFriend int64 `json:"closeFriend"`
+}
+
+func (relationship ModelRelationship) ToDto(userId string, otherId string) Relationship {
+	return Relationship{
+		UserId:      userId,
+		OtherId:     otherId,
+		Following:   relationship.Following > 0,
+		FollowedBy:  relationship.FollowedBy > 0,
+		Banned:      relationship.Banned > 0,
+		BannedBy:    relationship.BannedBy > 0,
+		Muted:       relationship.Muted > 0,
+		Requested:   relationship.Requested > 0,
+		RequestedBy: relationship.RequestedBy > 0,
+		CloseFriend: relationship.CloseFriend > 0,
+	}
 }
 
 func (user ModelUserWithCustom) ToDto() User {
+	var avatarUrl *string
+	if user.AvatarUrl != "" {
+		avatarUrl = &user.AvatarUrl
+	}
+
 	return User{
 		Id:              uuid.FromBytesOrNil(user.Id).String(),
 		FollowersCount:  user.FollowersCount,
@@ -38,10 +101,16 @@ func (user ModelUserWithCustom) ToDto() User {
 		PostsCount:      user.PostsCount,
 		Banned:          user.Banned > 0,
 		Following:       user.Following > 0,
+		FollowsYou:      user.FollowsYou > 0,
+		Private:         user.Private > 0,
+		AvatarUrl:       avatarUrl,
 		newUser: newUser{
 			Name:     user.Name,
 			Surname:  user.Surname,
 			Username: user.Username,
+			Bio:      user.Bio,
+			Website:  user.Website,
+			Pronouns: user.Pronouns,
 		},
 	}
 }
@@ -63,3 +132,44 @@ func DbUsersListToPage(dbUsers []ModelUserWithCustom) (users []User, pageCursor
 
 	return
 }
+
+// ScoredUsersListToPage converts the users sorted by score to DTOs, along with the next page cursor
+func ScoredUsersListToPage(results []ModelUserWithScore) (users []User, pageCursor *string) {
+	users = make([]User, len(results))
+	for i, result := range results {
+		users[i] = result.ToDto()
+	}
+
+	// Calculate next cursor
+	if len(results) == database.MaxPageItems {
+		lastResult := results[len(results)-1]
+		nextCursor := cursor.CreateScoreIdCursor(lastResult.Id, lastResult.Score)
+		pageCursor = &nextCursor
+	} else {
+		pageCursor = nil
+	}
+
+	return
+}
+
+// BannedUsersListToPage converts the banned users to DTOs, along with the next page cursor
+func BannedUsersListToPage(dbUsers []ModelBannedUser) (users []BannedUser, pageCursor *string) {
+	users = make([]BannedUser, len(dbUsers))
+	for i, dbUser := range dbUsers {
+		users[i] = BannedUser{User: dbUser.ToDto()}
+		if banDate, err := timeprovider.UTCStringToDate(dbUser.BanDate); err == nil {
+			users[i].BanDate = &banDate
+		}
+	}
+
+	// Calculate next cursor
+	if len(dbUsers) == database.MaxPageItems {
+		lastUser := dbUsers[len(dbUsers)-1]
+		nextCursor := cursor.CreateStringIdCursor(lastUser.Id, lastUser.Username)
+		pageCursor = &nextCursor
+	} else {
+		pageCursor = nil
+	}
+
+	return
+}
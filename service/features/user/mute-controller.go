@@ -0,0 +1,130 @@
+package user
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"net/http"
+)
+
+type MuteController struct {
+	Service MuteService
+}
+
+func (controller MuteController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/mutedPeople/",
+			Handler: controller.listMutedUsers,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/mutedPeople/:mutedId",
+			Handler: controller.getMute,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:  http.MethodPut,
+			Path:    "/users/:userId/mutedPeople/:mutedId",
+			Handler: controller.muteUser,
+			Scope:   route.ScopeMutesWrite,
+		},
+		route.SecureRoute{
+			Method:  http.MethodDelete,
+			Path:    "/users/:userId/mutedPeople/:mutedId",
+			Handler: controller.unmuteUser,
+			Scope:   route.ScopeMutesWrite,
+		},
+	}
+}
+
+func (controller MuteController) listMutedUsers(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseAllRequestVariables(r, params, &IdUserCursor{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	users, cursor, err := controller.Service.ListMutedUsers(args.UserId, args.PageCursorOrEmpty)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		// Add avatar URL prefix
+		for i := range users {
+			users[i].AddImageHost(r, context.Logger)
+		}
+
+		api.SendJson(w, api.PageResult[User]{
+			NextPageCursor: cursor,
+			PageData:       users,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller MuteController) getMute(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &muteParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	muted, err := controller.Service.IsUserMuted(args.MutedId, args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else if !muted {
+		http.Error(w, "user not muted", http.StatusNotFound)
+	} else {
+		api.SendJson(w, muteResult{
+			MutedId: args.MutedId,
+			MuterId: args.UserId,
+		}, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller MuteController) muteUser(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &muteParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.MuteUser(args.MutedId, args.UserId)
+	result := muteResult{
+		MutedId: args.MutedId,
+		MuterId: context.UserId,
+	}
+	api.HandlePutResult(result, err, w, context.Logger)
+}
+
+func (controller MuteController) unmuteUser(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &muteParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := controller.Service.UnmuteUser(args.MutedId, args.UserId)
+	api.HandleErrorsResponse(err, w, http.StatusNoContent, context.Logger)
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+func (dao DbDao) IsUserMutedBy(mutedId uuid.UUID, muterId uuid.UUID) (bool, error) {
+	result := struct {
+		Muted int64 `json:"muted"`
+	}{}
+
+	err := dao.Db.QueryStructRow(&result, "SELECT EXISTS(SELECT * FROM Mute WHERE mutedId = ? AND muterId = ?) AS muted", mutedId.Bytes(), muterId.Bytes())
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.Muted > 0, nil
+}
+
+func (dao DbDao) MuteUser(mutedId uuid.UUID, muterId uuid.UUID) (bool, error) {
+	rows, err := dao.Db.ExecRows("INSERT INTO Mute (mutedId, muterId) VALUES (?, ?)", mutedId.Bytes(), muterId.Bytes())
+	return rows > 0, err
+}
+
+func (dao DbDao) UnmuteUser(mutedUuid uuid.UUID, muterUuid uuid.UUID) (bool, error) {
+	rows, err := dao.Db.ExecRows("DELETE FROM Mute WHERE mutedId = ? AND muterId = ?", mutedUuid.Bytes(), muterUuid.Bytes())
+	return rows > 0, err
+}
+
+func (dao DbDao) GetMutedUsersPage(muterUuid uuid.UUID, afterMutedId uuid.UUID, afterUsername string) ([]ModelUserWithCustom, error) {
+	query := `
+		SELECT UserInfo.*,
+		       EXISTS(SELECT * FROM Ban WHERE bannedId = UserInfo.id AND bannerId = ?) AS banned,
+		       EXISTS(SELECT * FROM Follow WHERE followedId = UserInfo.id AND followerId = ?) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = UserInfo.id AND followedId = ?) AS followsYou
+		FROM UserInfo
+		JOIN Mute ON UserInfo.id = Mute.mutedId
+		WHERE Mute.muterId = ?
+		 	  -- Cursor pagination
+			  AND (username, id) > (?, ?)
+			  AND ` + ActiveCondition("UserInfo.id") + `
+		ORDER BY username, id
+		LIMIT ?`
+
+	rows, err := dao.Db.QueryStructRows(
+		ModelUserWithCustom{},
+		query,
+		muterUuid.Bytes(),
+		muterUuid.Bytes(),
+		muterUuid.Bytes(),
+		muterUuid.Bytes(),
+		afterUsername,
+		afterMutedId.Bytes(),
+		database.MaxPageItems,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseUserEntities(rows)
+}
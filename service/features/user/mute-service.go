@@ -0,0 +1,91 @@
+package user
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/utils/cursor"
+)
+
+// MuteService handles muted users.
+// Unlike a ban, a mute only hides the photos and the comments of the muted user
+// to who muted them: follows are kept, and the muted user never knows about it.
+type MuteService interface {
+	MuteUser(mutedId string, muterId string) error
+	UnmuteUser(mutedId string, muterId string) error
+	IsUserMuted(mutedId string, muterId string) (bool, error)
+	ListMutedUsers(muterId string, pageCursor string) ([]User, *string, error)
+}
+
+type MuteServiceImpl struct {
+	Db Dao
+}
+
+func (service MuteServiceImpl) MuteUser(mutedId string, muterId string) error {
+	mutedUuid := uuid.FromStringOrNil(mutedId)
+	muterUuid := uuid.FromStringOrNil(muterId)
+	if mutedUuid == uuid.Nil || muterUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	if mutedUuid == muterUuid {
+		return api.ErrSelfOperation
+	}
+
+	newMute, err := service.Db.MuteUser(mutedUuid, muterUuid)
+	if errors.Is(err, database.ErrForeignKey) {
+		return api.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if !newMute {
+		return api.ErrDuplicated
+	}
+
+	return nil
+}
+
+func (service MuteServiceImpl) UnmuteUser(mutedId string, muterId string) error {
+	mutedUuid := uuid.FromStringOrNil(mutedId)
+	muterUuid := uuid.FromStringOrNil(muterId)
+	if mutedUuid == uuid.Nil || muterUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	_, err := service.Db.UnmuteUser(mutedUuid, muterUuid)
+	return err
+}
+
+func (service MuteServiceImpl) IsUserMuted(mutedId string, muterId string) (bool, error) {
+	mutedUuid := uuid.FromStringOrNil(mutedId)
+	muterUuid := uuid.FromStringOrNil(muterId)
+	if mutedUuid == uuid.Nil || muterUuid == uuid.Nil {
+		return false, api.ErrWrongUUID
+	}
+
+	return service.Db.IsUserMutedBy(mutedUuid, muterUuid)
+}
+
+func (service MuteServiceImpl) ListMutedUsers(muterId string, pageCursor string) ([]User, *string, error) {
+	muterUuid := uuid.FromStringOrNil(muterId)
+	if muterUuid == uuid.Nil {
+		return nil, nil, api.ErrWrongUUID
+	}
+
+	// Parse cursor
+	afterMutedId, afterUsername, err := cursor.ParseStringIdCursor(pageCursor)
+	if err != nil {
+		return nil, nil, api.ErrWrongCursor
+	}
+
+	dbUsers, err := service.Db.GetMutedUsersPage(muterUuid, afterMutedId, afterUsername)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to DTO
+	users, nextCursor := DbUsersListToPage(dbUsers)
+	return users, nextCursor, nil
+}
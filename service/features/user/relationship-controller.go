@@ -0,0 +1,43 @@
+package user
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"net/http"
+)
+
+type RelationshipController struct {
+	Service RelationshipService
+}
+
+func (controller RelationshipController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/relationships/:otherId",
+			Handler: controller.getRelationship,
+			Scope:   route.ScopeRead,
+		},
+	}
+}
+
+func (controller RelationshipController) getRelationship(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &relationshipParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	if args.UserId != context.UserId {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	relationship, err := controller.Service.GetRelationship(args.UserId, args.OtherId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, relationship, http.StatusOK, context.Logger)
+	}
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+// GetRelationship finds out every relationship between the two users at once.
+// It returns nil if the other user doesn't exist.
+func (dao DbDao) GetRelationship(userUuid uuid.UUID, otherUuid uuid.UUID) (*ModelRelationship, error) {
+	query := `
+		SELECT EXISTS(SELECT * FROM Follow WHERE followerId = ? AND followedId = User.id) AS following,
+		       EXISTS(SELECT * FROM Follow WHERE followerId = User.id AND followedId = ?) AS followedBy,
+		       EXISTS(SELECT * FROM Ban WHERE bannerId = ? AND bannedId = User.id) AS banned,
+		       EXISTS(SELECT * FROM Ban WHERE bannerId = User.id AND bannedId = ?) AS bannedBy,
+		       EXISTS(SELECT * FROM Mute WHERE muterId = ? AND mutedId = User.id) AS muted,
+		       EXISTS(SELECT * FROM FollowRequest WHERE requesterId = ? AND targetId = User.id) AS requested,
+		       EXISTS(SELECT * FROM FollowRequest WHERE requesterId = User.id AND targetId = ?) AS requestedBy,
+		       EXISTS(SELECT * FROM CloseFriend WHERE ownerId = ? AND friendId = User.id) AS closeFriend
+		FROM User
+		WHERE User.id = ?`
+
+	relationship := &ModelRelationship{}
+	err := dao.Db.QueryStructRow(
+		relationship,
+		query,
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		userUuid.Bytes(),
+		otherUuid.Bytes(),
+	)
+
+	if errors.Is(err, database.ErrNoResult) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		return relationship, nil
+	}
+}
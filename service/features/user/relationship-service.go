@@ -0,0 +1,33 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+)
+
+// RelationshipService tells how two users are related,
+// so that clients don't need a request for each kind of relationship.
+type RelationshipService interface {
+	GetRelationship(userId string, otherId string) (Relationship, error)
+}
+
+type RelationshipServiceImpl struct {
+	Db Dao
+}
+
+func (service RelationshipServiceImpl) GetRelationship(userId string, otherId string) (Relationship, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	otherUuid := uuid.FromStringOrNil(otherId)
+	if userUuid == uuid.Nil || otherUuid == uuid.Nil {
+		return Relationship{}, api.ErrWrongUUID
+	}
+
+	relationship, err := service.Db.GetRelationship(userUuid, otherUuid)
+	if err != nil {
+		return Relationship{}, err
+	} else if relationship == nil {
+		return Relationship{}, api.ErrNotFound
+	}
+
+	return relationship.ToDto(userId, otherId), nil
+}
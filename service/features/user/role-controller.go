@@ -0,0 +1,65 @@
+package user
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+	"net/http"
+)
+
+type RoleController struct {
+	Service RoleService
+}
+
+func (controller RoleController) ListRoutes() []route.Route {
+	return []route.Route{
+		route.SecureRoute{
+			Method:  http.MethodGet,
+			Path:    "/users/:userId/role",
+			Handler: controller.getRole,
+			Scope:   route.ScopeRead,
+		},
+		route.SecureRoute{
+			Method:       http.MethodPut,
+			Path:         "/users/:userId/role",
+			Handler:      controller.setRole,
+			RequiredRole: route.RoleAdmin,
+		},
+	}
+}
+
+func (controller RoleController) getRole(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, bodyErr := api.ParseRequestVariables(params, &IdParams{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	// Only admins can see the roles of the other users
+	if args.UserId != context.UserId && !context.Role.Includes(route.RoleAdmin) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	role, err := controller.Service.GetRole(args.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, role, http.StatusOK, context.Logger)
+	}
+}
+
+func (controller RoleController) setRole(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+	args, body, bodyErr := api.ParseVariablesAndBody(r, params, &IdParams{}, &userRole{}, context.Logger)
+	if bodyErr != nil {
+		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+
+	err := controller.Service.SetRole(args.UserId, *body, context.UserId)
+	if err != nil {
+		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
+	} else {
+		api.SendJson(w, body, http.StatusOK, context.Logger)
+	}
+}
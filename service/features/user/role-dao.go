@@ -0,0 +1,37 @@
+package user
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+// GetUserRole returns the role of the user, or an empty string if the user doesn't exist
+func (dao DbDao) GetUserRole(userUuid uuid.UUID) (string, error) {
+	result := struct {
+		Role string `json:"role"`
+	}{}
+
+	err := dao.Db.QueryStructRow(&result, "SELECT role FROM User WHERE id = ?", userUuid.Bytes())
+	if errors.Is(err, database.ErrNoResult) {
+		return "", nil
+	}
+
+	return result.Role, err
+}
+
+func (dao DbDao) SetUserRole(userUuid uuid.UUID, role string) (bool, error) {
+	rows, err := dao.Db.ExecRows("UPDATE User SET role = ? WHERE id = ?", role, userUuid.Bytes())
+	return rows > 0, err
+}
+
+// SetUserRoleIfUnassigned sets the role of the user, only if no user has that role yet
+func (dao DbDao) SetUserRoleIfUnassigned(userUuid uuid.UUID, role string) (bool, error) {
+	rows, err := dao.Db.ExecRows(`
+		UPDATE User
+		SET role = ?
+		WHERE id = ?
+		  AND NOT EXISTS(SELECT * FROM User WHERE role = ?)
+	`, role, userUuid.Bytes(), role)
+	return rows > 0, err
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/api/route"
+)
+
+type RoleService interface {
+	GetRole(userId string) (userRole, error)
+	SetRole(userId string, role userRole, asUserId string) error
+	BootstrapAdmin(userId string) (bool, error)
+}
+
+type RoleServiceImpl struct {
+	Db Dao
+}
+
+func (service RoleServiceImpl) GetRole(userId string) (userRole, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid == uuid.Nil {
+		return userRole{}, api.ErrWrongUUID
+	}
+
+	role, err := service.Db.GetUserRole(userUuid)
+	if err != nil {
+		return userRole{}, err
+	} else if role == "" {
+		return userRole{}, api.ErrNotFound
+	}
+
+	return userRole{Role: role}, nil
+}
+
+// SetRole changes the role of a user.
+// Admins can't change their own role, so that at least one admin is always left.
+func (service RoleServiceImpl) SetRole(userId string, role userRole, asUserId string) error {
+	userUuid := uuid.FromStringOrNil(userId)
+	asUserUuid := uuid.FromStringOrNil(asUserId)
+	if userUuid == uuid.Nil || asUserUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	if userUuid == asUserUuid {
+		return api.ErrSelfOperation
+	}
+
+	found, err := service.Db.SetUserRole(userUuid, role.Role)
+	if err != nil {
+		return err
+	} else if !found {
+		return api.ErrNotFound
+	}
+
+	return nil
+}
+
+// BootstrapAdmin makes the user with the given ID an admin, only if there are no admins yet.
+// It's used to bootstrap the first admin, who can then manage the other roles,
+// so it never overrides the choices made by the admins later.
+// It returns whether the user has been promoted.
+func (service RoleServiceImpl) BootstrapAdmin(userId string) (bool, error) {
+	userUuid := uuid.FromStringOrNil(userId)
+	if userUuid == uuid.Nil {
+		return false, api.ErrWrongUUID
+	}
+
+	promoted, err := service.Db.SetUserRoleIfUnassigned(userUuid, string(route.RoleAdmin))
+	if err != nil || promoted {
+		return promoted, err
+	}
+
+	// Tell apart a missing user from an existing admin
+	role, err := service.Db.GetUserRole(userUuid)
+	if err != nil {
+		return false, err
+	} else if role == "" {
+		return false, api.ErrNotFound
+	}
+
+	return false, nil
+}
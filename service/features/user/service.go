@@ -5,19 +5,34 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/simonesestito/wasaphoto/service/api"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
 	"github.com/simonesestito/wasaphoto/service/utils/cursor"
+	"time"
 )
 
 type Service interface {
 	GetUserAs(searchedId string, searchAsId string) (*User, error)
 	UpdateUserDetails(id string, newUser newUser) (User, error)
 	UpdateUsername(id string, username string) (User, error)
+	CheckUsernameChange(id string, username string) error
 	GetUserByUsernameAs(username string, searchAsId string) (*User, error)
-	ListUsersByUsernameAs(username string, searchAsId string, pageCursor string) ([]User, *string, error)
+	SearchUsersAs(text string, searchAsId string, pageCursor string) ([]User, *string, error)
+}
+
+// UsernameConfig sets the limits on username changes
+type UsernameConfig struct {
+	// ReservationPeriod is how long a released username is reserved to its previous owner.
+	// Meanwhile, looking for it still finds the account.
+	ReservationPeriod time.Duration
+
+	// ChangeInterval is the minimum time between two username changes of the same user
+	ChangeInterval time.Duration
 }
 
 type ServiceImpl struct {
-	Db Dao
+	Db        Dao
+	Time      timeprovider.TimeProvider
+	Usernames UsernameConfig
 }
 
 func (service ServiceImpl) GetUserAs(searchedId string, searchAsId string) (*User, error) {
@@ -53,11 +68,28 @@ func (service ServiceImpl) UpdateUserDetails(id string, newUser newUser) (User,
 		return User{}, api.ErrWrongUUID
 	}
 
-	err := service.Db.EditUser(userUuid, ModelUser{
+	oldUser, err := service.Db.GetUserById(userUuid)
+	if err != nil {
+		return User{}, err
+	} else if oldUser == nil {
+		return User{}, api.ErrNotFound
+	}
+
+	editedUser := ModelUser{
 		Name:     newUser.Name,
 		Surname:  newUser.Surname,
 		Username: newUser.Username,
-	})
+		Bio:      newUser.Bio,
+		Website:  newUser.Website,
+		Pronouns: newUser.Pronouns,
+	}
+	if oldUser.Username != newUser.Username {
+		now := service.Time.Now()
+		lastChange, changeErr := service.Db.EditUserWithUsername(userUuid, editedUser, service.usernameChangeLimits(now))
+		err = service.handleUsernameChange(now, lastChange, changeErr)
+	} else {
+		err = service.Db.EditUser(userUuid, editedUser)
+	}
 
 	if errors.Is(err, database.ErrDuplicated) {
 		return User{}, api.ErrAlreadyTaken
@@ -72,18 +104,33 @@ func (service ServiceImpl) UpdateUserDetails(id string, newUser newUser) (User,
 	return updatedUser.ToDto(), nil
 }
 
+// UpdateUsername changes the username of the user.
+// The old one stays reserved to the user for a while, leading to the account.
+//
+// It returns api.ErrAlreadyTaken if the new username is used or reserved by someone else,
+// or an api.RetryAfterError if the user changed it too recently.
 func (service ServiceImpl) UpdateUsername(id string, username string) (User, error) {
 	userUuid := uuid.FromStringOrNil(id)
 	if userUuid == uuid.Nil {
 		return User{}, api.ErrWrongUUID
 	}
 
-	err := service.Db.EditUsername(userUuid, username)
-
-	if errors.Is(err, database.ErrDuplicated) {
-		return User{}, api.ErrAlreadyTaken
-	} else if err != nil {
+	oldUser, err := service.Db.GetUserById(userUuid)
+	if err != nil {
 		return User{}, err
+	} else if oldUser == nil {
+		return User{}, api.ErrNotFound
+	}
+
+	if oldUser.Username != username {
+		now := service.Time.Now()
+		lastChange, changeErr := service.Db.ChangeUsername(userUuid, username, service.usernameChangeLimits(now))
+		err = service.handleUsernameChange(now, lastChange, changeErr)
+		if errors.Is(err, database.ErrDuplicated) {
+			return User{}, api.ErrAlreadyTaken
+		} else if err != nil {
+			return User{}, err
+		}
 	}
 
 	updatedUser, err := service.Db.GetUserByIdAs(userUuid, userUuid)
@@ -93,32 +140,127 @@ func (service ServiceImpl) UpdateUsername(id string, username string) (User, err
 	return updatedUser.ToDto(), nil
 }
 
+// CheckUsernameChange tells if the user can change their username to the given one right now,
+// as long as nobody is using it, without changing it.
+func (service ServiceImpl) CheckUsernameChange(id string, username string) error {
+	userUuid := uuid.FromStringOrNil(id)
+	if userUuid == uuid.Nil {
+		return api.ErrWrongUUID
+	}
+
+	return service.checkUsernameChange(userUuid, username)
+}
+
+// checkUsernameChange enforces the minimum interval between username changes,
+// and the reservation of the usernames recently released by other users.
+// The DAO checks them again while changing the username, in the same transaction.
+func (service ServiceImpl) checkUsernameChange(userUuid uuid.UUID, username string) error {
+	now := service.Time.Now()
+
+	lastChange, err := service.Db.GetLastUsernameChangeDate(userUuid)
+	if err != nil {
+		return err
+	}
+	if lastChange != "" {
+		if err := service.retryAfterUsernameChange(now, lastChange); err != nil {
+			return err
+		}
+	}
+
+	reserved, err := service.Db.IsUsernameReservedToOthers(username, userUuid, timeprovider.DateToUTCString(now))
+	if err != nil {
+		return err
+	} else if reserved {
+		return api.ErrAlreadyTaken
+	}
+
+	return nil
+}
+
+// retryAfterUsernameChange returns an api.RetryAfterError
+// if the previous change, at the given date, is too recent
+func (service ServiceImpl) retryAfterUsernameChange(now time.Time, lastChange string) error {
+	lastChangeDate, err := timeprovider.UTCStringToDate(lastChange)
+	if err != nil {
+		return err
+	}
+
+	nextChangeDate := lastChangeDate.Add(service.Usernames.ChangeInterval)
+	if now.Before(nextChangeDate) {
+		return api.RetryAfterError{RetryAfter: nextChangeDate.Sub(now)}
+	}
+	return nil
+}
+
+// usernameChangeLimits tells the DAO how to check and record a username change made now.
+// The released username is reserved to the user for a while.
+func (service ServiceImpl) usernameChangeLimits(now time.Time) UsernameChangeLimits {
+	return UsernameChangeLimits{
+		Now:              timeprovider.DateToUTCString(now),
+		LastChangeBefore: timeprovider.DateToUTCString(now.Add(-service.Usernames.ChangeInterval)),
+		ReservedUntil:    timeprovider.DateToUTCString(now.Add(service.Usernames.ReservationPeriod)),
+	}
+}
+
+// handleUsernameChange converts the result of a username change made now by the DAO to the service errors
+func (service ServiceImpl) handleUsernameChange(now time.Time, lastChange string, err error) error {
+	switch {
+	case errors.Is(err, errUsernameChangedRecently):
+		return service.retryAfterUsernameChange(now, lastChange)
+	case errors.Is(err, database.ErrNoResult):
+		return api.ErrNotFound
+	default:
+		return err
+	}
+}
+
+// GetUserByUsernameAs finds the user with the given username.
+// If nobody has it, the user who recently released it is returned,
+// so that old links keep working during the reservation.
 func (service ServiceImpl) GetUserByUsernameAs(username string, searchAsId string) (*User, error) {
 	searchAsUuid := uuid.FromStringOrNil(searchAsId)
 	dbUser, err := service.Db.GetUserByUsernameAs(username, searchAsUuid)
 	if err != nil {
 		return nil, err
 	} else if dbUser == nil {
-		return nil, nil
+		return service.getUserByReservedUsernameAs(username, searchAsUuid)
+	}
+
+	user := dbUser.ToDto()
+	return &user, nil
+}
+
+func (service ServiceImpl) getUserByReservedUsernameAs(username string, searchAsUuid uuid.UUID) (*User, error) {
+	ownerUuid, err := service.Db.GetUserIdByReservedUsername(username, service.Time.UTCString())
+	if err != nil || ownerUuid.IsNil() {
+		return nil, err
+	}
+
+	dbUser, err := service.Db.GetUserByIdAs(ownerUuid, searchAsUuid)
+	if err != nil || dbUser == nil {
+		return nil, err
 	}
 
 	user := dbUser.ToDto()
 	return &user, nil
 }
 
-func (service ServiceImpl) ListUsersByUsernameAs(username string, searchAsId string, pageCursor string) ([]User, *string, error) {
+// SearchUsersAs finds the users whose username, name or surname contain the given text,
+// with the most relevant ones first.
+func (service ServiceImpl) SearchUsersAs(text string, searchAsId string, pageCursor string) ([]User, *string, error) {
 	searchAsUuid := uuid.FromStringOrNil(searchAsId)
-	afterId, afterUsername, err := cursor.ParseStringIdCursor(pageCursor)
+
+	afterId, afterScore, err := cursor.ParseScoreIdCursor(pageCursor)
 	if err != nil {
 		return nil, nil, api.ErrWrongCursor
 	}
 
-	dbUsers, err := service.Db.ListUsersByUsernameAs(username, searchAsUuid, afterUsername, afterId)
+	results, err := service.Db.SearchUsersAs(text, searchAsUuid, afterScore, afterId)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Convert to DTO
-	users, nextCursor := DbUsersListToPage(dbUsers)
+	users, nextCursor := ScoredUsersListToPage(results)
 	return users, nextCursor, nil
 }
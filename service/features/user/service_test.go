@@ -0,0 +1,55 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/database/databasetest"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+)
+
+func TestUpdateUsername(t *testing.T) {
+	clock := &timeprovider.MockTimeProvider{MockTime: time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)}
+	dao := DbDao{Db: databasetest.New(t)}
+	service := ServiceImpl{
+		Db:        dao,
+		Time:      clock,
+		Usernames: UsernameConfig{ReservationPeriod: 30 * 24 * time.Hour, ChangeInterval: 7 * 24 * time.Hour},
+	}
+
+	johnId, janeId := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	for id, username := range map[uuid.UUID]string{johnId: "john_doe", janeId: "jane_doe"} {
+		err := dao.Db.Exec("INSERT INTO User (id, name, surname, username) VALUES (?, 'Name', 'Surname', ?)", id.Bytes(), username)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if updated, err := service.UpdateUsername(johnId.String(), "johnny"); err != nil || updated.Username != "johnny" {
+		t.Fatalf("expected the username to be changed, got %+v, %v", updated, err)
+	}
+
+	// The released username is reserved to its previous owner
+	if _, err := service.UpdateUsername(janeId.String(), "john_doe"); !errors.Is(err, api.ErrAlreadyTaken) {
+		t.Errorf("expected ErrAlreadyTaken for a reserved username, got %v", err)
+	}
+	if _, err := service.UpdateUsername(janeId.String(), "johnny"); !errors.Is(err, api.ErrAlreadyTaken) {
+		t.Errorf("expected ErrAlreadyTaken for a used username, got %v", err)
+	}
+
+	// Another change must wait for the interval
+	clock.MockTime = clock.MockTime.Add(24 * time.Hour)
+	_, err := service.UpdateUsername(johnId.String(), "john_doe")
+	var retryAfterErr api.RetryAfterError
+	if !errors.As(err, &retryAfterErr) || retryAfterErr.RetryAfter != 6*24*time.Hour {
+		t.Errorf("expected to retry after 6 days, got %v", err)
+	}
+
+	clock.MockTime = clock.MockTime.Add(6 * 24 * time.Hour)
+	if updated, err := service.UpdateUsername(johnId.String(), "john_doe"); err != nil || updated.Username != "john_doe" {
+		t.Errorf("expected the reserved username to be taken back, got %+v, %v", updated, err)
+	}
+}
@@ -19,26 +19,30 @@ func (controller Controller) ListRoutes() []route.Route {
 			Method:  http.MethodGet,
 			Path:    "/users/:userId",
 			Handler: controller.getUserProfile,
+			Scope:   route.ScopeRead,
 		},
 		route.SecureRoute{
 			Method:  http.MethodPut,
 			Path:    "/users/:userId",
 			Handler: controller.setMyDetails,
+			Scope:   route.ScopeProfileWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodPut,
 			Path:    "/users/:userId/username",
 			Handler: controller.setMyUserName,
+			Scope:   route.ScopeProfileWrite,
 		},
 		route.SecureRoute{
 			Method:  http.MethodGet,
 			Path:    "/users/",
 			Handler: controller.searchUsers,
+			Scope:   route.ScopeRead,
 		},
 	}
 }
 
-func (controller Controller) getUserProfile(w http.ResponseWriter, _ *http.Request, params httprouter.Params, context route.SecureRequestContext) {
+func (controller Controller) getUserProfile(w http.ResponseWriter, r *http.Request, params httprouter.Params, context route.SecureRequestContext) {
 	args, bodyErr := api.ParseRequestVariables(params, &IdParams{}, context.Logger)
 	if bodyErr != nil {
 		http.Error(w, bodyErr.Message, bodyErr.StatusCode)
@@ -51,6 +55,7 @@ func (controller Controller) getUserProfile(w http.ResponseWriter, _ *http.Reque
 	} else if foundUser == nil {
 		http.Error(w, "not found", http.StatusNotFound)
 	} else {
+		foundUser.AddImageHost(r, context.Logger)
 		api.SendJson(w, foundUser, 200, context.Logger)
 	}
 }
@@ -78,6 +83,7 @@ func (controller Controller) setMyDetails(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		updatedUser.AddImageHost(r, context.Logger)
 		api.SendJson(w, updatedUser, 200, context.Logger)
 	}
 }
@@ -116,6 +122,7 @@ func (controller Controller) setMyUserName(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		updatedUser.AddImageHost(r, context.Logger)
 		api.SendJson(w, updatedUser, 200, context.Logger)
 	}
 }
@@ -142,12 +149,17 @@ func (controller Controller) searchUsers(w http.ResponseWriter, r *http.Request,
 			users = []User{*singleUser}
 		}
 	} else {
-		users, cursor, err = controller.Service.ListUsersByUsernameAs(args.Username, context.UserId, args.PageCursorOrEmpty)
+		users, cursor, err = controller.Service.SearchUsersAs(args.Username, context.UserId, args.PageCursorOrEmpty)
 	}
 
 	if err != nil {
 		api.HandleErrorsResponse(err, w, http.StatusOK, context.Logger)
 	} else {
+		// Add avatar URL prefix
+		for i := range users {
+			users[i].AddImageHost(r, context.Logger)
+		}
+
 		api.SendJson(w, api.PageResult[User]{
 			NextPageCursor: cursor,
 			PageData:       users,
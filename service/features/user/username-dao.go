@@ -0,0 +1,134 @@
+package user
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/simonesestito/wasaphoto/service/database"
+)
+
+// errUsernameChangedRecently is returned when the previous username change is too recent
+var errUsernameChangedRecently = errors.New("username changed recently")
+
+// EditUserWithUsername updates the user like EditUser, changing the username too.
+// See changeUsername for the checks and the record of the change.
+func (dao DbDao) EditUserWithUsername(userUuid uuid.UUID, user ModelUser, change UsernameChangeLimits) (string, error) {
+	query := "UPDATE User SET name = ?, surname = ?, username = ?, bio = ?, website = ?, pronouns = ? WHERE id = ?"
+	return dao.changeUsername(userUuid, user.Username, change, query, user.Name, user.Surname, user.Username, user.Bio, user.Website, user.Pronouns, userUuid.Bytes())
+}
+
+// ChangeUsername changes only the username of the user.
+// See changeUsername for the checks and the record of the change.
+func (dao DbDao) ChangeUsername(userUuid uuid.UUID, username string, change UsernameChangeLimits) (string, error) {
+	query := "UPDATE User SET username = ? WHERE id = ?"
+	return dao.changeUsername(userUuid, username, change, query, username, userUuid.Bytes())
+}
+
+// changeUsername runs the update query, if the user can change their username right now,
+// then it records the released one, reserving it to them.
+//
+// If the previous change is too recent, it returns errUsernameChangedRecently, along with its date.
+// If the new username is used or reserved by someone else, it returns database.ErrDuplicated.
+//
+// Since multiple tables are involved, and the checks must still hold when the username is changed,
+// a transaction is used.
+func (dao DbDao) changeUsername(userUuid uuid.UUID, username string, change UsernameChangeLimits, updateQuery string, updateArgs ...any) (string, error) {
+	tx, err := dao.Db.BeginTx()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Query(`
+		SELECT User.username, COALESCE(MAX(UsernameChange.changeDate), '')
+		FROM User
+		LEFT JOIN UsernameChange ON UsernameChange.userId = User.id
+		WHERE User.id = ?
+		GROUP BY User.id`,
+		userUuid.Bytes())
+	if err != nil {
+		return "", err
+	}
+	var oldUsername, lastChangeDate string
+	found := result.Next()
+	if found {
+		err = result.Scan(&oldUsername, &lastChangeDate)
+	}
+	_ = result.Close()
+	if err != nil {
+		return "", err
+	} else if !found {
+		return "", database.ErrNoResult
+	}
+
+	if lastChangeDate > change.LastChangeBefore {
+		return lastChangeDate, errUsernameChangedRecently
+	}
+
+	// Check if the username is available, and not reserved by who recently released it
+	result, err = tx.Query(`
+		SELECT id FROM User WHERE username = ? AND id != ?
+		UNION ALL
+		SELECT userId FROM UsernameChange WHERE username = ? AND userId != ? AND reservedUntil > ?`,
+		username, userUuid.Bytes(), username, userUuid.Bytes(), change.Now)
+	if err != nil {
+		return "", err
+	}
+	usernameTaken := result.Next()
+	_ = result.Close()
+	if usernameTaken {
+		return "", database.ErrDuplicated
+	}
+
+	if _, err := tx.Exec(updateQuery, updateArgs...); err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec("INSERT INTO UsernameChange (userId, username, changeDate, reservedUntil) VALUES (?, ?, ?, ?)",
+		userUuid.Bytes(), oldUsername, change.Now, change.ReservedUntil)
+	if err != nil {
+		return "", err
+	}
+
+	return "", tx.Commit()
+}
+
+// GetLastUsernameChangeDate returns when the user changed their username for the last time,
+// or an empty string if they never did.
+func (dao DbDao) GetLastUsernameChangeDate(userUuid uuid.UUID) (string, error) {
+	result := struct {
+		ChangeDate string `json:"changeDate"`
+	}{}
+	err := dao.Db.QueryStructRow(&result, "SELECT COALESCE(MAX(changeDate), '') AS changeDate FROM UsernameChange WHERE userId = ?", userUuid.Bytes())
+	return result.ChangeDate, err
+}
+
+// IsUsernameReservedToOthers tells if the username has been released by a different user,
+// who is the only one who can take it back until the end of the reservation.
+func (dao DbDao) IsUsernameReservedToOthers(username string, userUuid uuid.UUID, now string) (bool, error) {
+	result := struct {
+		Reserved int64 `json:"reserved"`
+	}{}
+	query := "SELECT EXISTS(SELECT * FROM UsernameChange WHERE username = ? AND userId != ? AND reservedUntil > ?) AS reserved"
+	err := dao.Db.QueryStructRow(&result, query, username, userUuid.Bytes(), now)
+	return result.Reserved > 0, err
+}
+
+// GetUserIdByReservedUsername finds the user who released the username,
+// as long as it's still reserved. It returns uuid.Nil if there's none.
+func (dao DbDao) GetUserIdByReservedUsername(username string, now string) (uuid.UUID, error) {
+	result := struct {
+		UserId []byte `json:"userId"`
+	}{}
+	query := "SELECT userId FROM UsernameChange WHERE username = ? AND reservedUntil > ? ORDER BY changeDate DESC LIMIT 1"
+	err := dao.Db.QueryStructRow(&result, query, username, now)
+	switch {
+	case errors.Is(err, database.ErrNoResult):
+		return uuid.Nil, nil
+	case err != nil:
+		return uuid.Nil, err
+	default:
+		return uuid.FromBytesOrNil(result.UserId), nil
+	}
+}
@@ -0,0 +1,157 @@
+package imaging
+
+import (
+	"bytes"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/utils/webp"
+	"github.com/sirupsen/logrus"
+	"image"
+	"image/draw"
+
+	// Supported input formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	// Imported photos are already WebP
+	_ "golang.org/x/image/webp"
+)
+
+// maxInputPixels limits the size of the decoded images,
+// since a small compressed file can hold a huge image
+const maxInputPixels = 50_000_000
+
+// LocalProcessor processes the images in this server, without any external service.
+// It accepts JPEG, PNG, GIF (only the first frame) and WebP images and produces lossless WebP images,
+// which are much larger than the lossy ones made by TinyPNG (see the webp package).
+type LocalProcessor struct {
+	// Width of the processed images, as in Config
+	Width int
+}
+
+func (processor LocalProcessor) CompressToWebp(imageData []byte, widths []int, logger logrus.FieldLogger) ([]Image, error) {
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		logger.WithError(err).Debugln("unsupported image format")
+		return nil, api.ErrMedia
+	} else if imageConfig.Width*imageConfig.Height > maxInputPixels {
+		logger.Debugf("image is too big: %dx%d\n", imageConfig.Width, imageConfig.Height)
+		return nil, api.ErrMedia
+	}
+
+	logger.Debugf("Decoding %s image...\n", format)
+	decodedImage, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		logger.WithError(err).Debugln("unable to decode the image")
+		return nil, api.ErrMedia
+	}
+
+	images := make([]Image, len(widths))
+	for i, width := range widths {
+		resizedImage := decodedImage
+		originalWidth := decodedImage.Bounds().Dx()
+		if width = targetWidth(width, processor.Width, originalWidth); width < originalWidth {
+			logger.Debugf("Resizing to width %d...\n", width)
+			resizedImage = resizeToWidth(decodedImage, width)
+		}
+
+		if bounds := resizedImage.Bounds(); bounds.Dx() > webp.MaxDimension || bounds.Dy() > webp.MaxDimension {
+			logger.Debugf("image is too big for WebP: %dx%d\n", bounds.Dx(), bounds.Dy())
+			return nil, api.ErrMedia
+		}
+
+		logger.Debugln("Converting to WebP...")
+		var buffer bytes.Buffer
+		if err := webp.Encode(&buffer, resizedImage); err != nil {
+			return nil, err
+		}
+
+		images[i] = Image{
+			Data:   buffer.Bytes(),
+			Width:  resizedImage.Bounds().Dx(),
+			Height: resizedImage.Bounds().Dy(),
+		}
+	}
+
+	logger.Debugln("Conversion to WebP successful")
+	return images, nil
+}
+
+// resizeToWidth scales down the image keeping its aspect ratio.
+// Every resulting pixel is the average of the area it covers in the original image.
+func resizeToWidth(src image.Image, width int) image.Image {
+	srcBounds := src.Bounds()
+	srcWidth, srcHeight := srcBounds.Dx(), srcBounds.Dy()
+	height := scaledHeight(width, srcWidth, srcHeight)
+
+	// Average premultiplied colors, so transparent pixels don't affect the others
+	rgba := image.NewRGBA(image.Rect(0, 0, srcWidth, srcHeight))
+	draw.Draw(rgba, rgba.Bounds(), src, srcBounds.Min, draw.Src)
+
+	// Horizontal pass, then the vertical one
+	columnWeights := areaWeights(srcWidth, width)
+	rows := make([]float64, width*srcHeight*4)
+	for y := 0; y < srcHeight; y++ {
+		for x, weights := range columnWeights {
+			for _, weight := range weights {
+				srcOffset := y*rgba.Stride + weight.index*4
+				for channel := 0; channel < 4; channel++ {
+					rows[(y*width+x)*4+channel] += float64(rgba.Pix[srcOffset+channel]) * weight.value
+				}
+			}
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, weights := range areaWeights(srcHeight, height) {
+		for x := 0; x < width; x++ {
+			var sums [4]float64
+			for _, weight := range weights {
+				for channel := 0; channel < 4; channel++ {
+					sums[channel] += rows[(weight.index*width+x)*4+channel] * weight.value
+				}
+			}
+			for channel, sum := range sums {
+				dst.Pix[y*dst.Stride+x*4+channel] = uint8(sum + 0.5)
+			}
+		}
+	}
+
+	return dst
+}
+
+type areaWeight struct {
+	index int
+	value float64
+}
+
+// areaWeights computes, for each of the dstSize pixels, how much every
+// source pixel contributes to it. The weights of each pixel sum to 1.
+func areaWeights(srcSize int, dstSize int) [][]areaWeight {
+	scale := float64(srcSize) / float64(dstSize)
+	weights := make([][]areaWeight, dstSize)
+	for i := range weights {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < srcSize && float64(j) < end; j++ {
+			covered := minFloat(end, float64(j+1)) - maxFloat(start, float64(j))
+			if covered > 0 {
+				weights[i] = append(weights[i], areaWeight{index: j, value: covered / scale})
+			}
+		}
+	}
+	return weights
+}
+
+func minFloat(a float64, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a float64, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/simonesestito/wasaphoto/service/utils/tinypng"
+	"github.com/sirupsen/logrus"
+)
+
+// Processor prepares the images uploaded by the users (photos, avatars, ...)
+// before they are saved in the storage.
+type Processor interface {
+	// CompressToWebp converts the given image to WebP, once for each of the requested widths.
+	// Widths are limited to the configured one, and images are never enlarged,
+	// so a zero width asks for the largest allowed size.
+	CompressToWebp(imageData []byte, widths []int, logger logrus.FieldLogger) ([]Image, error)
+}
+
+// Image is a processed image, along with its final size
+type Image struct {
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Available Processor implementations
+const (
+	ProcessorLocal   = "local"
+	ProcessorTinyPng = "tinypng"
+)
+
+// Config chooses and configures the Processor implementation
+type Config struct {
+	// Processor is the name of the implementation to use.
+	// If empty, images are processed locally.
+	Processor string
+
+	// Width of the processed images. Smaller images are never enlarged.
+	// If zero, images keep their original size.
+	Width int
+
+	// TinyPngToken is the API key of the TinyPNG service, required only if it's used
+	TinyPngToken string
+}
+
+// New creates the Processor implementation according to the configuration
+func New(config Config) (Processor, error) {
+	switch config.Processor {
+	case ProcessorLocal, "":
+		return LocalProcessor{Width: config.Width}, nil
+	case ProcessorTinyPng:
+		if config.TinyPngToken == "" {
+			return nil, fmt.Errorf("a TinyPNG token is required by the %s image processor", ProcessorTinyPng)
+		}
+		return TinyPngProcessor{
+			TinyPng: tinypng.API{Token: config.TinyPngToken},
+			Width:   config.Width,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown image processor: %s", config.Processor)
+	}
+}
+
+// targetWidth is the width to resize an image to, given the requested one,
+// the configured limit and the original width, where zero means no limit.
+func targetWidth(requested int, configured int, original int) int {
+	width := original
+	if configured > 0 && configured < width {
+		width = configured
+	}
+	if requested > 0 && requested < width {
+		width = requested
+	}
+	return width
+}
+
+// scaledHeight keeps the aspect ratio of an image resized to the given width
+func scaledHeight(width int, originalWidth int, originalHeight int) int {
+	height := (originalHeight*width + originalWidth/2) / originalWidth
+	if height < 1 {
+		return 1
+	}
+	return height
+}
+
+// IsWebp tells if the given data looks like a WebP image,
+// which is the format of the processed images
+func IsWebp(imageData []byte) bool {
+	return len(imageData) > 12 &&
+		bytes.Equal(imageData[0:4], []byte("RIFF")) &&
+		bytes.Equal(imageData[8:12], []byte("WEBP"))
+}
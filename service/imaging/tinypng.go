@@ -0,0 +1,53 @@
+package imaging
+
+import (
+	"errors"
+	"github.com/simonesestito/wasaphoto/service/api"
+	"github.com/simonesestito/wasaphoto/service/utils/tinypng"
+	"github.com/sirupsen/logrus"
+)
+
+// TinyPngProcessor delegates the processing to the TinyPNG service
+type TinyPngProcessor struct {
+	TinyPng tinypng.API
+
+	// Width of the processed images, as in Config
+	Width int
+}
+
+func (processor TinyPngProcessor) CompressToWebp(imageData []byte, widths []int, logger logrus.FieldLogger) ([]Image, error) {
+	logger.Debugln("Uploading photo...")
+	upload, err := processor.TinyPng.UploadPhoto(imageData)
+	if errors.Is(err, api.ErrMedia) {
+		return nil, err
+	} else if err != nil {
+		logger.WithError(err).Errorln("unable to reach TinyPNG service")
+		return nil, api.ErrThirdParty
+	}
+
+	// The same upload can be converted many times
+	images := make([]Image, len(widths))
+	for i, width := range widths {
+		width = targetWidth(width, processor.Width, upload.Width)
+
+		logger.Debugf("Converting to WebP with width %d...\n", width)
+		resizeWidth := width
+		if width == upload.Width {
+			resizeWidth = 0
+		}
+		webpData, err := processor.TinyPng.ConvertToWebp(upload, resizeWidth)
+		if err != nil {
+			logger.WithError(err).Errorln("Conversion to WebP failed")
+			return nil, api.ErrThirdParty
+		}
+
+		images[i] = Image{
+			Data:   webpData,
+			Width:  width,
+			Height: scaledHeight(width, upload.Width, upload.Height),
+		}
+	}
+
+	logger.Debugln("Conversion to WebP successful")
+	return images, nil
+}
@@ -2,8 +2,10 @@ package ioc
 
 import (
 	"github.com/simonesestito/wasaphoto/service/api/route"
+	"github.com/simonesestito/wasaphoto/service/features/account"
 	"github.com/simonesestito/wasaphoto/service/features/auth"
 	"github.com/simonesestito/wasaphoto/service/features/comments"
+	"github.com/simonesestito/wasaphoto/service/features/export"
 	"github.com/simonesestito/wasaphoto/service/features/follow"
 	"github.com/simonesestito/wasaphoto/service/features/likes"
 	"github.com/simonesestito/wasaphoto/service/features/photo"
@@ -21,18 +23,78 @@ func (ioc *Container) createLoginController() auth.LoginController {
 	}
 }
 
+func (ioc *Container) createOidcController() auth.OidcController {
+	return auth.OidcController{
+		AuthService: ioc.createExternalAuthService(),
+	}
+}
+
+func (ioc *Container) createEmailController() auth.EmailController {
+	return auth.EmailController{
+		Service: ioc.createEmailService(),
+	}
+}
+
+func (ioc *Container) createSessionController() auth.SessionController {
+	return auth.SessionController{
+		Service: ioc.createSessionService(),
+	}
+}
+
+func (ioc *Container) createTotpController() auth.TotpController {
+	return auth.TotpController{
+		Service: ioc.createTotpService(),
+	}
+}
+
+func (ioc *Container) createPersonalTokenController() auth.PersonalTokenController {
+	return auth.PersonalTokenController{
+		Service: ioc.createPersonalTokenService(),
+	}
+}
+
 func (ioc *Container) createUserController() user.Controller {
 	return user.Controller{
 		Service: ioc.createUserService(),
 	}
 }
 
+func (ioc *Container) createAvatarController() user.AvatarController {
+	return user.AvatarController{
+		Service: ioc.createAvatarService(),
+	}
+}
+
+func (ioc *Container) createRoleController() user.RoleController {
+	return user.RoleController{
+		Service: ioc.CreateRoleService(),
+	}
+}
+
 func (ioc *Container) createBanController() user.BanController {
 	return user.BanController{
 		Service: ioc.createBanService(),
 	}
 }
 
+func (ioc *Container) createMuteController() user.MuteController {
+	return user.MuteController{
+		Service: ioc.createMuteService(),
+	}
+}
+
+func (ioc *Container) createCloseFriendController() user.CloseFriendController {
+	return user.CloseFriendController{
+		Service: ioc.createCloseFriendService(),
+	}
+}
+
+func (ioc *Container) createRelationshipController() user.RelationshipController {
+	return user.RelationshipController{
+		Service: ioc.createRelationshipService(),
+	}
+}
+
 func (ioc *Container) createFollowController() follow.Controller {
 	return follow.Controller{Service: ioc.createFollowService()}
 }
@@ -56,3 +118,15 @@ func (ioc *Container) createStreamController() stream.Controller {
 func (ioc *Container) createCommentsController() comments.Controller {
 	return comments.Controller{Service: ioc.createCommentsService()}
 }
+
+func (ioc *Container) createAccountController() account.Controller {
+	return account.Controller{Service: ioc.createAccountService()}
+}
+
+func (ioc *Container) createExportController() export.Controller {
+	return export.Controller{Service: ioc.createExportService()}
+}
+
+func (ioc *Container) createImportController() export.ImportController {
+	return export.ImportController{Service: ioc.createImportService()}
+}
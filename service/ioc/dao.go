@@ -1,7 +1,10 @@
 package ioc
 
 import (
+	"github.com/simonesestito/wasaphoto/service/features/account"
+	"github.com/simonesestito/wasaphoto/service/features/auth"
 	"github.com/simonesestito/wasaphoto/service/features/comments"
+	"github.com/simonesestito/wasaphoto/service/features/export"
 	"github.com/simonesestito/wasaphoto/service/features/follow"
 	"github.com/simonesestito/wasaphoto/service/features/likes"
 	"github.com/simonesestito/wasaphoto/service/features/photo"
@@ -9,6 +12,10 @@ import (
 	"github.com/simonesestito/wasaphoto/service/features/user"
 )
 
+func (ioc *Container) createAuthDao() auth.Dao {
+	return auth.DbDao{Db: ioc.database}
+}
+
 func (ioc *Container) createUserDao() user.Dao {
 	return user.DbDao{Db: ioc.database}
 }
@@ -32,3 +39,11 @@ func (ioc *Container) createCommentsDao() comments.Dao {
 func (ioc *Container) createStreamDao() stream.Dao {
 	return stream.DbDao{Db: ioc.database}
 }
+
+func (ioc *Container) createAccountDao() account.Dao {
+	return account.DbDao{Db: ioc.database}
+}
+
+func (ioc *Container) createExportDao() export.Dao {
+	return export.DbDao{Db: ioc.database}
+}
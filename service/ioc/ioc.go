@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/simonesestito/wasaphoto/service/database"
+	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/imaging"
+	"github.com/simonesestito/wasaphoto/service/mailer"
 	"github.com/simonesestito/wasaphoto/service/storage"
 	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
 )
 
 //
@@ -27,6 +33,8 @@ type Container struct {
 	database        database.AppDatabase
 	storageDir      string
 	staticFilesPath string
+	config          Config
+	imageProcessor  imaging.Processor
 
 	// instances collects singleton instances for those
 	// dependencies which need to be a shared instance.
@@ -34,7 +42,30 @@ type Container struct {
 	instances map[string]any
 }
 
-func New(timeProvider timeprovider.TimeProvider, logger *logrus.Logger, rawDatabase *sqlx.DB, storageDir string, staticFilesPath string) (Container, error) {
+// Config collects the optional settings of the app components
+type Config struct {
+	// Oidc configures the login through an external provider.
+	// It's disabled if left empty.
+	Oidc oidc.Config
+
+	// Mail configures how emails are sent
+	Mail mailer.Config
+
+	// PublicUrl is where the users reach the web UI
+	PublicUrl string
+
+	// ExportsDir is where the personal data exports are saved.
+	// It must not be publicly served.
+	ExportsDir string
+
+	// Usernames limits the username changes
+	Usernames user.UsernameConfig
+
+	// Images chooses how the uploaded images are processed
+	Images imaging.Config
+}
+
+func New(timeProvider timeprovider.TimeProvider, logger *logrus.Logger, rawDatabase *sqlx.DB, storageDir string, staticFilesPath string, config Config) (Container, error) {
 	if logger == nil {
 		return Container{}, errors.New("logger is required")
 	}
@@ -48,12 +79,19 @@ func New(timeProvider timeprovider.TimeProvider, logger *logrus.Logger, rawDatab
 		return Container{}, fmt.Errorf("error wrapping database: %w", err)
 	}
 
+	imageProcessor, err := imaging.New(config.Images)
+	if err != nil {
+		return Container{}, fmt.Errorf("error creating image processor: %w", err)
+	}
+
 	return Container{
 		forcedTime:      timeProvider,
 		logger:          logger,
 		database:        appDatabase,
 		storageDir:      storageDir,
 		staticFilesPath: staticFilesPath,
+		config:          config,
+		imageProcessor:  imageProcessor,
 		instances:       make(map[string]any),
 	}, nil
 }
@@ -85,3 +123,45 @@ func (ioc *Container) CreateStorage() storage.Storage {
 	ioc.instances[key] = &newInstance
 	return &newInstance
 }
+
+// createOidcProvider creates a Singleton instance of the oidc.Provider,
+// so that its metadata is fetched only once.
+func (ioc *Container) createOidcProvider() *oidc.Provider {
+	const key = "oidc.Provider"
+	if previousInstance, ok := ioc.instances[key]; ok {
+		castedInstance, ok := previousInstance.(*oidc.Provider)
+		if ok {
+			return castedInstance
+		} else {
+			ioc.logger.Fatalf("Unable to recycle old provider instance in ioc.createOidcProvider")
+		}
+	}
+
+	// Create a new oidc.Provider
+	newInstance := oidc.NewProvider(
+		ioc.config.Oidc,
+		&http.Client{Timeout: 10 * time.Second},
+		ioc.createTimeProvider(),
+	)
+	ioc.instances[key] = newInstance
+	return newInstance
+}
+
+// createMailer creates a Singleton instance of the mailer.Mailer,
+// so that every component sends emails the same way.
+func (ioc *Container) createMailer() mailer.Mailer {
+	const key = "mailer.Mailer"
+	if previousInstance, ok := ioc.instances[key]; ok {
+		castedInstance, ok := previousInstance.(mailer.Mailer)
+		if ok {
+			return castedInstance
+		} else {
+			ioc.logger.Fatalf("Unable to recycle old mailer instance in ioc.createMailer")
+		}
+	}
+
+	// Create a new mailer.Mailer
+	newInstance := mailer.New(ioc.config.Mail)
+	ioc.instances[key] = newInstance
+	return newInstance
+}
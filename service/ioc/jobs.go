@@ -0,0 +1,31 @@
+package ioc
+
+import (
+	"github.com/simonesestito/wasaphoto/service/jobs"
+	"time"
+)
+
+func (ioc *Container) CreateJobs() []jobs.Job {
+	return []jobs.Job{
+		{
+			Name:     "delete-expired-accounts",
+			Interval: time.Hour,
+			Run:      ioc.createAccountService().DeleteExpiredAccounts,
+		},
+		{
+			Name:     "delete-expired-email-tokens",
+			Interval: time.Hour,
+			Run:      ioc.createEmailService().DeleteExpiredTokens,
+		},
+		{
+			Name:     "process-pending-exports",
+			Interval: time.Minute,
+			Run:      ioc.createExportService().ProcessPendingExports,
+		},
+		{
+			Name:     "delete-expired-exports",
+			Interval: time.Hour,
+			Run:      ioc.createExportService().DeleteExpiredExports,
+		},
+	}
+}
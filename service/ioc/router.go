@@ -6,16 +6,35 @@ import (
 )
 
 func (ioc *Container) CreateControllers() []route.Controller {
-	return []route.Controller{
+	controllers := []route.Controller{
 		ioc.createUserController(),
+		ioc.createAvatarController(),
+		ioc.createAccountController(),
+		ioc.createExportController(),
+		ioc.createImportController(),
 		ioc.createLoginController(),
+		ioc.createEmailController(),
+		ioc.createSessionController(),
+		ioc.createTotpController(),
+		ioc.createPersonalTokenController(),
+		ioc.createRoleController(),
 		ioc.createBanController(),
+		ioc.createMuteController(),
+		ioc.createCloseFriendController(),
+		ioc.createRelationshipController(),
 		ioc.createFollowController(),
 		ioc.createPhotoController(),
 		ioc.createLikesController(),
 		ioc.createCommentsController(),
 		ioc.createStreamController(),
 	}
+
+	// Optional controllers, depending on the configuration
+	if ioc.config.Oidc.IsEnabled() {
+		controllers = append(controllers, ioc.createOidcController())
+	}
+
+	return controllers
 }
 
 func (ioc *Container) CreateMiddlewares() []route.Middleware {
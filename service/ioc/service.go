@@ -1,23 +1,94 @@
 package ioc
 
 import (
+	"github.com/simonesestito/wasaphoto/service/features/account"
 	"github.com/simonesestito/wasaphoto/service/features/auth"
 	"github.com/simonesestito/wasaphoto/service/features/comments"
+	"github.com/simonesestito/wasaphoto/service/features/export"
 	"github.com/simonesestito/wasaphoto/service/features/follow"
 	"github.com/simonesestito/wasaphoto/service/features/likes"
 	"github.com/simonesestito/wasaphoto/service/features/photo"
 	"github.com/simonesestito/wasaphoto/service/features/stream"
 	"github.com/simonesestito/wasaphoto/service/features/user"
+	"github.com/simonesestito/wasaphoto/service/imaging"
 )
 
 func (ioc *Container) createAuthService() auth.LoginService {
+	if ioc.config.Oidc.IsEnabled() {
+		return ioc.createExternalAuthService()
+	}
+
+	return ioc.createUserIdAuthService()
+}
+
+func (ioc *Container) createUserIdAuthService() auth.UserIdLoginService {
 	return auth.UserIdLoginService{
+		Db:            ioc.createAuthDao(),
+		Time:          ioc.createTimeProvider(),
+		SecondFactor:  ioc.createTotpService(),
+		Throttle:      ioc.createLoginThrottle(),
+		EmailVerifier: ioc.createEmailService(),
+	}
+}
+
+func (ioc *Container) createEmailService() auth.EmailService {
+	return auth.EmailServiceImpl{
+		Db:        ioc.createAuthDao(),
+		Mailer:    ioc.createMailer(),
+		Time:      ioc.createTimeProvider(),
+		Logger:    ioc.logger,
+		Throttle:  ioc.createLoginThrottle(),
+		PublicUrl: ioc.config.PublicUrl,
+	}
+}
+
+func (ioc *Container) createLoginThrottle() auth.LoginThrottle {
+	return auth.LoginThrottleImpl{
+		Db:   ioc.createAuthDao(),
+		Time: ioc.createTimeProvider(),
+	}
+}
+
+func (ioc *Container) createExternalAuthService() auth.ExternalLoginService {
+	return auth.OidcLoginService{
+		UserIdLoginService: ioc.createUserIdAuthService(),
+		Provider:           ioc.createOidcProvider(),
+		Issuer:             ioc.config.Oidc.Issuer,
+	}
+}
+
+func (ioc *Container) createTotpService() auth.TotpServiceImpl {
+	return auth.TotpServiceImpl{
+		Db:      ioc.createAuthDao(),
 		UserDao: ioc.createUserDao(),
+		Time:    ioc.createTimeProvider(),
+	}
+}
+
+func (ioc *Container) createSessionService() auth.SessionService {
+	return auth.SessionServiceImpl{
+		Db:   ioc.createAuthDao(),
+		Time: ioc.createTimeProvider(),
+	}
+}
+
+func (ioc *Container) createPersonalTokenService() auth.PersonalTokenService {
+	return auth.PersonalTokenServiceImpl{
+		Db:   ioc.createAuthDao(),
+		Time: ioc.createTimeProvider(),
 	}
 }
 
 func (ioc *Container) createUserService() user.Service {
 	return user.ServiceImpl{
+		Db:        ioc.createUserDao(),
+		Time:      ioc.createTimeProvider(),
+		Usernames: ioc.config.Usernames,
+	}
+}
+
+func (ioc *Container) CreateRoleService() user.RoleService {
+	return user.RoleServiceImpl{
 		Db: ioc.createUserDao(),
 	}
 }
@@ -37,33 +108,65 @@ func (ioc *Container) createBanService() user.BanService {
 
 	// Create a new BanService
 	newInstance := user.BanServiceImpl{
-		Db: ioc.createUserDao(),
+		Db:   ioc.createUserDao(),
+		Time: ioc.createTimeProvider(),
 	}
 	ioc.instances[key] = &newInstance
 	return &newInstance
 }
 
+func (ioc *Container) createMuteService() user.MuteService {
+	return user.MuteServiceImpl{
+		Db: ioc.createUserDao(),
+	}
+}
+
+func (ioc *Container) createCloseFriendService() user.CloseFriendService {
+	return user.CloseFriendServiceImpl{
+		Db: ioc.createUserDao(),
+	}
+}
+
+func (ioc *Container) createRelationshipService() user.RelationshipService {
+	return user.RelationshipServiceImpl{
+		Db: ioc.createUserDao(),
+	}
+}
+
 func (ioc *Container) createFollowService() follow.Service {
 	return follow.NewServiceImpl(
 		ioc.createFollowDao(),
 		ioc.createBanService(),
 		ioc.createUserService(),
+		ioc.createTimeProvider(),
 	)
 }
 
 func (ioc *Container) createPhotoService() photo.Service {
 	return photo.ServiceImpl{
-		Db:          ioc.createPhotoDao(),
-		Storage:     ioc.CreateStorage(),
-		UserService: ioc.createUserService(),
-		BanService:  ioc.createBanService(),
+		Db:             ioc.createPhotoDao(),
+		Storage:        ioc.CreateStorage(),
+		ImageProcessor: ioc.createImageProcessor(),
+		UserService:    ioc.createUserService(),
+		BanService:     ioc.createBanService(),
+	}
+}
+
+func (ioc *Container) createAvatarService() user.AvatarService {
+	return user.AvatarServiceImpl{
+		Db:             ioc.createUserDao(),
+		Storage:        ioc.CreateStorage(),
+		ImageProcessor: ioc.createImageProcessor(),
 	}
 }
 
+func (ioc *Container) createImageProcessor() imaging.Processor {
+	return ioc.imageProcessor
+}
+
 func (ioc *Container) createLikesService() likes.Service {
 	return likes.ServiceImpl{
 		Db:           ioc.createLikesDao(),
-		BanService:   ioc.createBanService(),
 		PhotoService: ioc.createPhotoService(),
 	}
 }
@@ -71,7 +174,6 @@ func (ioc *Container) createLikesService() likes.Service {
 func (ioc *Container) createCommentsService() comments.Service {
 	return comments.ServiceImpl{
 		Db:           ioc.createCommentsDao(),
-		BanService:   ioc.createBanService(),
 		PhotoService: ioc.createPhotoService(),
 		TimeProvider: ioc.createTimeProvider(),
 	}
@@ -80,3 +182,35 @@ func (ioc *Container) createCommentsService() comments.Service {
 func (ioc *Container) createStreamService() stream.Service {
 	return stream.ServiceImpl{Db: ioc.createStreamDao()}
 }
+
+func (ioc *Container) createAccountService() account.Service {
+	return account.ServiceImpl{
+		Db:            ioc.createAccountDao(),
+		PhotoService:  ioc.createPhotoService(),
+		AvatarService: ioc.createAvatarService(),
+		ExportService: ioc.createExportService(),
+		Time:          ioc.createTimeProvider(),
+	}
+}
+
+func (ioc *Container) createExportService() export.Service {
+	return export.ServiceImpl{
+		Db:           ioc.createExportDao(),
+		PhotoService: ioc.createPhotoService(),
+		Time:         ioc.createTimeProvider(),
+		Logger:       ioc.logger,
+		Dir:          ioc.config.ExportsDir,
+	}
+}
+
+func (ioc *Container) createImportService() export.ImportService {
+	return export.ImportServiceImpl{
+		Db:                  ioc.createExportDao(),
+		PhotoService:        ioc.createPhotoService(),
+		UserService:         ioc.createUserService(),
+		FollowService:       ioc.createFollowService(),
+		BanService:          ioc.createBanService(),
+		RelationshipService: ioc.createRelationshipService(),
+		Logger:              ioc.logger,
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Job is a task performed periodically in background,
+// like the cleanup of expired data.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Runner runs every job in its own goroutine:
+// once as soon as it starts, then at every interval.
+//
+// A failing job is just logged, and it'll be retried at the next interval.
+type Runner struct {
+	jobs   []Job
+	logger logrus.FieldLogger
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewRunner(jobs []Job, logger logrus.FieldLogger) *Runner {
+	return &Runner{
+		jobs:   jobs,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start starts all the jobs, without waiting for them
+func (runner *Runner) Start() {
+	for _, job := range runner.jobs {
+		runner.wg.Add(1)
+		go runner.loop(job)
+	}
+}
+
+// Close stops the jobs, waiting for the running ones to finish
+func (runner *Runner) Close() {
+	close(runner.stop)
+	runner.wg.Wait()
+}
+
+func (runner *Runner) loop(job Job) {
+	defer runner.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		runner.runOnce(job)
+
+		select {
+		case <-ticker.C:
+		case <-runner.stop:
+			return
+		}
+	}
+}
+
+func (runner *Runner) runOnce(job Job) {
+	logger := runner.logger.WithField("job", job.Name)
+	logger.Debug("Running background job")
+
+	if err := job.Run(); err != nil {
+		logger.WithError(err).Error("background job failed")
+	}
+}
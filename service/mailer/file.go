@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer saves every email in a .eml file, instead of sending it.
+// It's meant for local development.
+type FileMailer struct {
+	Directory string
+	From      string
+}
+
+func (mailer FileMailer) Send(message Message) error {
+	if err := os.MkdirAll(mailer.Directory, os.ModePerm); err != nil {
+		return err
+	}
+
+	fileUuid, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	fileName := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405Z"), fileUuid)
+	return os.WriteFile(filepath.Join(mailer.Directory, fileName), message.format(mailer.From, now), 0o600)
+}
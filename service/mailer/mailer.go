@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"time"
+)
+
+// Mailer sends emails to the users
+type Mailer interface {
+	Send(message Message) error
+}
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Config chooses and configures the Mailer implementation
+type Config struct {
+	// SMTP server to send the emails through.
+	// If empty, emails are not sent, but saved in Directory.
+	SmtpHost     string
+	SmtpPort     int
+	SmtpUsername string
+	SmtpPassword string
+
+	// From is the sender address of every email
+	From string
+
+	// Directory where emails are saved, if no SMTP server is configured.
+	// If empty too, emails are just kept in memory.
+	Directory string
+}
+
+// New creates the Mailer implementation according to the configuration
+func New(config Config) Mailer {
+	switch {
+	case config.SmtpHost != "":
+		return SmtpMailer{
+			Host:     config.SmtpHost,
+			Port:     config.SmtpPort,
+			Username: config.SmtpUsername,
+			Password: config.SmtpPassword,
+			From:     config.From,
+		}
+	case config.Directory != "":
+		return FileMailer{
+			Directory: config.Directory,
+			From:      config.From,
+		}
+	default:
+		return &MemoryMailer{}
+	}
+}
+
+// format builds the RFC 5322 representation of the message
+func (message Message) format(from string, date time.Time) []byte {
+	var buffer bytes.Buffer
+	_, _ = fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	_, _ = fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	_, _ = fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	_, _ = fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(message.Body)
+	return buffer.Bytes()
+}
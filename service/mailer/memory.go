@@ -0,0 +1,26 @@
+package mailer
+
+import "sync"
+
+// MemoryMailer keeps the emails in memory, instead of sending them.
+// It's meant for tests, which can read the sent emails.
+type MemoryMailer struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+func (mailer *MemoryMailer) Send(message Message) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.messages = append(mailer.messages, message)
+	return nil
+}
+
+// Messages returns a copy of all the emails sent so far
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return append([]Message(nil), mailer.messages...)
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SmtpMailer sends emails through an SMTP server.
+// The connection is upgraded with STARTTLS, if the server supports it.
+type SmtpMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (mailer SmtpMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if mailer.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+
+	address := net.JoinHostPort(mailer.Host, strconv.Itoa(mailer.Port))
+	err := smtp.SendMail(address, auth, mailer.From, []string{message.To}, message.format(mailer.From, time.Now()))
+	if err != nil {
+		return fmt.Errorf("sending email through %s: %w", address, err)
+	}
+
+	return nil
+}
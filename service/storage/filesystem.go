@@ -55,6 +55,15 @@ func (fs FilesystemStorage) DeleteFile(path string) error {
 	return nil
 }
 
+func (fs FilesystemStorage) ReadFile(path string) ([]byte, error) {
+	fileToRead := fs.pathForExistingFile(path)
+	if fileToRead == "" {
+		return nil, os.ErrNotExist
+	}
+
+	return os.ReadFile(fileToRead)
+}
+
 func (fs FilesystemStorage) GetRoot() string {
 	return fs.FsStorageRootDir
 }
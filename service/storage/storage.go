@@ -20,6 +20,11 @@ type Storage interface {
 	// It should have been saved using the same Storage implementation.
 	DeleteFile(path string) error
 
+	// ReadFile returns the content of a stored file given its path.
+	// It should have been saved using the same Storage implementation.
+	// If the file doesn't exist, it returns an error satisfying errors.Is(err, fs.ErrNotExist).
+	ReadFile(path string) ([]byte, error)
+
 	// GetRoot returns the root path (on this device or somewhere else) that this storage implementation
 	// is using to save given data.
 	GetRoot() string
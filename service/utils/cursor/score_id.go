@@ -0,0 +1,34 @@
+package cursor
+
+import (
+	"github.com/gofrs/uuid"
+	"math"
+	"strconv"
+)
+
+// ParseScoreIdCursor parses a cursor of results sorted by descending score.
+// The empty cursor starts from the highest possible score.
+func ParseScoreIdCursor(userCursor string) (uuid.UUID, float64, error) {
+	if userCursor == "" {
+		return uuid.Nil, math.MaxFloat64, nil
+	}
+
+	// Parse as a string
+	id, rawScore, err := ParseStringIdCursor(userCursor)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+
+	// Parse score
+	score, err := strconv.ParseFloat(rawScore, 64)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+
+	// Success!
+	return id, score, nil
+}
+
+func CreateScoreIdCursor(id []byte, score float64) string {
+	return CreateStringIdCursor(id, strconv.FormatFloat(score, 'g', -1, 64))
+}
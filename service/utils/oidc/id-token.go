@@ -0,0 +1,227 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when an ID token cannot be trusted
+var ErrInvalidToken = errors.New("invalid ID token")
+
+// clockSkew is the tolerance used checking the token expiration
+const clockSkew = time.Minute
+
+// Claims are the used claims of an ID token
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	Expiration        int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+	GivenName         string   `json:"given_name"`
+	FamilyName        string   `json:"family_name"`
+}
+
+// audience is the "aud" claim, which can be a single string or an array
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*aud = multiple
+	return nil
+}
+
+func (aud audience) contains(clientId string) bool {
+	for _, value := range aud {
+		if value == clientId {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyIdToken checks the signature and the claims of an ID token,
+// as described in OpenID Connect Core, section 3.1.3.7
+func (provider *Provider) VerifyIdToken(rawToken string, nonce string) (Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+
+	// Check header
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyId     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, err
+	}
+	if header.Algorithm != "RS256" {
+		return Claims{}, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidToken, header.Algorithm)
+	}
+
+	// Check signature
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	key, err := provider.getKey(header.KeyId)
+	if err != nil {
+		return Claims{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return Claims{}, fmt.Errorf("%w: wrong signature", ErrInvalidToken)
+	}
+
+	// Check claims
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, err
+	}
+
+	now := provider.time.Now()
+	switch {
+	case claims.Issuer != provider.config.Issuer:
+		return Claims{}, fmt.Errorf("%w: wrong issuer", ErrInvalidToken)
+	case !claims.Audience.contains(provider.config.ClientId):
+		return Claims{}, fmt.Errorf("%w: wrong audience", ErrInvalidToken)
+	case now.Add(-clockSkew).After(time.Unix(claims.Expiration, 0)):
+		return Claims{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	case now.Add(clockSkew).Before(time.Unix(claims.IssuedAt, 0)):
+		return Claims{}, fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	case claims.Nonce != nonce:
+		return Claims{}, fmt.Errorf("%w: wrong nonce", ErrInvalidToken)
+	case claims.Subject == "":
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, result any) error {
+	rawJson, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := json.Unmarshal(rawJson, result); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return nil
+}
+
+// keySet maps the key IDs to the RSA public keys of the provider
+type keySet map[string]*rsa.PublicKey
+
+// keysRefreshInterval is how often the keys can be fetched again
+// because a token has been signed with an unknown one.
+// It prevents forged tokens from causing a request to the provider every time.
+const keysRefreshInterval = 5 * time.Minute
+
+// getKey returns the key used to sign a token.
+// The keys are fetched again if the key is unknown, since the provider may have rotated them,
+// but at most once every keysRefreshInterval.
+func (provider *Provider) getKey(keyId string) (*rsa.PublicKey, error) {
+	provider.keysMutex.Lock()
+	defer provider.keysMutex.Unlock()
+
+	key, found := provider.keys.find(keyId)
+	if found {
+		return key, nil
+	}
+
+	now := provider.time.Now()
+	if provider.keys == nil || now.Sub(provider.keysFetchDate) >= keysRefreshInterval {
+		discovery, err := provider.getDiscovery()
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := provider.fetchKeys(discovery.JwksUri)
+		if err != nil {
+			return nil, err
+		}
+		provider.keys = keys
+		provider.keysFetchDate = now
+	}
+
+	key, found = provider.keys.find(keyId)
+	if !found {
+		return nil, fmt.Errorf("%w: unknown key %s", ErrInvalidToken, keyId)
+	}
+	return key, nil
+}
+
+// find looks for a key by ID.
+// If the token doesn't specify the key ID, it's allowed only if there is just one key.
+func (keys keySet) find(keyId string) (*rsa.PublicKey, bool) {
+	if keyId == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, found := keys[keyId]
+	return key, found
+}
+
+func (provider *Provider) fetchKeys(jwksUri string) (keySet, error) {
+	var jwks struct {
+		Keys []struct {
+			KeyType  string `json:"kty"`
+			KeyId    string `json:"kid"`
+			Use      string `json:"use"`
+			Modulus  string `json:"n"`
+			Exponent string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := provider.getJson(jwksUri, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(keySet)
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		modulus, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			continue
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.KeyId] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,195 @@
+// Package oidctest provides a minimal OpenID Connect provider,
+// running on a local HTTP server, to test the login flow without a real one.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Provider is a stub OpenID Connect provider.
+// It issues an authorization code for every authorization URL passed to Authorize,
+// as if the user logged in successfully, and redeems it on its token endpoint
+// checking the PKCE code verifier.
+type Provider struct {
+	*httptest.Server
+
+	ClientId string
+	KeyId    string
+	Key      *rsa.PrivateKey
+
+	// Issuer is announced in the discovery document. By default, it's the server URL
+	Issuer string
+
+	mutex        sync.Mutex
+	codes        map[string]authorization
+	jwksRequests int
+}
+
+// authorization is what the provider remembers about an issued code
+type authorization struct {
+	codeChallenge string
+	claims        map[string]any
+}
+
+// NewProvider starts a new stub provider, which must be closed at the end of the test
+func NewProvider(clientId string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &Provider{
+		ClientId: clientId,
+		KeyId:    "test-key",
+		Key:      key,
+		codes:    make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.handleDiscovery)
+	mux.HandleFunc("/jwks", provider.handleJwks)
+	mux.HandleFunc("/token", provider.handleToken)
+	provider.Server = httptest.NewServer(mux)
+	provider.Issuer = provider.URL
+
+	return provider, nil
+}
+
+// Claims returns the claims of a valid ID token for the given subject, issued at the given time
+func (provider *Provider) Claims(subject string, now time.Time) map[string]any {
+	return map[string]any{
+		"iss": provider.URL,
+		"sub": subject,
+		"aud": provider.ClientId,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// Authorize simulates a successful login on the given authorization URL,
+// returning the authorization code the user is sent back with.
+// The ID token will have the given claims, plus the nonce of the URL if not specified.
+func (provider *Provider) Authorize(authorizationUrl string, claims map[string]any) (string, error) {
+	parsedUrl, err := url.Parse(authorizationUrl)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsedUrl.Query()
+	if query.Get("client_id") != provider.ClientId || query.Get("code_challenge_method") != "S256" {
+		return "", errors.New("unexpected authorization request")
+	}
+
+	tokenClaims := make(map[string]any, len(claims)+1)
+	tokenClaims["nonce"] = query.Get("nonce")
+	for key, value := range claims {
+		tokenClaims[key] = value
+	}
+
+	code := randomString()
+	provider.mutex.Lock()
+	provider.codes[code] = authorization{
+		codeChallenge: query.Get("code_challenge"),
+		claims:        tokenClaims,
+	}
+	provider.mutex.Unlock()
+
+	return code, nil
+}
+
+// SignIdToken builds an ID token with the given claims, signed with RS256 by the provider key
+func (provider *Provider) SignIdToken(claims map[string]any) string {
+	return SignIdToken(claims, provider.Key, provider.KeyId)
+}
+
+// SignIdToken builds an ID token with the given claims, signed with RS256 by the given key
+func SignIdToken(claims map[string]any, key *rsa.PrivateKey, keyId string) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyId})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (provider *Provider) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, map[string]string{
+		"issuer":                 provider.Issuer,
+		"authorization_endpoint": provider.URL + "/authorize",
+		"token_endpoint":         provider.URL + "/token",
+		"jwks_uri":               provider.URL + "/jwks",
+	})
+}
+
+// JwksRequests returns how many times the key set has been downloaded
+func (provider *Provider) JwksRequests() int {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	return provider.jwksRequests
+}
+
+func (provider *Provider) handleJwks(w http.ResponseWriter, _ *http.Request) {
+	provider.mutex.Lock()
+	provider.jwksRequests++
+	provider.mutex.Unlock()
+
+	publicKey := provider.Key.PublicKey
+	writeJson(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": provider.KeyId,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// handleToken redeems an authorization code, which can be used only once
+func (provider *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	provider.mutex.Lock()
+	found, exists := provider.codes[r.PostForm.Get("code")]
+	delete(provider.codes, r.PostForm.Get("code"))
+	provider.mutex.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !exists || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != found.codeChallenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	writeJson(w, map[string]string{
+		"token_type": "Bearer",
+		"id_token":   provider.SignIdToken(found.claims),
+	})
+}
+
+func writeJson(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func randomString() string {
+	randomBytes := make([]byte, 32)
+	_, _ = rand.Read(randomBytes)
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
@@ -0,0 +1,182 @@
+// Package oidc implements the client side of the OpenID Connect
+// authorization code flow, with PKCE, to log in through an external provider.
+//
+// Only the features needed by this app are implemented:
+// discovery, the authorization URL, the code exchange
+// and the verification of RS256 signed ID tokens.
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes how to reach the provider,
+// and how this app is registered on it.
+type Config struct {
+	// Issuer is the URL of the provider, used to find its discovery document
+	Issuer string
+
+	ClientId     string
+	ClientSecret string // Optional, for confidential clients
+
+	// RedirectUrl is where the provider sends the user back after the login
+	RedirectUrl string
+}
+
+// IsEnabled reports whether the configuration has been filled
+func (config Config) IsEnabled() bool {
+	return config.Issuer != "" && config.ClientId != ""
+}
+
+// ErrProvider is returned when the provider cannot be reached or it responds in an unexpected way
+var ErrProvider = errors.New("unexpected response from OpenID provider")
+
+// discoveryDocument holds the used fields of /.well-known/openid-configuration
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider.
+// Its metadata is fetched lazily and cached, so it's safe to create it
+// even if the provider is not reachable yet.
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+	time       timeprovider.TimeProvider
+
+	mutex     sync.Mutex
+	discovery *discoveryDocument
+
+	// keysMutex is held while the keys are fetched, so that they're downloaded once at a time
+	keysMutex     sync.Mutex
+	keys          keySet
+	keysFetchDate time.Time
+}
+
+func NewProvider(config Config, httpClient *http.Client, time timeprovider.TimeProvider) *Provider {
+	return &Provider{
+		config:     config,
+		httpClient: httpClient,
+		time:       time,
+	}
+}
+
+// getDiscovery returns the discovery document, fetching it the first time
+func (provider *Provider) getDiscovery() (*discoveryDocument, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	discoveryUrl := strings.TrimSuffix(provider.config.Issuer, "/") + "/.well-known/openid-configuration"
+	document := &discoveryDocument{}
+	if err := provider.getJson(discoveryUrl, document); err != nil {
+		return nil, err
+	}
+
+	// As required by OpenID Connect Discovery, section 4.3
+	if document.Issuer != provider.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch in discovery document (%s)", ErrProvider, document.Issuer)
+	}
+
+	provider.discovery = document
+	return document, nil
+}
+
+// AuthorizationUrl builds the URL where the user must be sent to log in
+func (provider *Provider) AuthorizationUrl(state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.config.ClientId)
+	query.Set("redirect_uri", provider.config.RedirectUrl)
+	query.Set("scope", "openid profile email")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// ExchangeCode redeems the authorization code received on the redirect URL,
+// returning the verified claims of the ID token.
+func (provider *Provider) ExchangeCode(code string, codeVerifier string, nonce string) (Claims, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.config.RedirectUrl)
+	form.Set("client_id", provider.config.ClientId)
+	form.Set("code_verifier", codeVerifier)
+	if provider.config.ClientSecret != "" {
+		form.Set("client_secret", provider.config.ClientSecret)
+	}
+
+	response, err := provider.httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		// Usually, the code is invalid or expired
+		return Claims{}, ErrInvalidToken
+	}
+
+	var tokenResponse struct {
+		IdToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(&tokenResponse); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	return provider.VerifyIdToken(tokenResponse.IdToken, nonce)
+}
+
+// maxResponseSize limits how much is read from the provider responses
+const maxResponseSize = 1024 * 1024
+
+func (provider *Provider) getJson(url string, result any) error {
+	response, err := provider.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d from %s", ErrProvider, response.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(result); err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	return nil
+}
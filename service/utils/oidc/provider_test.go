@@ -0,0 +1,291 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simonesestito/wasaphoto/service/timeprovider"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc"
+	"github.com/simonesestito/wasaphoto/service/utils/oidc/oidctest"
+)
+
+const testClientId = "wasaphoto"
+
+var testNow = time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+
+	stub, err := oidctest.NewProvider(testClientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stub.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      stub.URL,
+		ClientId:    testClientId,
+		RedirectUrl: "http://localhost/",
+	}, stub.Client(), timeprovider.MockTimeProvider{MockTime: testNow})
+
+	return stub, provider
+}
+
+func TestAuthorizationUrl(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	authorizationUrl, err := provider.AuthorizationUrl("the-state", "the-nonce", oidc.CodeChallenge("the-verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedUrl, err := url.Parse(authorizationUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedUrl.Scheme+"://"+parsedUrl.Host+parsedUrl.Path != stub.URL+"/authorize" {
+		t.Errorf("unexpected authorization endpoint %s", authorizationUrl)
+	}
+
+	query := parsedUrl.Query()
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientId,
+		"redirect_uri":          "http://localhost/",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        oidc.CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("%s = %q, expected %q", key, query.Get(key), value)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	stub, provider := newTestProvider(t)
+	stub.Issuer = "https://evil.example.com"
+
+	_, err := provider.AuthorizationUrl("state", "nonce", "challenge")
+	if !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	challenge := oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected code challenge %s", challenge)
+	}
+}
+
+func TestExchangeCode(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	authorizationUrl, err := provider.AuthorizationUrl("state", "the-nonce", oidc.CodeChallenge("the-verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := stub.Claims("subject-1", testNow)
+	claims["preferred_username"] = "john_doe"
+	code, err := stub.Authorize(authorizationUrl, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := provider.ExchangeCode(code, "the-verifier", "the-nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Subject != "subject-1" || result.PreferredUsername != "john_doe" {
+		t.Errorf("unexpected claims %+v", result)
+	}
+
+	// A code can be redeemed once
+	if _, err := provider.ExchangeCode(code, "the-verifier", "the-nonce"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken reusing the code, got %v", err)
+	}
+}
+
+func TestExchangeCodeWrongVerifier(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	authorizationUrl, err := provider.AuthorizationUrl("state", "nonce", oidc.CodeChallenge("the-verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := stub.Authorize(authorizationUrl, stub.Claims("subject-1", testNow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.ExchangeCode(code, "another-verifier", "nonce"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestVerifyIdToken(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		token func(stub *oidctest.Provider, claims map[string]any) string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			token: (*oidctest.Provider).SignIdToken,
+			valid: true,
+		},
+		{
+			name: "audience array",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["aud"] = []string{"other", testClientId}
+				return stub.SignIdToken(claims)
+			},
+			valid: true,
+		},
+		{
+			name: "wrong signature",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				return oidctest.SignIdToken(claims, otherKey, stub.KeyId)
+			},
+		},
+		{
+			name: "unknown key",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				return oidctest.SignIdToken(claims, otherKey, "other-key")
+			},
+		},
+		{
+			name: "unsigned",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				parts := strings.Split(stub.SignIdToken(claims), ".")
+				header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+				return header + "." + parts[1] + "."
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["iss"] = "https://evil.example.com"
+				return stub.SignIdToken(claims)
+			},
+		},
+		{
+			name: "wrong audience",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["aud"] = "another-client"
+				return stub.SignIdToken(claims)
+			},
+		},
+		{
+			name: "wrong nonce",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["nonce"] = "another-nonce"
+				return stub.SignIdToken(claims)
+			},
+		},
+		{
+			name: "expired",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["exp"] = testNow.Add(-2 * time.Minute).Unix()
+				return stub.SignIdToken(claims)
+			},
+		},
+		{
+			name: "expired within clock skew",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["exp"] = testNow.Add(-30 * time.Second).Unix()
+				return stub.SignIdToken(claims)
+			},
+			valid: true,
+		},
+		{
+			name: "issued in the future",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				claims["iat"] = testNow.Add(5 * time.Minute).Unix()
+				return stub.SignIdToken(claims)
+			},
+		},
+		{
+			name: "missing subject",
+			token: func(stub *oidctest.Provider, claims map[string]any) string {
+				delete(claims, "sub")
+				return stub.SignIdToken(claims)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stub, provider := newTestProvider(t)
+
+			claims := stub.Claims("subject-1", testNow)
+			claims["nonce"] = "the-nonce"
+
+			_, err := provider.VerifyIdToken(testCase.token(stub, claims), "the-nonce")
+			switch {
+			case testCase.valid && err != nil:
+				t.Errorf("expected a valid token, got %v", err)
+			case !testCase.valid && !errors.Is(err, oidc.ErrInvalidToken):
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeysRefreshInterval(t *testing.T) {
+	stub, err := oidctest.NewProvider(testClientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stub.Close)
+
+	clock := &timeprovider.MockTimeProvider{MockTime: testNow}
+	provider := oidc.NewProvider(oidc.Config{Issuer: stub.URL, ClientId: testClientId}, stub.Client(), clock)
+
+	// verify checks a token signed with the given key ID, then how many times the keys have been downloaded
+	verify := func(keyId string, expectedRequests int) {
+		t.Helper()
+
+		claims := stub.Claims("subject-1", clock.Now())
+		claims["nonce"] = "nonce"
+		_, err := provider.VerifyIdToken(oidctest.SignIdToken(claims, stub.Key, keyId), "nonce")
+		if keyId == stub.KeyId && err != nil {
+			t.Errorf("expected a valid token, got %v", err)
+		} else if keyId != stub.KeyId && !errors.Is(err, oidc.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+
+		if stub.JwksRequests() != expectedRequests {
+			t.Errorf("keys downloaded %d times, expected %d", stub.JwksRequests(), expectedRequests)
+		}
+	}
+
+	verify(stub.KeyId, 1)
+	verify(stub.KeyId, 1)
+
+	// Tokens with an unknown key don't make the keys be downloaded every time
+	verify("forged-key", 1)
+	verify("forged-key", 1)
+
+	clock.MockTime = clock.MockTime.Add(5 * time.Minute)
+	verify("forged-key", 2)
+	verify("forged-key", 2)
+	verify(stub.KeyId, 2)
+}
@@ -0,0 +1,35 @@
+package securetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// tokenBytes is the amount of random bytes in a token,
+// before its encoding as a string.
+const tokenBytes = 32
+
+// New generates a new random opaque token to send to the client,
+// along with its hash, which is the only value to store server side.
+//
+// In this way, even if someone can read the database,
+// he won't be able to impersonate any user.
+func New() (token string, tokenHash []byte, err error) {
+	randomBytes := make([]byte, tokenBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", nil, err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(randomBytes)
+	return token, Hash(token), nil
+}
+
+// Hash computes the hash to store and to look up a token.
+//
+// A fast hash function is fine here,
+// since the token is already random and long enough to not be guessable.
+func Hash(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
@@ -6,46 +6,42 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/simonesestito/wasaphoto/service/api"
-	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
 	"net/url"
 )
 
-// In a real project, always use config file for that (not git committed)
-const apiToken = "lHLbzbX5M7v4NqtPVPzjqqqY4zJvHgy8"
-
 type API struct {
+	// Token is the API key of the TinyPNG account
+	Token string
 }
 
-func (imgApi API) CompressPhoto(imageData []byte, logger logrus.FieldLogger) ([]byte, error) {
-	logger.Debugln("Uploading photo...")
-	imageId, err := imgApi.uploadPhoto(imageData)
-	if err != nil {
-		return nil, err
-	}
-
-	logger.Debugln("Converting to WebP...")
-	webp, err := imgApi.convertToWebp(imageId)
-	if err != nil {
-		logger.WithError(err).Errorln("Conversion to WebP failed")
-		return nil, err
-	}
+// Upload is an image uploaded to TinyPNG, which can be converted many times
+type Upload struct {
+	Location string
+	Width    int
+	Height   int
+}
 
-	logger.Debugln("Conversion to WebP successful")
-	return webp, nil
+// uploadResponse is the relevant part of the JSON response of the upload
+type uploadResponse struct {
+	Output struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"output"`
 }
 
-func (API) getAuthString() string {
-	authString := "api:" + apiToken
+func (imgApi API) getAuthString() string {
+	authString := "api:" + imgApi.Token
 	authDigest := base64.StdEncoding.EncodeToString([]byte(authString))
 	return "Basic " + authDigest
 }
 
-func (imgApi API) uploadPhoto(imageData []byte) (string, error) {
+// UploadPhoto sends the image to TinyPNG, which compresses it
+func (imgApi API) UploadPhoto(imageData []byte) (Upload, error) {
 	apiUrl, err := url.Parse("https://api.tinify.com/shrink")
 	if err != nil {
-		return "", err
+		return Upload{}, err
 	}
 
 	request := &http.Request{
@@ -62,34 +58,50 @@ func (imgApi API) uploadPhoto(imageData []byte) (string, error) {
 
 	response, err := http.DefaultClient.Do(request)
 	if err != nil {
-		return "", err
+		return Upload{}, err
 	}
 
 	defer response.Body.Close()
 
 	switch response.StatusCode {
 	case http.StatusCreated:
-		return response.Header.Get("Location"), nil
+		var body uploadResponse
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			return Upload{}, err
+		} else if body.Output.Width <= 0 || body.Output.Height <= 0 {
+			return Upload{}, errors.New("TinyPNG API response without the image size")
+		}
+		return Upload{
+			Location: response.Header.Get("Location"),
+			Width:    body.Output.Width,
+			Height:   body.Output.Height,
+		}, nil
 	case http.StatusUnsupportedMediaType:
-		return "", api.ErrMedia
+		return Upload{}, api.ErrMedia
 	default:
-		return "", errors.New("TinyPNG API status code response: " + response.Status)
+		return Upload{}, errors.New("TinyPNG API status code response: " + response.Status)
 	}
 }
 
-func (imgApi API) convertToWebp(imageId string) ([]byte, error) {
-	apiUrl, err := url.Parse(imageId)
+// ConvertToWebp downloads the uploaded image as WebP.
+// If width is zero, the image keeps its original size.
+func (imgApi API) ConvertToWebp(upload Upload, width int) ([]byte, error) {
+	apiUrl, err := url.Parse(upload.Location)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonBody, err := json.Marshal(map[string]any{
+	options := map[string]any{
 		"convert": map[string]string{"type": "image/webp"},
-		"resize": map[string]any{
+	}
+	if width > 0 {
+		options["resize"] = map[string]any{
 			"method": "scale",
-			"width":  500,
-		},
-	})
+			"width":  width,
+		}
+	}
+
+	jsonBody, err := json.Marshal(options)
 	if err != nil {
 		return nil, err
 	}
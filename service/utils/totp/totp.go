@@ -0,0 +1,123 @@
+// Package totp implements Time-based One-Time Passwords, as described in RFC 6238,
+// with the default parameters used by authenticator apps: HMAC-SHA1, 6 digits, 30 seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// SecretSize is the length in bytes of a generated secret (160 bits, as suggested by RFC 4226)
+	SecretSize = 20
+
+	// Digits is the length of a generated code
+	Digits = 6
+
+	// Period is the time step, how long a single code is valid for
+	Period = 30 * time.Second
+
+	// Skew is the number of time steps accepted before and after the current one,
+	// to tolerate clock drift and network delays.
+	Skew = 1
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret creates a new random shared secret
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// EncodeSecret returns the secret in the base32 form accepted by authenticator apps
+func EncodeSecret(secret []byte) string {
+	return secretEncoding.EncodeToString(secret)
+}
+
+// ProvisioningUri builds the otpauth:// URI to be shown as a QR code to the user
+func ProvisioningUri(issuer string, accountName string, secret []byte) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TimeStep returns the counter value of the time step containing the given instant
+func TimeStep(now time.Time) int64 {
+	return now.Unix() / int64(Period.Seconds())
+}
+
+// Code computes the code for the given secret and time step (RFC 4226, section 5.3)
+func Code(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%modulo)
+}
+
+// NormalizeCode removes the spaces and the dashes users may type
+// to group the digits, or copy along with the code.
+func NormalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// Validate checks the code supplied by the user at the given time.
+//
+// To prevent replay attacks, codes belonging to a time step
+// not after lastUsedStep are rejected.
+// On success, it returns the time step the code belongs to,
+// which must be saved as the new lastUsedStep.
+func Validate(secret []byte, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = NormalizeCode(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	currentStep := TimeStep(now)
+	for step := currentStep - Skew; step <= currentStep+Skew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		if hmac.Equal([]byte(Code(secret, step)), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors in RFC 6238, appendix B
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	// The RFC lists 8 digits codes: the last 6 digits are the same
+	testCases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unixTime, expected := range testCases {
+		if code := Code(rfcSecret, TimeStep(time.Unix(unixTime, 0))); code != expected {
+			t.Errorf("code at %d is %s, expected %s", unixTime, code, expected)
+		}
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	currentStep := TimeStep(now)
+
+	testCases := []struct {
+		step  int64
+		valid bool
+	}{
+		{currentStep - 2, false},
+		{currentStep - 1, true},
+		{currentStep, true},
+		{currentStep + 1, true},
+		{currentStep + 2, false},
+	}
+
+	for _, testCase := range testCases {
+		usedStep, valid := Validate(rfcSecret, Code(rfcSecret, testCase.step), now, 0)
+		if valid != testCase.valid {
+			t.Errorf("code of step %+d: valid = %v, expected %v", testCase.step-currentStep, valid, testCase.valid)
+		} else if valid && usedStep != testCase.step {
+			t.Errorf("code of step %+d: used step %d, expected %d", testCase.step-currentStep, usedStep, testCase.step)
+		}
+	}
+}
+
+func TestValidateReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := Code(rfcSecret, TimeStep(now))
+
+	usedStep, valid := Validate(rfcSecret, code, now, 0)
+	if !valid {
+		t.Fatal("expected a valid code")
+	}
+
+	// The same code, or an older one, can't be used again
+	if _, valid := Validate(rfcSecret, code, now, usedStep); valid {
+		t.Error("expected the same code to be rejected")
+	}
+	if _, valid := Validate(rfcSecret, Code(rfcSecret, usedStep-1), now, usedStep); valid {
+		t.Error("expected an older code to be rejected")
+	}
+
+	// The next one is still accepted
+	if _, valid := Validate(rfcSecret, Code(rfcSecret, usedStep+1), now, usedStep); !valid {
+		t.Error("expected the next code to be accepted")
+	}
+}
+
+func TestValidateFormatting(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	testCases := map[string]bool{
+		"050471":       true,
+		"050 471":      true,
+		" 050471\n":    true,
+		"050-471":      true,
+		"\t050 471 ":   true,
+		"05047":        false,
+		"0504711":      false,
+		"050471a":      false,
+		"":             false,
+		"      ":       false,
+		"050_471":      false,
+		"050\u00a0471": true,
+	}
+
+	for code, expected := range testCases {
+		if _, valid := Validate(rfcSecret, code, now, 0); valid != expected {
+			t.Errorf("code %q: valid = %v, expected %v", code, valid, expected)
+		}
+	}
+}
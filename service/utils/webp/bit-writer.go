@@ -0,0 +1,29 @@
+package webp
+
+// bitWriter packs values LSB first, as required by the VP8L bitstream
+type bitWriter struct {
+	buffer      []byte
+	accumulator uint64
+	bitsCount   uint
+}
+
+// writeBits appends the n least significant bits of value, with n <= 32
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	w.accumulator |= uint64(value&(1<<n-1)) << w.bitsCount
+	w.bitsCount += n
+	for w.bitsCount >= 8 {
+		w.buffer = append(w.buffer, byte(w.accumulator))
+		w.accumulator >>= 8
+		w.bitsCount -= 8
+	}
+}
+
+// bytes flushes the pending bits, padding them to a byte
+func (w *bitWriter) bytes() []byte {
+	if w.bitsCount > 0 {
+		w.buffer = append(w.buffer, byte(w.accumulator))
+		w.accumulator = 0
+		w.bitsCount = 0
+	}
+	return w.buffer
+}
@@ -0,0 +1,239 @@
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+//
+// Encoder of lossless WebP images (VP8L bitstream), since neither the standard library
+// nor golang.org/x/image provide one.
+//
+// Only a subset of the format is used: the subtract green and the predictor transforms,
+// followed by prefix coded pixels, where the only backward references are runs of the same pixel.
+// The color cache is not used.
+// It's enough to get a good compression for a lossless format, keeping the encoder simple.
+//
+// Being lossless, the output is much larger than a lossy encoding of the same image:
+// a 500 pixels wide photo takes about 200-300 KB, usually more than the JPEG it comes from.
+//
+// Format specification: https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+//
+
+// MaxDimension is the maximum width or height of a WebP lossless image
+const MaxDimension = 1 << 14
+
+// predictorBits is the log2 of the size of the blocks sharing the same predictor
+const predictorBits = 4
+
+// Transform types
+const (
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+)
+
+// Predictor modes used by the encoder, among the 14 defined by the format
+const (
+	predictorLeft             = 1
+	predictorTop              = 2
+	predictorAverageLeftTop   = 7
+	predictorClampAddSubtract = 12
+)
+
+var predictorModes = []int{predictorLeft, predictorTop, predictorAverageLeftTop, predictorClampAddSubtract}
+
+// Alphabet sizes of the 5 prefix codes of a group:
+// green plus length prefixes, red, blue, alpha and distance prefixes
+var alphabetSizes = [5]int{256 + 24, 256, 256, 256, 40}
+
+// Limits of the length of a backward reference
+const (
+	minRunLength = 3
+	maxRunLength = 4096
+)
+
+// leftPixelDistance is the distance code of the pixel on the left,
+// in the distance map defined by the format
+const leftPixelDistance = 2
+
+var errTooBig = errors.New("webp: image is too big")
+
+// Encode writes the image to w in the lossless WebP format
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return errors.New("webp: empty image")
+	} else if width > MaxDimension || height > MaxDimension {
+		return errTooBig
+	}
+
+	pixels, hasAlpha := toArgb(img)
+
+	bits := &bitWriter{}
+	bits.writeBits(0x2f, 8) // Signature
+	bits.writeBits(uint32(width-1), 14)
+	bits.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bits.writeBits(1, 1)
+	} else {
+		bits.writeBits(0, 1)
+	}
+	bits.writeBits(0, 3) // Version
+
+	bits.writeBits(1, 1)
+	bits.writeBits(transformSubtractGreen, 2)
+	subtractGreen(pixels)
+
+	bits.writeBits(1, 1)
+	bits.writeBits(transformPredictor, 2)
+	bits.writeBits(predictorBits-2, 3)
+	modes := choosePredictorModes(pixels, width, height)
+	writeEntropyCodedImage(bits, modes, false)
+	pixels = predictorResiduals(pixels, width, height, modes)
+
+	bits.writeBits(0, 1) // No more transforms
+	writeEntropyCodedImage(bits, pixels, true)
+
+	return writeRiff(w, bits.bytes())
+}
+
+// toArgb converts the image to non-premultiplied ARGB pixels,
+// telling if any of them is not fully opaque
+func toArgb(img image.Image) ([]uint32, bool) {
+	bounds := img.Bounds()
+	pixels := make([]uint32, 0, bounds.Dx()*bounds.Dy())
+	hasAlpha := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			hasAlpha = hasAlpha || c.A != 0xff
+			pixels = append(pixels, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return pixels, hasAlpha
+}
+
+func subtractGreen(pixels []uint32) {
+	for i, argb := range pixels {
+		green := (argb >> 8) & 0xff
+		red := ((argb >> 16) - green) & 0xff
+		blue := (argb - green) & 0xff
+		pixels[i] = argb&0xff00ff00 | red<<16 | blue
+	}
+}
+
+func writeRiff(w io.Writer, vp8l []byte) error {
+	padding := len(vp8l) % 2
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(12+len(vp8l)+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(vp8l)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(vp8l); err != nil {
+		return err
+	}
+	if padding > 0 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// writeEntropyCodedImage writes the pixels using a single group of prefix codes.
+// The main image also declares that there are no meta prefix codes.
+func writeEntropyCodedImage(bits *bitWriter, pixels []uint32, isMainImage bool) {
+	bits.writeBits(0, 1) // No color cache
+	if isMainImage {
+		bits.writeBits(0, 1) // No meta prefix codes
+	}
+
+	symbols := findRuns(pixels)
+
+	var histograms [5][]uint32
+	for i, size := range alphabetSizes {
+		histograms[i] = make([]uint32, size)
+	}
+	distancePrefix, _, _ := prefixEncode(leftPixelDistance)
+	for _, symbol := range symbols {
+		if symbol.runLength > 0 {
+			lengthPrefix, _, _ := prefixEncode(symbol.runLength)
+			histograms[0][256+lengthPrefix]++
+			histograms[4][distancePrefix]++
+		} else {
+			histograms[0][(symbol.argb>>8)&0xff]++
+			histograms[1][(symbol.argb>>16)&0xff]++
+			histograms[2][symbol.argb&0xff]++
+			histograms[3][symbol.argb>>24]++
+		}
+	}
+
+	var codes [5]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bits, histogram)
+	}
+
+	for _, symbol := range symbols {
+		if symbol.runLength > 0 {
+			lengthPrefix, extraBits, extraValue := prefixEncode(symbol.runLength)
+			codes[0].write(bits, 256+lengthPrefix)
+			bits.writeBits(uint32(extraValue), uint(extraBits))
+			codes[4].write(bits, distancePrefix)
+		} else {
+			codes[0].write(bits, int((symbol.argb>>8)&0xff))
+			codes[1].write(bits, int((symbol.argb>>16)&0xff))
+			codes[2].write(bits, int(symbol.argb&0xff))
+			codes[3].write(bits, int(symbol.argb>>24))
+		}
+	}
+}
+
+// pixelSymbol is either a literal pixel or a run repeating the previous one
+type pixelSymbol struct {
+	argb      uint32
+	runLength int
+}
+
+func findRuns(pixels []uint32) []pixelSymbol {
+	symbols := make([]pixelSymbol, 0, len(pixels))
+	for i := 0; i < len(pixels); {
+		runLength := 0
+		for i > 0 && i+runLength < len(pixels) && runLength < maxRunLength && pixels[i+runLength] == pixels[i-1] {
+			runLength++
+		}
+
+		if runLength >= minRunLength {
+			symbols = append(symbols, pixelSymbol{runLength: runLength})
+			i += runLength
+		} else {
+			symbols = append(symbols, pixelSymbol{argb: pixels[i]})
+			i++
+		}
+	}
+	return symbols
+}
+
+// prefixEncode splits a length or distance value in its prefix symbol and the extra bits
+func prefixEncode(value int) (prefix int, extraBits int, extraValue int) {
+	if value <= 4 {
+		return value - 1, 0, 0
+	}
+
+	value--
+	highestBit := 0
+	for (value >> (highestBit + 1)) > 0 {
+		highestBit++
+	}
+	secondHighestBit := (value >> (highestBit - 1)) & 1
+	extraBits = highestBit - 1
+	extraValue = value & ((1 << extraBits) - 1)
+	return 2*highestBit + secondHighestBit, extraBits, extraValue
+}
@@ -0,0 +1,127 @@
+package webp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+// testImage draws a photo-like image: smooth gradients, a flat area (for runs)
+// and some noise, so that every predictor and every symbol kind is used.
+// If withAlpha is set, the transparency changes across the image too.
+func testImage(width int, height int, withAlpha bool) *image.NRGBA {
+	random := rand.New(rand.NewSource(int64(width*31 + height)))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{
+				R: uint8(x * 255 / width),
+				G: uint8(y * 255 / height),
+				B: uint8(128 + 127*math.Sin(float64(x+y)/7)),
+				A: 0xff,
+			}
+			switch {
+			case x < width/3 && y < height/3:
+				c = color.NRGBA{R: 0x20, G: 0x40, B: 0x60, A: 0xff}
+			case x > 2*width/3:
+				c.R ^= uint8(random.Intn(16))
+				c.G ^= uint8(random.Intn(16))
+			}
+			if withAlpha {
+				c.A = uint8((x + y) * 255 / (width + height))
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	sizes := []image.Point{{1, 1}, {2, 3}, {16, 16}, {17, 33}, {100, 7}, {500, 375}}
+
+	for _, size := range sizes {
+		for _, withAlpha := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%dx%d alpha=%v", size.X, size.Y, withAlpha), func(t *testing.T) {
+				original := testImage(size.X, size.Y, withAlpha)
+
+				var buffer bytes.Buffer
+				if err := Encode(&buffer, original); err != nil {
+					t.Fatal(err)
+				}
+
+				decoded, err := xwebp.Decode(bytes.NewReader(buffer.Bytes()))
+				if err != nil {
+					t.Fatalf("invalid WebP image: %v", err)
+				}
+
+				if decoded.Bounds().Size() != size {
+					t.Fatalf("decoded size %v, expected %v", decoded.Bounds().Size(), size)
+				}
+				for y := 0; y < size.Y; y++ {
+					for x := 0; x < size.X; x++ {
+						expected := original.NRGBAAt(x, y)
+						actual := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+						if actual != expected {
+							t.Fatalf("pixel (%d, %d) is %v, expected %v", x, y, actual, expected)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestEncodeRuns(t *testing.T) {
+	// A single color image is encoded almost entirely with runs
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []byte{0xaa, 0xbb, 0xcc, 0xff})
+	}
+
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Len() > 1024 {
+		t.Errorf("single color image too big: %d bytes", buffer.Len())
+	}
+
+	decoded, err := xwebp.Decode(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(decoded.At(299, 199)).(color.NRGBA); c != (color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}) {
+		t.Errorf("unexpected color %v", c)
+	}
+}
+
+func TestEncodeTooBig(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, MaxDimension+1, 1))
+	if err := Encode(&bytes.Buffer{}, img); err == nil {
+		t.Error("expected an error encoding a too big image")
+	}
+}
+
+func TestDecodeSize(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, testImage(123, 45, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	width, height, err := DecodeSize(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	} else if width != 123 || height != 45 {
+		t.Errorf("decoded size %dx%d, expected 123x45", width, height)
+	}
+
+	if _, _, err := DecodeSize([]byte("not a WebP image at all, really")); err == nil {
+		t.Error("expected an error decoding an invalid header")
+	}
+}
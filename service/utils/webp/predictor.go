@@ -0,0 +1,116 @@
+package webp
+
+// choosePredictorModes picks, for each block of the image, the predictor
+// which gives the smallest residuals. Modes are stored in the green channel.
+func choosePredictorModes(pixels []uint32, width int, height int) []uint32 {
+	blockSize := 1 << predictorBits
+	blocksX := (width + blockSize - 1) >> predictorBits
+	blocksY := (height + blockSize - 1) >> predictorBits
+
+	modes := make([]uint32, blocksX*blocksY)
+	for blockY := 0; blockY < blocksY; blockY++ {
+		for blockX := 0; blockX < blocksX; blockX++ {
+			bestMode, bestCost := predictorModes[0], -1
+			for _, mode := range predictorModes {
+				cost := 0
+				for y := blockY * blockSize; y < height && y < (blockY+1)*blockSize; y++ {
+					for x := blockX * blockSize; x < width && x < (blockX+1)*blockSize; x++ {
+						cost += residualCost(pixels[y*width+x], predict(pixels, width, x, y, mode))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[blockY*blocksX+blockX] = 0xff000000 | uint32(bestMode)<<8
+		}
+	}
+	return modes
+}
+
+// predictorResiduals returns the difference between each pixel and its prediction
+func predictorResiduals(pixels []uint32, width int, height int, modes []uint32) []uint32 {
+	blocksX := (width + (1 << predictorBits) - 1) >> predictorBits
+	residuals := make([]uint32, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := int((modes[(y>>predictorBits)*blocksX+(x>>predictorBits)] >> 8) & 0xff)
+			residuals[y*width+x] = subPixels(pixels[y*width+x], predict(pixels, width, x, y, mode))
+		}
+	}
+	return residuals
+}
+
+// predict computes the prediction of a pixel from the already decoded ones.
+// The first row and the first column have fixed predictors.
+func predict(pixels []uint32, width int, x int, y int, mode int) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return pixels[x-1]
+	case x == 0:
+		return pixels[(y-1)*width]
+	}
+
+	left := pixels[y*width+x-1]
+	top := pixels[(y-1)*width+x]
+	switch mode {
+	case predictorLeft:
+		return left
+	case predictorTop:
+		return top
+	case predictorAverageLeftTop:
+		return average2(left, top)
+	case predictorClampAddSubtract:
+		return clampAddSubtractFull(left, top, pixels[(y-1)*width+x-1])
+	default:
+		panic("webp: unsupported predictor mode")
+	}
+}
+
+func average2(a uint32, b uint32) uint32 {
+	var result uint32
+	for shift := 0; shift < 32; shift += 8 {
+		channel := (((a >> shift) & 0xff) + ((b >> shift) & 0xff)) / 2
+		result |= channel << shift
+	}
+	return result
+}
+
+func clampAddSubtractFull(a uint32, b uint32, c uint32) uint32 {
+	var result uint32
+	for shift := 0; shift < 32; shift += 8 {
+		channel := int((a>>shift)&0xff) + int((b>>shift)&0xff) - int((c>>shift)&0xff)
+		if channel < 0 {
+			channel = 0
+		} else if channel > 0xff {
+			channel = 0xff
+		}
+		result |= uint32(channel) << shift
+	}
+	return result
+}
+
+// subPixels subtracts each channel, modulo 256
+func subPixels(a uint32, b uint32) uint32 {
+	var result uint32
+	for shift := 0; shift < 32; shift += 8 {
+		result |= (((a >> shift) - (b >> shift)) & 0xff) << shift
+	}
+	return result
+}
+
+// residualCost estimates how expensive is to encode a residual, as the sum of its absolute values
+func residualCost(pixel uint32, prediction uint32) int {
+	residual := subPixels(pixel, prediction)
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		channel := int(int8(residual >> shift))
+		if channel < 0 {
+			channel = -channel
+		}
+		cost += channel
+	}
+	return cost
+}
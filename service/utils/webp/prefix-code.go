@@ -0,0 +1,214 @@
+package webp
+
+import "sort"
+
+// maxCodeLength is the maximum length of a prefix code, in bits
+const maxCodeLength = 15
+
+// maxCodeLengthCodeLength is the maximum length of the code used to write code lengths
+const maxCodeLengthCodeLength = 7
+
+// codeLengthCodeOrder is the order in which the code length code lengths are written
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// prefixCode is a canonical Huffman code.
+// Codes are stored bit reversed, so they can be written LSB first.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+}
+
+func (c prefixCode) write(bits *bitWriter, symbol int) {
+	bits.writeBits(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+}
+
+// writePrefixCode builds the optimal code for the given histogram and writes it
+func writePrefixCode(bits *bitWriter, histogram []uint32) prefixCode {
+	var usedSymbols []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			usedSymbols = append(usedSymbols, symbol)
+		}
+	}
+
+	if len(usedSymbols) <= 1 {
+		// A single symbol is encoded with a simple code, using 0 bits per symbol
+		symbol := 0
+		if len(usedSymbols) == 1 {
+			symbol = usedSymbols[0]
+		}
+		writeSimpleCode(bits, symbol)
+		return prefixCode{
+			lengths: make([]uint8, len(histogram)),
+			codes:   make([]uint16, len(histogram)),
+		}
+	}
+
+	code := buildPrefixCode(histogram, maxCodeLength)
+	writeNormalCode(bits, code.lengths)
+	return code
+}
+
+// writeSimpleCode writes a code with a single symbol, which must be less than 256
+func writeSimpleCode(bits *bitWriter, symbol int) {
+	bits.writeBits(1, 1) // Simple code
+	bits.writeBits(0, 1) // One symbol
+	if symbol < 2 {
+		bits.writeBits(0, 1)
+		bits.writeBits(uint32(symbol), 1)
+	} else {
+		bits.writeBits(1, 1)
+		bits.writeBits(uint32(symbol), 8)
+	}
+}
+
+// writeNormalCode writes the code lengths, themselves prefix coded.
+// Repetition codes are not used.
+func writeNormalCode(bits *bitWriter, lengths []uint8) {
+	histogram := make([]uint32, len(codeLengthCodeOrder))
+	for _, length := range lengths {
+		histogram[length]++
+	}
+
+	// The code length code must have at least 2 symbols to be a complete code
+	used := 0
+	for _, count := range histogram {
+		if count > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		if histogram[0] == 0 {
+			histogram[0] = 1
+		} else {
+			histogram[1] = 1
+		}
+	}
+
+	lengthCode := buildPrefixCode(histogram, maxCodeLengthCodeLength)
+
+	codesCount := 4
+	for i, symbol := range codeLengthCodeOrder {
+		if lengthCode.lengths[symbol] > 0 && i+1 > codesCount {
+			codesCount = i + 1
+		}
+	}
+
+	bits.writeBits(0, 1) // Normal code
+	bits.writeBits(uint32(codesCount-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:codesCount] {
+		bits.writeBits(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bits.writeBits(0, 1) // All the symbols are written
+
+	for _, length := range lengths {
+		lengthCode.write(bits, int(length))
+	}
+}
+
+// buildPrefixCode builds a canonical code with lengths not greater than maxLength.
+// When the optimal code is too long, the histogram is flattened until it fits.
+func buildPrefixCode(histogram []uint32, maxLength int) prefixCode {
+	var lengths []uint8
+	for minCount := uint32(1); ; minCount *= 2 {
+		lengths = huffmanLengths(histogram, minCount)
+		if maxOf(lengths) <= maxLength {
+			break
+		}
+	}
+
+	// Canonical codes, as in DEFLATE
+	lengthsCount := make([]int, maxLength+1)
+	for _, length := range lengths {
+		if length > 0 {
+			lengthsCount[length]++
+		}
+	}
+	nextCode := make([]int, maxLength+1)
+	code := 0
+	for length := 1; length <= maxLength; length++ {
+		code = (code + lengthsCount[length-1]) << 1
+		nextCode[length] = code
+	}
+
+	codes := make([]uint16, len(lengths))
+	for symbol, length := range lengths {
+		if length > 0 {
+			codes[symbol] = reverseBits(uint16(nextCode[length]), int(length))
+			nextCode[length]++
+		}
+	}
+
+	return prefixCode{lengths: lengths, codes: codes}
+}
+
+// huffmanLengths computes the code length of each symbol, raising the counts
+// of the used symbols to at least minCount. At least 2 symbols must be used.
+func huffmanLengths(histogram []uint32, minCount uint32) []uint8 {
+	type node struct {
+		symbol int
+		count  uint64
+		parent int
+	}
+
+	var leaves []node
+	for symbol, count := range histogram {
+		if count > 0 {
+			if count < minCount {
+				count = minCount
+			}
+			leaves = append(leaves, node{symbol: symbol, count: uint64(count)})
+		}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool {
+		return leaves[i].count < leaves[j].count
+	})
+
+	// Two queues algorithm: internal nodes are created in increasing count order
+	nodes := append(leaves, make([]node, 0, len(leaves)-1)...)
+	nextLeaf, nextInternal := 0, len(leaves)
+	popSmallest := func() int {
+		if nextLeaf < len(leaves) && (nextInternal >= len(nodes) || nodes[nextLeaf].count <= nodes[nextInternal].count) {
+			nextLeaf++
+			return nextLeaf - 1
+		}
+		nextInternal++
+		return nextInternal - 1
+	}
+	for len(nodes) < 2*len(leaves)-1 {
+		first, second := popSmallest(), popSmallest()
+		nodes = append(nodes, node{count: nodes[first].count + nodes[second].count, parent: -1})
+		nodes[first].parent = len(nodes) - 1
+		nodes[second].parent = len(nodes) - 1
+	}
+
+	// Parents always come after their children
+	depths := make([]int, len(nodes))
+	for i := len(nodes) - 2; i >= 0; i-- {
+		depths[i] = depths[nodes[i].parent] + 1
+	}
+
+	lengths := make([]uint8, len(histogram))
+	for i, leaf := range nodes[:len(leaves)] {
+		lengths[leaf.symbol] = uint8(depths[i])
+	}
+	return lengths
+}
+
+func maxOf(lengths []uint8) int {
+	result := 0
+	for _, length := range lengths {
+		if int(length) > result {
+			result = int(length)
+		}
+	}
+	return result
+}
+
+func reverseBits(code uint16, length int) uint16 {
+	var result uint16
+	for i := 0; i < length; i++ {
+		result = result<<1 | (code>>i)&1
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidHeader = errors.New("webp: invalid header")
+
+// DecodeSize reads the width and height of a WebP image, without decoding it.
+// Both the lossy and the lossless formats are supported, as well as the extended one.
+func DecodeSize(data []byte) (width int, height int, err error) {
+	const chunkStart = 20
+	if len(data) < chunkStart+10 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, errInvalidHeader
+	}
+
+	chunk := data[chunkStart:]
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// Frame tag (3 bytes), start code (3 bytes), then 14 bits for each dimension
+		if chunk[3] != 0x9d || chunk[4] != 0x01 || chunk[5] != 0x2a {
+			return 0, 0, errInvalidHeader
+		}
+		width = int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff)
+	case "VP8L":
+		// Signature, then 14 bits for each dimension minus one
+		if chunk[0] != 0x2f {
+			return 0, 0, errInvalidHeader
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		width = int(bits&0x3fff) + 1
+		height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		// Flags (4 bytes), then 24 bits for each canvas dimension minus one
+		width = int(uint32(chunk[4])|uint32(chunk[5])<<8|uint32(chunk[6])<<16) + 1
+		height = int(uint32(chunk[7])|uint32(chunk[8])<<8|uint32(chunk[9])<<16) + 1
+	default:
+		return 0, 0, errInvalidHeader
+	}
+
+	if width == 0 || height == 0 {
+		return 0, 0, errInvalidHeader
+	}
+	return width, height, nil
+}
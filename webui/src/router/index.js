@@ -10,6 +10,11 @@ import FollowersView from "../views/FollowersView.vue";
 import FollowingsView from "../views/FollowingsView.vue";
 import PhotoUploadView from "../views/PhotoUploadView.vue";
 import CommentsView from "../views/CommentsView.vue";
+import EmailLinkView from "../views/EmailLinkView.vue";
+import FollowRequestsView from "../views/FollowRequestsView.vue";
+import BannedUsersView from "../views/BannedUsersView.vue";
+import CloseFriendsView from "../views/CloseFriendsView.vue";
+import DataExportView from "../views/DataExportView.vue";
 
 const router = createRouter({
 	history: createWebHashHistory(import.meta.env.BASE_URL),
@@ -19,16 +24,22 @@ const router = createRouter({
 		{path: '/search', component: SearchUsers},
 		{path: '/login', component: LoginView},
 		{path: '/me/edit', component: EditAccountView},
+		{path: '/me/followRequests', component: FollowRequestsView},
+		{path: '/me/banned', component: BannedUsersView},
+		{path: '/me/closeFriends', component: CloseFriendsView},
+		{path: '/me/export', component: DataExportView},
 		{path: '/users/:username', component: SingleUserView},
 		{path: '/users/:username/followers', component: FollowersView},
 		{path: '/users/:username/followings', component: FollowingsView},
 		{path: '/upload', component: PhotoUploadView},
 		{path: '/photos/:photoId/comments', component: CommentsView},
+		{path: '/verify-email', component: EmailLinkView},
+		{path: '/reset-password', component: EmailLinkView},
 	]
 });
 
 router.beforeEach((to, from, next) => {
-	const goingToAnonymous = to.path === '/login';
+	const goingToAnonymous = ['/login', '/verify-email', '/reset-password'].includes(to.path);
 	const isAuthenticated = getCurrentUID();
 	if (goingToAnonymous || isAuthenticated) {
 		// Allow routing
@@ -14,6 +14,7 @@ export function handleApiError(response) {
 		case 404: throw new NotFoundError('Item not found');
 		case 409: throw new ConflictError(null);
 		case 413: throw new TooLargeError();
+		case 429: throw new TooManyRequestsError(response.headers['retry-after']);
         case 500: throw new ServerError();
 		case 503: throw new ThirdPartyError();
 		default: throw new Error('Unexpected error received from server: ' + response.status);
@@ -52,6 +53,12 @@ export class AuthError extends Error {
     }
 }
 
+export class SecondFactorRequiredError extends Error {
+    constructor() {
+        super("Insert the code from your authenticator app, or a recovery code");
+    }
+}
+
 export class ForbiddenError extends Error {
     constructor() {
         super("You are not authorized to perform this action");
@@ -75,3 +82,15 @@ export class TooLargeError extends Error {
 		super("The file you're uploading is too big");
 	}
 }
+
+export class TooManyRequestsError extends Error {
+	/**
+	 * Too many attempts, with the optional time to wait
+	 * @param {string|undefined} retryAfter Seconds to wait before trying again
+	 */
+	constructor(retryAfter) {
+		super(retryAfter
+			? `Too many attempts, try again in ${retryAfter} seconds`
+			: 'Too many attempts, try again later');
+	}
+}
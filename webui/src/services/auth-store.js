@@ -1,21 +1,26 @@
 const AUTH_TOKEN_KEY = 'auth-token';
+const USER_ID_KEY = 'user-id';
 
 /**
  * Save the new auth token received
  * @param {string|null} authToken
+ * @param {string|null} [userId] ID of the user the token belongs to
  * @param {boolean} [keepSignedIn] Keep me signed in
  */
-export function saveAuthToken(authToken, keepSignedIn) {
+export function saveAuthToken(authToken, userId, keepSignedIn) {
 	const storage = keepSignedIn ? localStorage : sessionStorage;
 	const resetStorage = keepSignedIn ? sessionStorage : localStorage;
 
     if (authToken) {
 		storage.setItem(AUTH_TOKEN_KEY, authToken);
+		storage.setItem(USER_ID_KEY, userId);
 	} else {
 		storage.removeItem(AUTH_TOKEN_KEY);
+		storage.removeItem(USER_ID_KEY);
 	}
 
 	resetStorage.removeItem(AUTH_TOKEN_KEY);
+	resetStorage.removeItem(USER_ID_KEY);
 }
 
 /**
@@ -33,10 +38,15 @@ export function getAuthToken() {
 
 /**
  * Retrieve the ID of the current user.
- * It may be different from the auth token, not in this case.
+ * It's different from the auth token, which identifies the session.
  *
  * @returns {string|null} Current logged-in user ID
  */
 export function getCurrentUID() {
-    return getAuthToken();
+	const persistingUID = localStorage.getItem(USER_ID_KEY);
+	if (persistingUID) {
+		return persistingUID;
+	}
+
+	return sessionStorage.getItem(USER_ID_KEY);
 }
@@ -1,50 +1,165 @@
-import { handleApiError } from './api-errors';
-import { saveAuthToken } from './auth-store';
+import { handleApiError, SecondFactorRequiredError } from './api-errors';
+import { getCurrentUID, saveAuthToken } from './auth-store';
 import api from './axios';
 
 /**
  * @typedef {Object} UserLoginResult
  * User Login Result
- * @property {string} userId Auth token and user ID
+ * @property {string} userId ID of the logged-in user
  * @property {boolean} isNewUser Indicates whether the operation was a login or a sign up
  */
 
+/**
+ * Save the session obtained from a login or sign up response
+ * @param {import("axios").AxiosResponse<any, any>} response Login or sign up response
+ * @param {boolean} keepSignedIn Keep me signed in
+ * @returns {UserLoginResult}
+ */
+function handleLoginResponse(response, keepSignedIn) {
+    // Save new auth token
+    if (response.status >= 200 && response.status < 300 && response.data.token) {
+        saveAuthToken(response.data.token, response.data.userId, keepSignedIn);
+    }
+
+    if (response.status === 401 && `${response.data}`.startsWith('second factor required')) {
+        throw new SecondFactorRequiredError();
+    }
+
+    if (response.status === 200) {
+        return {
+            userId: response.data.userId,
+            isNewUser: false,
+        };
+    } else if (response.status === 201) {
+        return {
+            userId: response.data.userId,
+            isNewUser: true,
+        };
+    } else {
+        handleApiError(response);
+    }
+}
+
 export const AuthService = Object.freeze({
     /**
-     * Login or sign up
+     * Login with existing credentials
      * @param {string} username User username
+     * @param {string} password User password
+     * @param {string|null} otp One-time password, if two-factor authentication is enabled
 	 * @param {boolean} keepSignedIn Keep me signed in
      * @returns {Promise<UserLoginResult>}
      */
-    async doLogin(username, keepSignedIn) {
+    async doLogin(username, password, otp, keepSignedIn) {
         const response = await api.post('/session', {
             username: username,
+            password: password,
+            otp: otp || undefined,
+        });
+        return handleLoginResponse(response, keepSignedIn);
+    },
+
+    /**
+     * Sign up a new user
+     * @param {string} username New user username
+     * @param {string} password New user password
+     * @param {string} email New user email, which must be verified
+	 * @param {boolean} keepSignedIn Keep me signed in
+     * @returns {Promise<UserLoginResult>}
+     */
+    async doSignup(username, password, email, keepSignedIn) {
+        const response = await api.post('/users/', {
+            username: username,
+            password: password,
+            email: email,
         });
+        return handleLoginResponse(response, keepSignedIn);
+    },
 
-        // Save new auth token
-        if (response.status >= 200 && response.status < 300 && response.data.userId) {
-            saveAuthToken(response.data.userId, keepSignedIn);
+    /**
+     * Start the login through the external provider
+     * @returns {Promise<string>} URL of the provider login page, where the user must be sent
+     */
+    async startExternalLogin() {
+        const response = await api.post('/session/oidc');
+        if (response.status !== 200) {
+            handleApiError(response);
         }
+        return response.data.authorizationUrl;
+    },
 
-        if (response.status === 200) {
-            return {
-                userId: response.data.userId,
-                isNewUser: false,
-            };
-        } else if (response.status === 201) {
-            return {
-                userId: response.data.userId,
-                isNewUser: true,
-            };
-        } else {
+    /**
+     * Complete the login through the external provider
+     * @param {string} code Authorization code sent back by the provider
+     * @param {string} state State sent back by the provider
+     * @param {boolean} keepSignedIn Keep me signed in
+     * @returns {Promise<UserLoginResult>}
+     */
+    async completeExternalLogin(code, state, keepSignedIn) {
+        const response = await api.post('/session/oidc/callback', {
+            code: code,
+            state: state,
+        });
+        return handleLoginResponse(response, keepSignedIn);
+    },
+
+    /**
+     * Verify the email address, using the token sent to it
+     * @param {string} token Token from the verification link
+     */
+    async verifyEmail(token) {
+        const response = await api.post('/email-verification', {
+            token: token,
+        });
+        if (response.status !== 204) {
             handleApiError(response);
         }
     },
 
     /**
-     * Logout current user
+     * Ask for a password reset link, sent to the given email if it belongs to a user
+     * @param {string} email Verified email of the user
      */
-    logout() {
+    async requestPasswordReset(email) {
+        const response = await api.post('/password-reset', {
+            email: email,
+        });
+        if (response.status !== 202) {
+            handleApiError(response);
+        }
+    },
+
+    /**
+     * Set a new password, using the token sent by email
+     * @param {string} token Token from the password reset link
+     * @param {string} newPassword New password
+     */
+    async resetPassword(token, newPassword) {
+        const response = await api.put('/password-reset', {
+            token: token,
+            newPassword: newPassword,
+        });
+        if (response.status !== 204) {
+            handleApiError(response);
+        }
+    },
+
+    /**
+     * Logout current user, revoking the current session
+     */
+    async logout() {
+        await api.delete('/session');
+        saveAuthToken(null);
+    },
+
+    /**
+     * Deactivate the account of the current user, hiding it until the next login.
+     * Every session is revoked, including the current one.
+     */
+    async deactivateAccount() {
+        const response = await api.put(`/users/${getCurrentUID()}/deactivation`);
+        if (response.status !== 204) {
+            handleApiError(response);
+        }
         saveAuthToken(null);
     }
 });
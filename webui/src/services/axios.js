@@ -1,5 +1,5 @@
 import axios from "axios";
-import {getCurrentUID, saveAuthToken} from "./auth-store";
+import {getAuthToken, saveAuthToken} from "./auth-store";
 import router from "../router";
 
 export const api = axios.create({
@@ -9,12 +9,12 @@ export const api = axios.create({
 });
 
 api.interceptors.request.use(config => {
-	config.headers['Authorization'] = 'Bearer ' + getCurrentUID();
+	config.headers['Authorization'] = 'Bearer ' + getAuthToken();
 	return config;
 });
 
 api.interceptors.response.use(async response => {
-	if (response && response.status === 401) {
+	if (response && response.status === 401 && router.currentRoute.value.path !== '/login') {
 		// Logout!
 		saveAuthToken(null);
 		await router.redirectToLogin();
@@ -3,6 +3,24 @@ import {getCurrentUID} from "./auth-store";
 import {ConflictError, handleApiError, NotFoundError} from "./api-errors";
 
 export const BanService = Object.freeze({
+	/**
+	 * List the users I banned
+	 * @param {string?} pageCursor
+	 */
+	async listMyBannedUsers(pageCursor) {
+		let apiPath = `/users/${getCurrentUID()}/bannedPeople/`;
+		if (pageCursor) {
+			apiPath += '?pageCursor=' + encodeURIComponent(pageCursor);
+		}
+
+		const response = await api.get(apiPath);
+
+		switch (response.status) {
+			case 200: return response.data;
+			default: handleApiError(response);
+		}
+	},
+
 	/**
 	 * Ban a user
 	 * @param blockedId ID of the user to ban
@@ -0,0 +1,50 @@
+import api from "./axios";
+import {getCurrentUID} from "./auth-store";
+import {ConflictError, handleApiError, NotFoundError} from "./api-errors";
+
+export const CloseFriendsService = Object.freeze({
+	/**
+	 * List my close friends
+	 * @param {string?} pageCursor
+	 */
+	async listMyCloseFriends(pageCursor) {
+		let apiPath = `/users/${getCurrentUID()}/closeFriends/`;
+		if (pageCursor) {
+			apiPath += '?pageCursor=' + encodeURIComponent(pageCursor);
+		}
+
+		const response = await api.get(apiPath);
+
+		switch (response.status) {
+			case 200: return response.data;
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Add a user to my close friends
+	 * @param friendId ID of the user to add
+	 */
+	async addCloseFriend(friendId) {
+		const response = await api.put(`/users/${getCurrentUID()}/closeFriends/${friendId}`);
+
+		switch (response.status) {
+			case 200: case 201: return;
+			case 404: throw new NotFoundError("User to add not found");
+			case 409: throw new ConflictError("You cannot add yourself");
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Remove a user from my close friends
+	 * @param friendId ID of the user to remove
+	 */
+	async removeCloseFriend(friendId) {
+		const response = await api.delete(`/users/${getCurrentUID()}/closeFriends/${friendId}`);
+
+		if (response.status !== 204) {
+			handleApiError(response);
+		}
+	},
+});
@@ -0,0 +1,62 @@
+import api from "./axios";
+import {getCurrentUID} from "./auth-store";
+import {BadRequestError, handleApiError} from "./api-errors";
+
+export const ExportService = Object.freeze({
+	/**
+	 * Request an export of all my data, built in background
+	 */
+	async requestExport() {
+		const response = await api.post(`/users/${getCurrentUID()}/export`);
+
+		switch (response.status) {
+			case 202: return response.data;
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Get my last export, or null if there's none
+	 */
+	async getLastExport() {
+		const response = await api.get(`/users/${getCurrentUID()}/export`);
+
+		switch (response.status) {
+			case 200: return response.data;
+			case 404: return null;
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Create a short-lived link to download my last export, once it's ready
+	 * @returns {Promise<string>} The URL of the archive
+	 */
+	async createDownloadLink() {
+		const response = await api.post(`/users/${getCurrentUID()}/export/links`);
+
+		switch (response.status) {
+			case 201: return response.data.url;
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Import an archive downloaded from an export into my account
+	 * @param {File} archiveFile Zip archive to import
+	 * @param {boolean} dryRun Only check what would be imported
+	 * @returns The report of the import
+	 */
+	async importArchive(archiveFile, dryRun) {
+		const response = await api.post(`/users/${getCurrentUID()}/import?dryRun=${dryRun}`, await archiveFile.arrayBuffer(), {
+			headers: {'Content-Type': 'application/zip'},
+			timeout: 60000,
+		});
+
+		switch (response.status) {
+			case 200: return response.data;
+			case 415: throw new BadRequestError('Selected file is not an archive of a data export');
+			default: handleApiError(response);
+		}
+	},
+});
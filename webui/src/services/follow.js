@@ -59,15 +59,80 @@ export const FollowService = Object.freeze({
     /**
      * Follow a user
      * @param {string} followedId
+     * @returns {Promise<boolean>} true if the account is private and a follow request has been sent instead
      */
      async followUser(followedId) {
         const response = await api.put(`/users/${getCurrentUID()}/followings/${followedId}`);
 
         switch (response.status) {
-            case 200: case 201: return;
+            case 200: case 201: return false;
+            case 202: return true;
             case 404: throw new NotFoundError('User to follow not found');
 			case 409: throw new ConflictError(response.data);
             default: handleApiError(response);
         }
+    },
+
+    /**
+     * List the users I may want to follow
+     * @param {string?} pageCursor
+     */
+    async listMySuggestions(pageCursor) {
+        let apiPath = `/users/${getCurrentUID()}/suggestions`;
+        if (pageCursor) {
+            apiPath += '?pageCursor=' + encodeURIComponent(pageCursor);
+        }
+
+        const response = await api.get(apiPath);
+
+        switch (response.status) {
+            case 200: return response.data;
+            default: handleApiError(response);
+        }
+    },
+
+    /**
+     * List the users who asked to follow me
+     * @param {string?} pageCursor
+     */
+    async listMyFollowRequests(pageCursor) {
+        let apiPath = `/users/${getCurrentUID()}/followRequests/`;
+        if (pageCursor) {
+            apiPath += '?pageCursor=' + encodeURIComponent(pageCursor);
+        }
+
+        const response = await api.get(apiPath);
+
+        switch (response.status) {
+            case 200: return response.data;
+            default: handleApiError(response);
+        }
+    },
+
+    /**
+     * Let a user follow me
+     * @param {string} requesterId
+     */
+    async approveFollowRequest(requesterId) {
+        const response = await api.put(`/users/${getCurrentUID()}/followRequests/${requesterId}`);
+
+        switch (response.status) {
+            case 200: return;
+            case 404: throw new NotFoundError('Follow request not found');
+            default: handleApiError(response);
+        }
+    },
+
+    /**
+     * Discard a follow request
+     * @param {string} requesterId
+     */
+    async denyFollowRequest(requesterId) {
+        const response = await api.delete(`/users/${getCurrentUID()}/followRequests/${requesterId}`);
+
+        switch (response.status) {
+            case 204: return;
+            default: handleApiError(response);
+        }
     }
 });
@@ -0,0 +1,47 @@
+import api from "./axios";
+import {getCurrentUID} from "./auth-store";
+import {ConflictError, handleApiError, NotFoundError} from "./api-errors";
+
+export const MuteService = Object.freeze({
+	/**
+	 * Check if I muted a user
+	 * @param mutedId ID of the user to check
+	 * @returns {Promise<boolean>}
+	 */
+	async isMuted(mutedId) {
+		const response = await api.get(`/users/${getCurrentUID()}/mutedPeople/${mutedId}`);
+
+		switch (response.status) {
+			case 200: return true;
+			case 404: return false;
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Mute a user
+	 * @param mutedId ID of the user to mute
+	 */
+	async muteUser(mutedId) {
+		const response = await api.put(`/users/${getCurrentUID()}/mutedPeople/${mutedId}`);
+
+		switch (response.status) {
+			case 200: case 201: return;
+			case 404: throw new NotFoundError("User to mute not found");
+			case 409: throw new ConflictError("You cannot mute yourself");
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Unmute a user
+	 * @param mutedId ID of the user to unmute
+	 */
+	async unmuteUser(mutedId) {
+		const response = await api.delete(`/users/${getCurrentUID()}/mutedPeople/${mutedId}`);
+
+		if (response.status !== 204) {
+			handleApiError(response);
+		}
+	},
+});
@@ -0,0 +1,18 @@
+/**
+ * Build the srcset attribute of a photo, listing all its renditions
+ * @param {Object} photo Photo returned by the API
+ * @returns {string} The srcset value, empty if the width of the renditions is unknown
+ */
+export function photoSrcset(photo) {
+	return (photo.images || []).filter(image => image.width).map(image => `${image.url} ${image.width}w`).join(', ');
+}
+
+/**
+ * Get the URL of the smallest rendition of a photo, to be shown in grids
+ * @param {Object} photo Photo returned by the API
+ * @returns {string} The thumbnail URL, or the full image one if the photo has no renditions
+ */
+export function thumbnailUrl(photo) {
+	const images = photo.images || [];
+	return images.length > 0 ? images[0].url : photo.imageUrl;
+}
@@ -25,10 +25,11 @@ export const PhotosService = Object.freeze({
 	/**
 	 * Upload photo
 	 * @param {File} photoFile
+	 * @param {string} audience Who can see the photo: everyone, followers or closeFriends
 	 * @param {Function} onProgress Progress callback
 	 */
-	async uploadPhoto(photoFile, onProgress) {
-		const response = await api.post('/photos/', await photoFile.arrayBuffer(), {
+	async uploadPhoto(photoFile, audience, onProgress) {
+		const response = await api.post('/photos/?audience=' + audience, await photoFile.arrayBuffer(), {
 			timeout: 60000, // Enlarge timeout for photo upload, check if enough on server side
 			onUploadProgress: progressEvent => onProgress(100.0 * progressEvent.loaded / progressEvent.total),
 		});
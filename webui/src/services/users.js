@@ -1,11 +1,11 @@
 import api from "./axios";
-import {ConflictError, handleApiError, NotFoundError} from "./api-errors";
+import {BadRequestError, ConflictError, handleApiError, NotFoundError, ThirdPartyError} from "./api-errors";
 import {getCurrentUID} from "./auth-store";
 
 export const UsersService = Object.freeze({
 	/**
-	 * Search users by username, performing a text search
-	 * @param {string} username Username or partial username to search
+	 * Search users by username, name or surname, performing a text search
+	 * @param {string} username Text to search
 	 * @param {string|null} pageCursor Search page cursor
 	 * @returns Promise<any>
 	 */
@@ -40,6 +40,20 @@ export const UsersService = Object.freeze({
 		}
 	},
 
+	/**
+	 * Get how I'm related to another user
+	 * @param otherId ID of the other user
+	 */
+	async getMyRelationshipWith(otherId) {
+		const response = await api.get(`/users/${getCurrentUID()}/relationships/${otherId}`);
+
+		switch (response.status) {
+			case 200: return response.data;
+			case 404: throw new NotFoundError('User not found');
+			default: handleApiError(response);
+		}
+	},
+
 	/**
 	 * Set my user details.
 	 * @param {string} userDetails.name
@@ -70,6 +84,47 @@ export const UsersService = Object.freeze({
 			case 409: throw new ConflictError("Username already taken");
 			default: handleApiError(response);
 		}
+	},
+
+	/**
+	 * Set my profile picture
+	 * @param {File} imageFile
+	 * @returns {Promise<Object>} Updated user
+	 */
+	async setMyAvatar(imageFile) {
+		const response = await api.put(`/users/${getCurrentUID()}/avatar`, await imageFile.arrayBuffer(), {
+			timeout: 60000, // Same as photos, since it's processed the same way
+		});
+
+		switch (response.status) {
+			case 200: return response.data;
+			case 415: throw new BadRequestError('Selected file cannot be processed as an image');
+			case 503: throw new ThirdPartyError();
+			default: handleApiError(response);
+		}
+	},
+
+	/**
+	 * Remove my profile picture
+	 */
+	async deleteMyAvatar() {
+		const response = await api.delete(`/users/${getCurrentUID()}/avatar`);
+
+		if (response.status !== 204) {
+			handleApiError(response);
+		}
+	},
+
+	/**
+	 * Make my account private or public
+	 * @param {boolean} isPrivate
+	 */
+	async setMyPrivacy(isPrivate) {
+		const response = await api.put(`/users/${getCurrentUID()}/privacy`, {private: isPrivate});
+
+		if (response.status !== 200) {
+			handleApiError(response);
+		}
 	}
 });
 